@@ -0,0 +1,357 @@
+// Command renderbench reports the time and allocations of every renderall
+// engine, for small and large payloads, buffered and streamed, next to the
+// plain standard library approaches they replace. Output is in the format
+// of go test -bench, so runs can be compared with benchstat:
+//
+//	go run ./cmd/renderbench -count 10 > old.txt
+//	# make a change
+//	go run ./cmd/renderbench -count 10 > new.txt
+//	benchstat old.txt new.txt
+//
+// Given -baseline, it also acts as a regression gate, exiting 1 when a
+// benchmark's time or allocations per op grow by more than -threshold
+// percent of the baseline's:
+//
+//	go run ./cmd/renderbench -count 5 -baseline old.txt
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pandemicsyn/electrostatic/renderall"
+)
+
+// discard is a ResponseWriter that throws the response away, so only the
+// render itself is measured.
+type discard struct {
+	h http.Header
+}
+
+func (d discard) Header() http.Header         { return d.h }
+func (d discard) WriteHeader(int)             {}
+func (d discard) Write(b []byte) (int, error) { return len(b), nil }
+
+type user struct {
+	ID    int      `json:"id" xml:"id,attr"`
+	Name  string   `json:"name" xml:"name"`
+	Email string   `json:"email" xml:"email"`
+	Tags  []string `json:"tags" xml:"tag"`
+}
+
+type userList struct {
+	XMLName xml.Name `xml:"users"`
+	Users   []user   `xml:"user"`
+}
+
+func makeUsers(n int) []user {
+	us := make([]user, n)
+	for i := range us {
+		us[i] = user{ID: i, Name: "Ada Lovelace", Email: "ada@example.com", Tags: []string{"admin", "<staff>"}}
+	}
+	return us
+}
+
+// payloads are the sizes every engine is measured with.
+var payloads = []struct {
+	name  string
+	users []user
+}{
+	{"small", makeUsers(1)},
+	{"large", makeUsers(1000)},
+}
+
+var templates = fstest.MapFS{
+	"layout.tmpl": {Data: []byte(`<html><body>{{ yield }}</body></html>`)},
+	"users.tmpl":  {Data: []byte(`<ul>{{ range . }}<li id="{{ .ID }}">{{ .Name }} &lt;{{ .Email }}&gt;{{ range .Tags }} {{ . }}{{ end }}</li>{{ end }}</ul>`)},
+	"users.txt.tmpl": {Data: []byte(`{{ range . }}{{ .ID }} {{ .Name }} <{{ .Email }}>
+{{ end }}`)},
+}
+
+// marshalJSON is the marshal, append, and two writes approach used before
+// JSON renders were pooled.
+func marshalJSON(w http.ResponseWriter, prefix []byte, indent bool, v interface{}) error {
+	var result []byte
+	var err error
+	if indent {
+		result, err = json.MarshalIndent(v, "", "  ")
+		result = append(result, '\n')
+	} else {
+		result, err = json.Marshal(v)
+	}
+	if err != nil {
+		return err
+	}
+	w.Header().Set(renderall.ContentType, renderall.ContentJSON)
+	w.WriteHeader(http.StatusOK)
+	if len(prefix) > 0 {
+		w.Write(prefix)
+	}
+	w.Write(result)
+	return nil
+}
+
+// benchmark is one measurement, named like a go test sub-benchmark.
+type benchmark struct {
+	name string
+	fn   func(b *testing.B)
+}
+
+// loop returns a benchmark function running render b.N times against a
+// fresh discard writer.
+func loop(render func(w http.ResponseWriter) error) func(b *testing.B) {
+	return func(b *testing.B) {
+		b.ReportAllocs()
+		w := discard{http.Header{}}
+		for i := 0; i < b.N; i++ {
+			if err := render(w); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func benchmarks() []benchmark {
+	options := func(o renderall.Options) *renderall.Render {
+		o.DisableRedaction = true
+		o.Loader = renderall.FSLoader(templates)
+		return renderall.New(o)
+	}
+	r := options(renderall.Options{})
+	indented := options(renderall.Options{IndentJSON: true})
+	prefixed := options(renderall.Options{PrefixJSON: []byte(")]}',\n")})
+	streaming := options(renderall.Options{StreamingJSON: true})
+	etag := options(renderall.Options{ETag: true})
+	layout := options(renderall.Options{Layout: "layout"})
+	html := template.Must(template.New("users").Parse(string(templates["users.tmpl"].Data)))
+
+	var bs []benchmark
+	add := func(name string, render func(w http.ResponseWriter) error) {
+		bs = append(bs, benchmark{name, loop(render)})
+	}
+	for _, p := range payloads {
+		us := p.users
+		rows := make([][]string, len(us))
+		for i, u := range us {
+			rows[i] = []string{strconv.Itoa(u.ID), u.Name, u.Email}
+		}
+		body := bytes.Repeat([]byte("x"), 64*len(us))
+
+		add("JSON/marshal/"+p.name, func(w http.ResponseWriter) error {
+			return marshalJSON(w, nil, false, us)
+		})
+		add("JSON/pooled/"+p.name, func(w http.ResponseWriter) error {
+			return r.JSON(w, http.StatusOK, us)
+		})
+		add("JSON/indent-marshal/"+p.name, func(w http.ResponseWriter) error {
+			return marshalJSON(w, nil, true, us)
+		})
+		add("JSON/indent-pooled/"+p.name, func(w http.ResponseWriter) error {
+			return indented.JSON(w, http.StatusOK, us)
+		})
+		add("JSON/prefix/"+p.name, func(w http.ResponseWriter) error {
+			return prefixed.JSON(w, http.StatusOK, us)
+		})
+		add("JSON/streaming/"+p.name, func(w http.ResponseWriter) error {
+			return streaming.JSON(w, http.StatusOK, us)
+		})
+		add("JSON/buffered-etag/"+p.name, func(w http.ResponseWriter) error {
+			return etag.JSON(w, http.StatusOK, us)
+		})
+		add("JSONP/"+p.name, func(w http.ResponseWriter) error {
+			return r.JSONP(w, http.StatusOK, "callback", us)
+		})
+		add("XML/"+p.name, func(w http.ResponseWriter) error {
+			return r.XML(w, http.StatusOK, userList{Users: us})
+		})
+		add("CSV/"+p.name, func(w http.ResponseWriter) error {
+			return r.CSV(w, http.StatusOK, rows)
+		})
+		add("XLSX/"+p.name, func(w http.ResponseWriter) error {
+			return r.XLSX(w, http.StatusOK, rows)
+		})
+		add("HTML/direct/"+p.name, func(w http.ResponseWriter) error {
+			var buf bytes.Buffer
+			if err := html.Execute(&buf, us); err != nil {
+				return err
+			}
+			w.Header().Set(renderall.ContentType, renderall.ContentHTML)
+			w.WriteHeader(http.StatusOK)
+			_, err := buf.WriteTo(w)
+			return err
+		})
+		add("HTML/pooled/"+p.name, func(w http.ResponseWriter) error {
+			return r.HTML(w, http.StatusOK, "users", us)
+		})
+		add("HTML/layout/"+p.name, func(w http.ResponseWriter) error {
+			return layout.HTML(w, http.StatusOK, "users", us)
+		})
+		add("HTML/etag/"+p.name, func(w http.ResponseWriter) error {
+			return etag.HTML(w, http.StatusOK, "users", us)
+		})
+		add("TextTemplate/"+p.name, func(w http.ResponseWriter) error {
+			return r.TextTemplate(w, http.StatusOK, renderall.ContentText, "users.txt", us)
+		})
+		add("Data/"+p.name, func(w http.ResponseWriter) error {
+			return r.Data(w, http.StatusOK, body)
+		})
+		add("Zip/"+p.name, func(w http.ResponseWriter) error {
+			return r.Zip(w, http.StatusOK, []renderall.ArchiveEntry{{Name: "users.bin", Body: bytes.NewReader(body)}})
+		})
+	}
+	add("QRCode/small", func(w http.ResponseWriter) error {
+		return r.QRCode(w, http.StatusOK, "https://example.com/")
+	})
+	return bs
+}
+
+// result is the mean per op figures of a benchmark over its runs.
+type result struct {
+	ns, allocs float64
+	runs       int
+}
+
+func (r *result) add(ns, allocs float64) {
+	r.ns += ns
+	r.allocs += allocs
+	r.runs++
+}
+
+func (r result) mean() (float64, float64) {
+	return r.ns / float64(r.runs), r.allocs / float64(r.runs)
+}
+
+// readResults parses go test -bench output, such as a previous run's.
+func readResults(in io.Reader) (map[string]*result, error) {
+	results := map[string]*result{}
+	s := bufio.NewScanner(in)
+	for s.Scan() {
+		f := strings.Fields(s.Text())
+		if len(f) < 4 || !strings.HasPrefix(f[0], "Benchmark") {
+			continue
+		}
+		// Drop the -GOMAXPROCS suffix so runs on other machines still match.
+		name := f[0]
+		if i := strings.LastIndexByte(name, '-'); i > 0 {
+			if _, err := strconv.Atoi(name[i+1:]); err == nil {
+				name = name[:i]
+			}
+		}
+		var ns, allocs float64
+		for i := 2; i+1 < len(f); i += 2 {
+			v, err := strconv.ParseFloat(f[i], 64)
+			if err != nil {
+				continue
+			}
+			switch f[i+1] {
+			case "ns/op":
+				ns = v
+			case "allocs/op":
+				allocs = v
+			}
+		}
+		if results[name] == nil {
+			results[name] = &result{}
+		}
+		results[name].add(ns, allocs)
+	}
+	return results, s.Err()
+}
+
+// regressions compares current to baseline, describing each benchmark
+// that grew by more than threshold percent.
+func regressions(baseline, current map[string]*result, threshold float64) []string {
+	var out []string
+	grew := func(old, cur float64) bool {
+		return cur > old*(1+threshold/100) && cur-old >= 1
+	}
+	for name, cur := range current {
+		old, ok := baseline[name]
+		if !ok {
+			continue
+		}
+		oldNS, oldAllocs := old.mean()
+		curNS, curAllocs := cur.mean()
+		if grew(oldNS, curNS) {
+			out = append(out, fmt.Sprintf("%s: %.0f ns/op, was %.0f (%+.1f%%)", name, curNS, oldNS, (curNS/oldNS-1)*100))
+		}
+		if grew(oldAllocs, curAllocs) {
+			out = append(out, fmt.Sprintf("%s: %.0f allocs/op, was %.0f", name, curAllocs, oldAllocs))
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func main() {
+	testing.Init()
+	run := flag.String("run", "", "only run benchmarks matching this regular expression")
+	count := flag.Int("count", 1, "run each benchmark this many times")
+	benchtime := flag.String("benchtime", "1s", "run each benchmark for this long, or Nx times")
+	baseline := flag.String("baseline", "", "fail on regressions against this earlier output")
+	threshold := flag.Float64("threshold", 10, "percent growth over -baseline counted as a regression")
+	flag.Parse()
+
+	if err := flag.Set("test.benchtime", *benchtime); err != nil {
+		fmt.Fprintln(os.Stderr, "renderbench:", err)
+		os.Exit(2)
+	}
+	filter, err := regexp.Compile(*run)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "renderbench:", err)
+		os.Exit(2)
+	}
+
+	fmt.Printf("goos: %s\ngoarch: %s\npkg: github.com/pandemicsyn/electrostatic/renderall\n", runtime.GOOS, runtime.GOARCH)
+	var out bytes.Buffer
+	procs := runtime.GOMAXPROCS(0)
+	for _, bm := range benchmarks() {
+		if !filter.MatchString(bm.name) {
+			continue
+		}
+		for i := 0; i < *count; i++ {
+			res := testing.Benchmark(bm.fn)
+			line := fmt.Sprintf("Benchmark%s-%d\t%s\t%s\n", bm.name, procs, res, res.MemString())
+			fmt.Print(line)
+			out.WriteString(line)
+		}
+	}
+
+	if *baseline == "" {
+		return
+	}
+	f, err := os.Open(*baseline)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "renderbench:", err)
+		os.Exit(2)
+	}
+	defer f.Close()
+	old, err := readResults(f)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "renderbench:", err)
+		os.Exit(2)
+	}
+	current, _ := readResults(&out)
+	if found := regressions(old, current, *threshold); len(found) > 0 {
+		fmt.Fprintf(os.Stderr, "renderbench: regressions over %.0f%%:\n", *threshold)
+		for _, r := range found {
+			fmt.Fprintln(os.Stderr, "  "+r)
+		}
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,3 @@
+module github.com/pandemicsyn/electrostatic
+
+go 1.23
@@ -0,0 +1,287 @@
+package renderall
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"time"
+)
+
+const (
+	// ContentZip header value for zip archives.
+	ContentZip = "application/zip"
+	// ContentTar header value for tar archives.
+	ContentTar = "application/x-tar"
+	// ContentGzip header value for gzip compressed data.
+	ContentGzip = "application/gzip"
+)
+
+// ArchiveEntry is a file, or a tree of files, to add to an archive.
+type ArchiveEntry struct {
+	// Name is the slash separated path in the archive. For FS entries it is
+	// the directory the tree is added under; blank adds it at the root.
+	Name string
+	// Body is the file contents. It is closed after copying if it is an io.Closer.
+	Body io.Reader
+	// Write produces the file contents in place of Body.
+	Write func(w io.Writer) error
+	// Size of Body or of what Write produces, if known. Tar archives need
+	// it up front, so without it the contents are buffered in memory first.
+	Size int64
+	// FS adds every file in the tree in place of Body.
+	FS fs.FS
+	// Modified is the file time, defaulting to the engine's. FS files keep
+	// their own unless it is zero.
+	Modified time.Time
+}
+
+// ArchiveOptions is a struct for overriding some rendering Options for specific archive call.
+type ArchiveOptions struct {
+	// Download file name for the Content-Disposition header. Defaults to "archive" with the format's extension.
+	Filename string
+}
+
+// archiveFile is one file of an archive, produced only when it is written.
+type archiveFile struct {
+	name     string
+	modified time.Time
+	mode     fs.FileMode
+	// size is -1 when unknown until the contents are written.
+	size  int64
+	write func(w io.Writer) error
+}
+
+// walkArchive calls fn for every file the entries describe, in order.
+// Entries without a time get now, or the current time if it is zero.
+func walkArchive(entries []ArchiveEntry, now time.Time, fn func(archiveFile) error) error {
+	if now.IsZero() {
+		now = time.Now()
+	}
+	for _, e := range entries {
+		if e.FS == nil {
+			write := e.Write
+			if write == nil && e.Body != nil {
+				body := e.Body
+				write = func(w io.Writer) error { return copyClose(w, body) }
+			}
+			if e.Name == "" || write == nil {
+				return fmt.Errorf("renderall: archive entry needs a Name and a Body, Write, or FS")
+			}
+			modified := e.Modified
+			if modified.IsZero() {
+				modified = now
+			}
+			size := e.Size
+			if size == 0 {
+				size = -1
+			}
+			if err := fn(archiveFile{name: e.Name, modified: modified, mode: 0644, size: size, write: write}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		fsys := e.FS
+		err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			modified, mode := info.ModTime(), info.Mode().Perm()
+			if modified.IsZero() {
+				modified = now
+			}
+			if mode == 0 {
+				mode = 0644
+			}
+			return fn(archiveFile{
+				name:     path.Join(e.Name, name),
+				modified: modified,
+				mode:     mode,
+				size:     info.Size(),
+				write: func(w io.Writer) error {
+					f, err := fsys.Open(name)
+					if err != nil {
+						return err
+					}
+					return copyClose(w, f)
+				},
+			})
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyClose copies body to w, closing body if it can be.
+func copyClose(w io.Writer, body io.Reader) error {
+	if c, ok := body.(io.Closer); ok {
+		defer c.Close()
+	}
+	_, err := io.Copy(w, body)
+	return err
+}
+
+// Zip built-in renderer. It streams a []ArchiveEntry as a zip archive
+// without staging it, so errors part way can only abort the download.
+type Zip struct {
+	Head
+	// Modified is the time of entries without one. Zero uses the current time.
+	Modified time.Time
+}
+
+// Render a zip response.
+func (z Zip) Render(w http.ResponseWriter, v interface{}) error {
+	entries, ok := v.([]ArchiveEntry)
+	if !ok {
+		return fmt.Errorf("renderall: zip requires []ArchiveEntry, got %T", v)
+	}
+
+	z.Head.Write(w)
+	zw := zip.NewWriter(w)
+	err := walkArchive(entries, z.Modified, func(f archiveFile) error {
+		hdr := &zip.FileHeader{Name: f.name, Method: zip.Deflate, Modified: f.modified}
+		hdr.SetMode(f.mode)
+		fw, err := zw.CreateHeader(hdr)
+		if err != nil {
+			return err
+		}
+		return f.write(fw)
+	})
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// Zip streams the entries as a zip archive download.
+func (r *Render) Zip(w http.ResponseWriter, status int, entries []ArchiveEntry, archiveOpt ...ArchiveOptions) error {
+	opt := ArchiveOptions{Filename: "archive.zip"}
+	if len(archiveOpt) > 0 && archiveOpt[0].Filename != "" {
+		opt = archiveOpt[0]
+	}
+	setAttachment(w.Header(), opt.Filename)
+
+	head := Head{
+		ContentType: ContentZip,
+		Status:      status,
+	}
+
+	z := Zip{
+		Head:     head,
+		Modified: r.now(),
+	}
+
+	return r.Render(w, z, entries)
+}
+
+// Tar built-in renderer. It streams a []ArchiveEntry as a tar archive,
+// gzip compressed if Gzip is set.
+type Tar struct {
+	Head
+	Gzip bool
+	// Modified is the time of entries without one. Zero uses the current time.
+	Modified time.Time
+}
+
+// Render a tar response.
+func (t Tar) Render(w http.ResponseWriter, v interface{}) error {
+	entries, ok := v.([]ArchiveEntry)
+	if !ok {
+		return fmt.Errorf("renderall: tar requires []ArchiveEntry, got %T", v)
+	}
+
+	t.Head.Write(w)
+	var out io.Writer = w
+	var gz *gzip.Writer
+	if t.Gzip {
+		gz = gzip.NewWriter(w)
+		out = gz
+	}
+	tw := tar.NewWriter(out)
+	err := walkArchive(entries, t.Modified, func(f archiveFile) error {
+		hdr := &tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     f.name,
+			Mode:     int64(f.mode),
+			ModTime:  f.modified,
+			Size:     f.size,
+		}
+		if f.size >= 0 {
+			if err := tw.WriteHeader(hdr); err != nil {
+				return err
+			}
+			return f.write(tw)
+		}
+
+		// Tar headers carry the size, so buffer contents of unknown length.
+		buf := bufPool.Get()
+		defer bufPool.Put(buf)
+		if err := f.write(buf); err != nil {
+			return err
+		}
+		hdr.Size = int64(buf.Len())
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err := buf.WriteTo(tw)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if gz != nil {
+		return gz.Close()
+	}
+	return nil
+}
+
+// Tar streams the entries as a tar archive download.
+func (r *Render) Tar(w http.ResponseWriter, status int, entries []ArchiveEntry, archiveOpt ...ArchiveOptions) error {
+	return r.tar(w, status, entries, false, "archive.tar", archiveOpt)
+}
+
+// TarGz streams the entries as a gzip compressed tar archive download.
+func (r *Render) TarGz(w http.ResponseWriter, status int, entries []ArchiveEntry, archiveOpt ...ArchiveOptions) error {
+	return r.tar(w, status, entries, true, "archive.tar.gz", archiveOpt)
+}
+
+func (r *Render) tar(w http.ResponseWriter, status int, entries []ArchiveEntry, compress bool, filename string, archiveOpt []ArchiveOptions) error {
+	if len(archiveOpt) > 0 && archiveOpt[0].Filename != "" {
+		filename = archiveOpt[0].Filename
+	}
+	setAttachment(w.Header(), filename)
+
+	contentType := ContentTar
+	if compress {
+		contentType = ContentGzip
+	}
+	head := Head{
+		ContentType: contentType,
+		Status:      status,
+	}
+
+	t := Tar{
+		Head:     head,
+		Gzip:     compress,
+		Modified: r.now(),
+	}
+
+	return r.Render(w, t, entries)
+}
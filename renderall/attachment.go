@@ -0,0 +1,18 @@
+package renderall
+
+import (
+	"mime"
+	"net/http"
+)
+
+// ContentDisposition header constant.
+const ContentDisposition = "Content-Disposition"
+
+// setAttachment offers the response as a download named filename. Names
+// outside ASCII are encoded per RFC 2231. A blank filename does nothing.
+func setAttachment(h http.Header, filename string) {
+	if filename == "" {
+		return
+	}
+	h.Set(ContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+}
@@ -0,0 +1,190 @@
+package renderall
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// AuditRecord describes one render for Options.Audit.
+type AuditRecord struct {
+	Time time.Time `json:"time"`
+	// Method, Route, and Path of the request, when the render was given one.
+	// Route is the ServeMux pattern that matched, or blank outside of one.
+	Method string `json:"method,omitempty"`
+	Route  string `json:"route,omitempty"`
+	Path   string `json:"path,omitempty"`
+	// RequestID is the render's request ID, if Options.RequestIDHeader is set.
+	RequestID string `json:"request_id,omitempty"`
+	// Status sent, or 0 if the render failed before sending one.
+	Status          int    `json:"status"`
+	ContentType     string `json:"content_type,omitempty"`
+	ContentEncoding string `json:"content_encoding,omitempty"`
+	// Bytes is the body size and SHA256 its hex encoded digest, before any
+	// Content-Encoding, so they match the body the handler rendered. Both
+	// cover no body for 204 and 304 responses, which are sent without one.
+	Bytes  int64  `json:"bytes"`
+	SHA256 string `json:"sha256"`
+	// Body is the start of the body for sampled renders, see
+	// Options.AuditBodySample.
+	Body string `json:"body,omitempty"`
+	// Truncated is set when Body was cut at 64KB.
+	Truncated bool          `json:"truncated,omitempty"`
+	Duration  time.Duration `json:"duration"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// AuditSink stores AuditRecords. Audit is called once the response was
+// written, from the handler's goroutine, and its errors are passed to
+// Options.OnWarning.
+type AuditSink interface {
+	Audit(req *http.Request, rec AuditRecord) error
+}
+
+// AuditFunc adapts a function to an AuditSink.
+type AuditFunc func(req *http.Request, rec AuditRecord) error
+
+// Audit calls f(req, rec).
+func (f AuditFunc) Audit(req *http.Request, rec AuditRecord) error {
+	return f(req, rec)
+}
+
+// auditLog writes records as JSON lines.
+type auditLog struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewAuditLog returns an AuditSink writing each record to w as a line of
+// JSON, e.g. to an append-only file.
+func NewAuditLog(w io.Writer) AuditSink {
+	return &auditLog{w: w}
+}
+
+func (l *auditLog) Audit(req *http.Request, rec AuditRecord) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err = l.w.Write(append(b, '\n'))
+	return err
+}
+
+// audited reports whether renders for req go to Options.Audit.
+func (r *Render) audited(req *http.Request) bool {
+	if r.opt.Audit == nil {
+		return false
+	}
+	return req == nil || r.opt.AuditRequest == nil || r.opt.AuditRequest(req)
+}
+
+// sampleBody reports whether an audited render keeps its body.
+func (r *Render) sampleBody() bool {
+	if r.opt.AuditBodySample <= 0 {
+		return false
+	}
+	var b [8]byte
+	if _, err := io.ReadFull(r.random(), b[:]); err != nil {
+		return false
+	}
+	// The top 53 bits give a uniform float in [0, 1).
+	return float64(binary.BigEndian.Uint64(b[:])>>11)/(1<<53) < r.opt.AuditBodySample
+}
+
+// auditWriter hashes and counts the body of a render, keeping its start
+// when sampled. Buffered renders report their body before it is encoded
+// through identity, and the bytes written after it pass through.
+type auditWriter struct {
+	http.ResponseWriter
+	status    int
+	hash      hash.Hash
+	n         int64
+	sample    bool
+	body      []byte
+	truncated bool
+	// recorded is set once identity was given the body.
+	recorded bool
+}
+
+func (aw *auditWriter) WriteHeader(status int) {
+	if aw.status == 0 {
+		aw.status = status
+	}
+	aw.ResponseWriter.WriteHeader(status)
+}
+
+func (aw *auditWriter) Write(b []byte) (int, error) {
+	if aw.status == 0 {
+		aw.status = http.StatusOK
+	}
+	n, err := aw.ResponseWriter.Write(b)
+	if !aw.recorded {
+		aw.add(aw.status, b[:n])
+	}
+	return n, err
+}
+
+// identity records the whole body of a buffered render before any
+// Content-Encoding is applied to it.
+func (aw *auditWriter) identity(status int, body []byte) {
+	aw.add(status, body)
+	aw.recorded = true
+}
+
+// add hashes, counts, and samples b, unless status responses have no body.
+func (aw *auditWriter) add(status int, b []byte) {
+	if status == http.StatusNoContent || status == http.StatusNotModified {
+		return
+	}
+	aw.hash.Write(b)
+	aw.n += int64(len(b))
+	if aw.sample && !aw.truncated {
+		aw.body, aw.truncated = appendCapped(aw.body, b)
+	}
+}
+
+// Flush sends any buffered data to the client.
+func (aw *auditWriter) Flush() {
+	http.NewResponseController(aw.ResponseWriter).Flush()
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (aw *auditWriter) Unwrap() http.ResponseWriter {
+	return aw.ResponseWriter
+}
+
+// audit runs render against an auditWriter and sends the record to
+// Options.Audit.
+func (r *Render) audit(w http.ResponseWriter, ctx *RenderContext, render func(http.ResponseWriter) error) error {
+	rec := AuditRecord{Time: r.now()}
+	if req := ctx.Request; req != nil {
+		rec.Method, rec.Route, rec.Path = req.Method, req.Pattern, req.URL.Path
+		rec.RequestID = r.RequestID(req)
+	}
+
+	aw := &auditWriter{ResponseWriter: w, hash: sha256.New(), sample: r.sampleBody()}
+	ctx.audit = aw
+	err := render(aw)
+	rec.Duration = r.now().Sub(rec.Time)
+	rec.Status = aw.status
+	rec.ContentType = w.Header().Get(ContentType)
+	rec.ContentEncoding = w.Header().Get(ContentEncoding)
+	rec.Bytes = aw.n
+	rec.SHA256 = hex.EncodeToString(aw.hash.Sum(nil))
+	rec.Body, rec.Truncated = string(aw.body), aw.truncated
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	if auditErr := r.opt.Audit.Audit(ctx.Request, rec); auditErr != nil {
+		r.warn(ctx.Request, auditErr)
+	}
+	return err
+}
@@ -0,0 +1,90 @@
+package renderall
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAudit(t *testing.T) {
+	var records []AuditRecord
+	sink := AuditFunc(func(_ *http.Request, rec AuditRecord) error {
+		records = append(records, rec)
+		return nil
+	})
+	big := map[string]string{"a": strings.Repeat("b", 2048)}
+	identity := `{"a":"` + big["a"] + `"}`
+	sum := sha256.Sum256([]byte(identity))
+
+	tests := []struct {
+		name     string
+		opt      Options
+		status   int
+		v        interface{}
+		bytes    int64
+		sha256   string
+		encoding string
+	}{
+		{"plain", Options{}, http.StatusOK, big, int64(len(identity)), hex.EncodeToString(sum[:]), ""},
+		{"gzip", Options{Compress: true}, http.StatusOK, big, int64(len(identity)), hex.EncodeToString(sum[:]), "gzip"},
+		{"masked", Options{PostRender: []func(*RenderContext) error{MaskPII(PIIMask{Paths: []string{"$.a"}})}}, http.StatusOK, big,
+			int64(len(`{"a":"[REDACTED]"}`)), "", ""},
+		{"no content", Options{}, http.StatusNoContent, big, 0, hex.EncodeToString(sha256.New().Sum(nil)), ""},
+		{"no content buffered", Options{ETag: true}, http.StatusNoContent, big, 0, hex.EncodeToString(sha256.New().Sum(nil)), ""},
+	}
+	for _, tt := range tests {
+		records = nil
+		tt.opt.Audit = sink
+		tt.opt.AuditBodySample = 1
+		tt.opt.OnWarning = func(*http.Request, error) {}
+		r := newTestRender(tt.opt)
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		if err := r.JSON(httptest.NewRecorder(), tt.status, tt.v, JSONOptions{Request: req}); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if len(records) != 1 {
+			t.Fatalf("%s: %d records, want 1", tt.name, len(records))
+		}
+		rec := records[0]
+		if rec.Status != tt.status || rec.Bytes != tt.bytes || rec.ContentEncoding != tt.encoding {
+			t.Errorf("%s: status %d, %d bytes, encoding %q, want %d, %d, %q", tt.name, rec.Status, rec.Bytes, rec.ContentEncoding, tt.status, tt.bytes, tt.encoding)
+		}
+		if tt.sha256 != "" && rec.SHA256 != tt.sha256 {
+			t.Errorf("%s: sha256 %s, want %s", tt.name, rec.SHA256, tt.sha256)
+		}
+		if int64(len(rec.Body)) != rec.Bytes || strings.Contains(rec.Body, "\x1f\x8b") {
+			t.Errorf("%s: sampled %d bytes of %d, want the identity body", tt.name, len(rec.Body), rec.Bytes)
+		}
+	}
+}
+
+func TestAuditBodySample(t *testing.T) {
+	var rec AuditRecord
+	sink := AuditFunc(func(_ *http.Request, r AuditRecord) error {
+		rec = r
+		return nil
+	})
+	tests := []struct {
+		name string
+		opt  Options
+		kept bool
+	}{
+		{"none", Options{AuditBodySample: 0, TestMode: true}, false},
+		{"test mode", Options{AuditBodySample: 0.01, TestMode: true}, true},
+		{"random", Options{AuditBodySample: 0.5, Random: strings.NewReader(strings.Repeat("\xff", 8))}, false},
+	}
+	for _, tt := range tests {
+		tt.opt.Audit = sink
+		r := New(tt.opt)
+		if err := r.JSON(httptest.NewRecorder(), http.StatusOK, 1, JSONOptions{Request: httptest.NewRequest(http.MethodGet, "/", nil)}); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if kept := rec.Body != ""; kept != tt.kept {
+			t.Errorf("%s: body kept %v, want %v", tt.name, kept, tt.kept)
+		}
+	}
+}
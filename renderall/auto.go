@@ -0,0 +1,227 @@
+package renderall
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// FormatSource is a place Auto looks for the requested format.
+type FormatSource int
+
+const (
+	// FormatQuery reads the Options.FormatParam query parameter, e.g. ?format=xml.
+	FormatQuery FormatSource = iota
+	// FormatExtension reads the URL path suffix, e.g. /users.json.
+	FormatExtension
+	// FormatAccept negotiates with the Accept header.
+	FormatAccept
+)
+
+var defaultFormatPrecedence = []FormatSource{FormatQuery, FormatExtension, FormatAccept}
+
+// format is a response format Auto can select.
+type format struct {
+	name         string
+	contentTypes []string
+	render       func(w http.ResponseWriter, req *http.Request, status int, v interface{}) error
+}
+
+// builtinFormats returns the formats every Render supports. With
+// Options.BrowsableAPI set, browsers asking for HTML get the browsable JSON
+// view since Auto has no template to use; otherwise html is not offered and
+// they get the default format.
+func (r *Render) builtinFormats() []*format {
+	formats := []*format{
+		{name: "json", contentTypes: []string{ContentJSON}, render: func(w http.ResponseWriter, req *http.Request, status int, v interface{}) error {
+			return r.JSON(w, status, v, JSONOptions{Request: req})
+		}},
+		{name: "xml", contentTypes: []string{ContentXML, "application/xml"}, render: func(w http.ResponseWriter, req *http.Request, status int, v interface{}) error {
+			return r.XML(w, status, v, XMLOptions{Request: req})
+		}},
+		{name: "yaml", contentTypes: []string{ContentYAML, "application/x-yaml", "text/yaml"}, render: func(w http.ResponseWriter, req *http.Request, status int, v interface{}) error {
+			return r.YAML(w, status, v, YAMLOptions{Request: req})
+		}},
+		{name: "msgpack", contentTypes: []string{ContentMsgPack, "application/x-msgpack"}, render: func(w http.ResponseWriter, req *http.Request, status int, v interface{}) error {
+			return r.MsgPack(w, status, v, MsgPackOptions{Request: req})
+		}},
+	}
+	if r.opt.BrowsableAPI {
+		formats = append(formats, &format{name: "html", contentTypes: []string{ContentHTML, ContentXHTML}, render: func(w http.ResponseWriter, req *http.Request, status int, v interface{}) error {
+			return r.browsableJSON(w, status, req, v)
+		}})
+	}
+	return formats
+}
+
+// lookupFormat returns the format with the given name.
+func (r *Render) lookupFormat(name string) *format {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	for _, f := range r.formats {
+		if f.name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// negotiateFormat picks the format best matching the Accept header.
+func (r *Render) negotiateFormat(accept string) *format {
+	r.lock.RLock()
+	var offers []string
+	byType := map[string]*format{}
+	for _, f := range r.formats {
+		for _, ct := range f.contentTypes {
+			offers = append(offers, ct)
+			byType[ct] = f
+		}
+	}
+	r.lock.RUnlock()
+
+	if strings.TrimSpace(accept) == "" {
+		return nil
+	}
+	return byType[Negotiate(accept, offers...)]
+}
+
+// selectFormat resolves the requested format following the configured
+// precedence. explicit is true when the client named a format directly
+// through the query or extension, in which case an unknown one is an error.
+func (r *Render) selectFormat(req *http.Request) (f *format, name string, explicit bool) {
+	precedence := r.opt.FormatPrecedence
+	if len(precedence) == 0 {
+		precedence = defaultFormatPrecedence
+	}
+
+	for _, src := range precedence {
+		switch src {
+		case FormatQuery:
+			if name = req.URL.Query().Get(r.opt.FormatParam); name != "" {
+				return r.lookupFormat(strings.ToLower(name)), name, true
+			}
+		case FormatExtension:
+			if ext := path.Ext(req.URL.Path); len(ext) > 1 {
+				if f = r.lookupFormat(strings.ToLower(ext[1:])); f != nil {
+					return f, ext[1:], true
+				}
+			}
+		case FormatAccept:
+			if f = r.negotiateFormat(req.Header.Get("Accept")); f != nil {
+				return f, f.name, false
+			}
+		}
+	}
+	return r.lookupFormat(r.opt.DefaultFormat), r.opt.DefaultFormat, false
+}
+
+// Auto writes v in the format the request asks for, chosen by
+// Options.FormatPrecedence from the format query parameter, the URL
+// extension, and the Accept header, falling back to Options.DefaultFormat.
+// A format named explicitly but not supported is answered with 406.
+func (r *Render) Auto(w http.ResponseWriter, req *http.Request, status int, v interface{}) error {
+	addVary(w.Header(), "Accept")
+
+	f, name, explicit := r.selectFormat(req)
+	if f == nil {
+		if explicit {
+			return r.Error(w, http.StatusNotAcceptable, "", "unsupported format "+name)
+		}
+		return r.Error(w, http.StatusInternalServerError, "", "default format "+name+" is not registered")
+	}
+	return f.render(w, req, status, v)
+}
+
+// registeredEngine adapts a user Engine registered with RegisterEngine. The
+// Engine only needs to write the body: the content type is set beforehand
+// and the status is sent on its first write unless it sends one itself.
+// Engines implementing EngineV2 are handed the request, when there is one.
+type registeredEngine struct {
+	Head
+	Engine  Engine
+	Request *http.Request
+}
+
+// Render a response with the registered engine.
+func (re registeredEngine) Render(w http.ResponseWriter, v interface{}) error {
+	if w.Header().Get(ContentType) == "" {
+		w.Header().Set(ContentType, re.Head.ContentType)
+	}
+	hw := &headWriter{ResponseWriter: w, status: re.Head.Status}
+	var err error
+	if v2, ok := re.Engine.(EngineV2); ok {
+		ctx := context.Background()
+		if re.Request != nil {
+			ctx = re.Request.Context()
+		}
+		err = v2.RenderContext(ctx, hw, re.Request, v)
+	} else {
+		err = re.Engine.Render(hw, v)
+	}
+	if err != nil {
+		return err
+	}
+	hw.writeHeader()
+	return nil
+}
+
+// headWriter sends a default status ahead of the first body write.
+type headWriter struct {
+	http.ResponseWriter
+	status int
+	wrote  bool
+}
+
+func (h *headWriter) WriteHeader(status int) {
+	if !h.wrote {
+		h.wrote = true
+		h.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (h *headWriter) writeHeader() {
+	h.WriteHeader(h.status)
+}
+
+func (h *headWriter) Write(b []byte) (int, error) {
+	h.writeHeader()
+	return h.ResponseWriter.Write(b)
+}
+
+// RegisterEngine adds a named format rendered by e, which may also implement
+// EngineV2, with the given content type. It takes part in Auto negotiation,
+// is selectable by name through the format query parameter and URL
+// extension, and can be used directly with Format. Registering an existing
+// name, including a built-in, replaces it.
+func (r *Render) RegisterEngine(name, contentType string, e Engine) {
+	name = strings.ToLower(name)
+	f := &format{name: name, contentTypes: []string{contentType}}
+	f.render = func(w http.ResponseWriter, req *http.Request, status int, v interface{}) error {
+		head := Head{
+			ContentType: contentType,
+			Status:      status,
+		}
+		return r.render(w, req, registeredEngine{Head: head, Engine: e, Request: req}, v)
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for i, existing := range r.formats {
+		if existing.name == name {
+			r.formats[i] = f
+			return
+		}
+	}
+	r.formats = append(r.formats, f)
+}
+
+// Format writes v with the named format, built-in or registered.
+func (r *Render) Format(w http.ResponseWriter, name string, status int, v interface{}) error {
+	f := r.lookupFormat(strings.ToLower(name))
+	if f == nil {
+		return r.fail(w, fmt.Errorf("renderall: unknown format %q", name))
+	}
+	return f.render(w, nil, status, v)
+}
@@ -0,0 +1,20 @@
+package renderall
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestAutoVary(t *testing.T) {
+	r := New(Options{BrowsableAPI: true})
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "application/json")
+	if err := r.Auto(w, req, 200, map[string]int{"a": 1}); err != nil {
+		t.Fatal(err)
+	}
+	if got := w.Header().Values("Vary"); !reflect.DeepEqual(got, []string{"Accept"}) {
+		t.Errorf("Vary = %q, want [Accept]", got)
+	}
+}
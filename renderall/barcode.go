@@ -0,0 +1,252 @@
+package renderall
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"net/http"
+	"strings"
+)
+
+// BarcodeOptions is a struct for overriding some rendering Options for specific QRCode and Code128 calls.
+type BarcodeOptions struct {
+	// Image format, "png" or "svg". Defaults to "png".
+	Format string
+	// Size of one module in pixels. Defaults to 4 for QR codes and 2 for barcodes.
+	Size int
+	// QR code error correction level. Defaults to QRMedium.
+	Level QRLevel
+	// Quiet zone around the code in modules, negative for none. Defaults to 4 for QR codes and 10 for barcodes.
+	Quiet int
+	// Bar height of barcodes in pixels. Defaults to 30 times Size.
+	Height int
+}
+
+// symbol is a grid of dark and light modules. Barcodes are one module high
+// and stretched to their bar height.
+type symbol struct {
+	width, height int
+	dark          []bool
+}
+
+// image draws s in black on white.
+func (s *symbol) image(opt BarcodeOptions) image.Image {
+	w := (s.width + 2*opt.Quiet) * opt.Size
+	h := s.pixelHeight(opt)
+	img := image.NewPaletted(image.Rect(0, 0, w, h), color.Palette{color.White, color.Black})
+	for py := 0; py < h; py++ {
+		y := s.moduleRow(py, opt)
+		if y < 0 {
+			continue
+		}
+		row := img.Pix[py*img.Stride : py*img.Stride+w]
+		for x := 0; x < s.width; x++ {
+			if !s.dark[y*s.width+x] {
+				continue
+			}
+			start := (x + opt.Quiet) * opt.Size
+			for px := start; px < start+opt.Size; px++ {
+				row[px] = 1
+			}
+		}
+	}
+	return img
+}
+
+// svg draws s as one path of dark runs over a white background.
+func (s *symbol) svg(opt BarcodeOptions) []byte {
+	w := (s.width + 2*opt.Quiet) * opt.Size
+	h := s.pixelHeight(opt)
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, w, h, w, h)
+	b.WriteString(`<rect width="100%" height="100%" fill="#fff"/><path fill="#000" d="`)
+	rowHeight, top := opt.Size, opt.Quiet*opt.Size
+	if s.height == 1 {
+		rowHeight, top = h, 0
+	}
+	for y := 0; y < s.height; y++ {
+		for x := 0; x < s.width; {
+			if !s.dark[y*s.width+x] {
+				x++
+				continue
+			}
+			run := 1
+			for x+run < s.width && s.dark[y*s.width+x+run] {
+				run++
+			}
+			fmt.Fprintf(&b, "M%d %dh%dv%dh-%dz", (x+opt.Quiet)*opt.Size, top+y*rowHeight, run*opt.Size, rowHeight, run*opt.Size)
+			x += run
+		}
+	}
+	b.WriteString(`"/></svg>`)
+	return b.Bytes()
+}
+
+func (s *symbol) pixelHeight(opt BarcodeOptions) int {
+	if s.height == 1 {
+		return opt.Height
+	}
+	return (s.height + 2*opt.Quiet) * opt.Size
+}
+
+// moduleRow maps a pixel row to a module row, or -1 in the quiet zone.
+func (s *symbol) moduleRow(py int, opt BarcodeOptions) int {
+	if s.height == 1 {
+		return 0
+	}
+	y := py/opt.Size - opt.Quiet
+	if y < 0 || y >= s.height {
+		return -1
+	}
+	return y
+}
+
+// code128Patterns are the bar and space widths of each symbol value,
+// starting with a bar. 103 to 105 are the starts, 106 the stop.
+var code128Patterns = [107]string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312", "132212", "221213",
+	"221312", "231212", "112232", "122132", "122231", "113222", "123122", "123221", "223211", "221132",
+	"221231", "213212", "223112", "312131", "311222", "321122", "321221", "312212", "322112", "322211",
+	"212123", "212321", "232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121", "313121", "211331",
+	"231131", "213113", "213311", "213131", "311123", "311321", "331121", "312113", "312311", "332111",
+	"314111", "221411", "431111", "111224", "111422", "121124", "121421", "141122", "141221", "112214",
+	"112412", "122114", "122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112", "421211", "212141",
+	"214121", "412121", "111143", "111341", "131141", "114113", "114311", "411113", "411311", "113141",
+	"114131", "311141", "411131", "211412", "211214", "211232", "2331112",
+}
+
+const (
+	code128CodeC  = 99
+	code128CodeB  = 100
+	code128StartB = 104
+	code128StartC = 105
+	code128Stop   = 106
+)
+
+// encodeCode128 encodes printable ASCII as a Code 128 barcode, in code
+// set B with runs of digits packed in pairs in code set C.
+func encodeCode128(data string) (*symbol, error) {
+	if data == "" {
+		return nil, fmt.Errorf("renderall: Code128 requires data")
+	}
+	for i := 0; i < len(data); i++ {
+		if data[i] < ' ' || data[i] > 127 {
+			return nil, fmt.Errorf("renderall: Code128 cannot encode byte %#x at %d", data[i], i)
+		}
+	}
+	digits := func(i int) int {
+		n := 0
+		for i+n < len(data) && data[i+n] >= '0' && data[i+n] <= '9' {
+			n++
+		}
+		return n
+	}
+
+	var values []int
+	setC := false
+	if n := digits(0); n >= 4 || n == len(data) && n%2 == 0 {
+		values, setC = append(values, code128StartC), true
+	} else {
+		values = append(values, code128StartB)
+	}
+	for i := 0; i < len(data); {
+		if setC {
+			if digits(i) >= 2 {
+				values = append(values, int(data[i]-'0')*10+int(data[i+1]-'0'))
+				i += 2
+				continue
+			}
+			values, setC = append(values, code128CodeB), false
+		}
+		// Switching to C pays off for six digits, or four that end the data.
+		if n := digits(i); n >= 6 || n >= 4 && i+n == len(data) {
+			if n%2 == 1 {
+				values = append(values, int(data[i]-' '))
+				i++
+			}
+			values, setC = append(values, code128CodeC), true
+			continue
+		}
+		values = append(values, int(data[i]-' '))
+		i++
+	}
+	sum := values[0]
+	for i, v := range values[1:] {
+		sum += (i + 1) * v
+	}
+	values = append(values, sum%103, code128Stop)
+
+	var dark []bool
+	for _, v := range values {
+		bar := true
+		for _, c := range code128Patterns[v] {
+			for n := 0; n < int(c-'0'); n++ {
+				dark = append(dark, bar)
+			}
+			bar = !bar
+		}
+	}
+	return &symbol{width: len(dark), height: 1, dark: dark}, nil
+}
+
+// QRCode renders data as a QR code image.
+func (r *Render) QRCode(w http.ResponseWriter, status int, data string, barcodeOpt ...BarcodeOptions) error {
+	opt := prepareBarcodeOptions(4, 4, barcodeOpt)
+	if opt.Level == "" {
+		opt.Level = QRMedium
+	}
+	s, err := encodeQR([]byte(data), opt.Level)
+	if err != nil {
+		return r.fail(w, err)
+	}
+	return r.barcode(w, status, s, opt)
+}
+
+// Code128 renders data, printable ASCII, as a Code 128 barcode image.
+func (r *Render) Code128(w http.ResponseWriter, status int, data string, barcodeOpt ...BarcodeOptions) error {
+	opt := prepareBarcodeOptions(2, 10, barcodeOpt)
+	if opt.Height == 0 {
+		opt.Height = 30 * opt.Size
+	}
+	s, err := encodeCode128(data)
+	if err != nil {
+		return r.fail(w, err)
+	}
+	return r.barcode(w, status, s, opt)
+}
+
+// prepareBarcodeOptions fills in the per symbology size and quiet zone defaults.
+func prepareBarcodeOptions(size, quiet int, barcodeOpt []BarcodeOptions) BarcodeOptions {
+	opt := BarcodeOptions{}
+	if len(barcodeOpt) > 0 {
+		opt = barcodeOpt[0]
+	}
+	if opt.Quiet == 0 {
+		opt.Quiet = quiet
+	} else if opt.Quiet < 0 {
+		opt.Quiet = 0
+	}
+	if opt.Size <= 0 {
+		opt.Size = size
+	}
+	return opt
+}
+
+func (r *Render) barcode(w http.ResponseWriter, status int, s *symbol, opt BarcodeOptions) error {
+	switch strings.ToLower(opt.Format) {
+	case "", "png":
+		return r.Image(w, status, s.image(opt), "png")
+	case "svg":
+		d := Data{
+			Head: Head{
+				ContentType: ContentSVG,
+				Status:      status,
+			},
+		}
+		return r.Render(w, d, s.svg(opt))
+	}
+	return r.fail(w, fmt.Errorf("renderall: unknown barcode format %q", opt.Format))
+}
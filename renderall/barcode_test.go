@@ -0,0 +1,100 @@
+package renderall
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRSRemainder(t *testing.T) {
+	// The version 1-M codewords of "HELLO WORLD" and their error
+	// correction, from the worked example in ISO/IEC 18004.
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if got := rsRemainder(data, rsDivisor(len(want))); !bytes.Equal(got, want) {
+		t.Errorf("rsRemainder = %v, want %v", got, want)
+	}
+}
+
+func TestEncodeQR(t *testing.T) {
+	tests := []struct {
+		data  string
+		level QRLevel
+		size  int
+	}{
+		{"hello", QRMedium, 21},
+		{"hello", QRHigh, 21},
+		{"https://example.com/some/longer/path?with=query", QRLow, 29},
+		{"https://example.com/some/longer/path?with=query", QRHigh, 41},
+	}
+	for _, tt := range tests {
+		s, err := encodeQR([]byte(tt.data), tt.level)
+		if err != nil {
+			t.Errorf("encodeQR(%q, %s): %v", tt.data, tt.level, err)
+			continue
+		}
+		if s.width != tt.size || s.height != tt.size {
+			t.Errorf("encodeQR(%q, %s) is %dx%d, want %dx%d", tt.data, tt.level, s.width, s.height, tt.size, tt.size)
+		}
+		// The top left finder pattern: a dark ring around a light ring
+		// around a dark three by three square.
+		for i, want := range []bool{true, true, true, true, true, true, true, false} {
+			if got := s.dark[i]; got != want {
+				t.Errorf("encodeQR(%q, %s) top row module %d dark = %v", tt.data, tt.level, i, got)
+			}
+		}
+	}
+
+	if _, err := encodeQR([]byte("hello"), "X"); err == nil {
+		t.Error("encodeQR with an unknown level succeeded")
+	}
+	if _, err := encodeQR(make([]byte, 3000), QRHigh); err == nil {
+		t.Error("encodeQR of 3000 bytes at level H succeeded")
+	}
+}
+
+func TestEncodeCode128(t *testing.T) {
+	tests := []struct {
+		data   string
+		values []int
+	}{
+		// Digits only: code set C throughout.
+		{"1234", []int{105, 12, 34, 82, 106}},
+		// Text: code set B.
+		{"Ab", []int{104, 33, 66, 63, 106}},
+		// A run of six digits switches to C and back.
+		{"A123456B", []int{104, 33, 99, 12, 34, 56, 100, 34, 80, 106}},
+	}
+	for _, tt := range tests {
+		s, err := encodeCode128(tt.data)
+		if err != nil {
+			t.Errorf("encodeCode128(%q): %v", tt.data, err)
+			continue
+		}
+		var want []bool
+		for _, v := range tt.values {
+			bar := true
+			for _, c := range code128Patterns[v] {
+				for n := 0; n < int(c-'0'); n++ {
+					want = append(want, bar)
+				}
+				bar = !bar
+			}
+		}
+		if s.height != 1 || s.width != len(want) {
+			t.Errorf("encodeCode128(%q) is %dx%d, want %dx1", tt.data, s.width, s.height, len(want))
+			continue
+		}
+		for i := range want {
+			if s.dark[i] != want[i] {
+				t.Errorf("encodeCode128(%q) differs at module %d", tt.data, i)
+				break
+			}
+		}
+	}
+
+	for _, data := range []string{"", "café", "tab\there"} {
+		if _, err := encodeCode128(data); err == nil {
+			t.Errorf("encodeCode128(%q) succeeded", data)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package renderall
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+// discardWriter is a ResponseWriter that throws the response away, so
+// benchmarks measure only the render.
+type discardWriter struct {
+	h http.Header
+}
+
+func (d discardWriter) Header() http.Header         { return d.h }
+func (d discardWriter) WriteHeader(int)             {}
+func (d discardWriter) Write(b []byte) (int, error) { return len(b), nil }
+
+type benchUser struct {
+	ID    int      `json:"id" xml:"id,attr"`
+	Name  string   `json:"name" xml:"name"`
+	Email string   `json:"email" xml:"email"`
+	Tags  []string `json:"tags" xml:"tag"`
+}
+
+type benchUsers struct {
+	XMLName xml.Name    `xml:"users"`
+	Users   []benchUser `xml:"user"`
+}
+
+// benchPayloads are the sizes every engine is benchmarked with, as in
+// cmd/renderbench.
+var benchPayloads = func() []struct {
+	name  string
+	users []benchUser
+} {
+	sizes := []struct {
+		name  string
+		users []benchUser
+	}{{"small", nil}, {"large", nil}}
+	for i, n := range []int{1, 1000} {
+		us := make([]benchUser, n)
+		for j := range us {
+			us[j] = benchUser{ID: j, Name: "Ada Lovelace", Email: "ada@example.com", Tags: []string{"admin", "<staff>"}}
+		}
+		sizes[i].users = us
+	}
+	return sizes
+}()
+
+// benchRender runs render b.N times for every payload size.
+func benchRender(b *testing.B, o Options, render func(r *Render, w http.ResponseWriter, us []benchUser) error) {
+	o.DisableRedaction = true
+	r := newTestRender(o)
+	for _, p := range benchPayloads {
+		b.Run(p.name, func(b *testing.B) {
+			b.ReportAllocs()
+			w := discardWriter{http.Header{}}
+			for i := 0; i < b.N; i++ {
+				if err := render(r, w, p.users); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkJSON(b *testing.B) {
+	render := func(r *Render, w http.ResponseWriter, us []benchUser) error {
+		return r.JSON(w, http.StatusOK, us)
+	}
+	b.Run("pooled", func(b *testing.B) { benchRender(b, Options{}, render) })
+	b.Run("indent", func(b *testing.B) { benchRender(b, Options{IndentJSON: true}, render) })
+	b.Run("streaming", func(b *testing.B) { benchRender(b, Options{StreamingJSON: true}, render) })
+	b.Run("etag", func(b *testing.B) { benchRender(b, Options{ETag: true}, render) })
+}
+
+func BenchmarkXML(b *testing.B) {
+	benchRender(b, Options{}, func(r *Render, w http.ResponseWriter, us []benchUser) error {
+		return r.XML(w, http.StatusOK, benchUsers{Users: us})
+	})
+}
+
+func BenchmarkHTML(b *testing.B) {
+	render := func(r *Render, w http.ResponseWriter, us []benchUser) error {
+		return r.HTML(w, http.StatusOK, "users", us)
+	}
+	b.Run("plain", func(b *testing.B) { benchRender(b, Options{}, render) })
+	b.Run("layout", func(b *testing.B) { benchRender(b, Options{Layout: "layout"}, render) })
+}
+
+func BenchmarkData(b *testing.B) {
+	benchRender(b, Options{}, func(r *Render, w http.ResponseWriter, us []benchUser) error {
+		return r.Data(w, http.StatusOK, make([]byte, 64*len(us)))
+	})
+}
+
+func BenchmarkCSV(b *testing.B) {
+	benchRender(b, Options{}, func(r *Render, w http.ResponseWriter, us []benchUser) error {
+		rows := make([][]string, len(us))
+		for i, u := range us {
+			rows[i] = []string{strconv.Itoa(u.ID), u.Name, u.Email}
+		}
+		return r.CSV(w, http.StatusOK, rows)
+	})
+}
+
+func BenchmarkYAML(b *testing.B) {
+	benchRender(b, Options{}, func(r *Render, w http.ResponseWriter, us []benchUser) error {
+		return r.YAML(w, http.StatusOK, us)
+	})
+}
+
+func BenchmarkMsgPack(b *testing.B) {
+	benchRender(b, Options{}, func(r *Render, w http.ResponseWriter, us []benchUser) error {
+		return r.MsgPack(w, http.StatusOK, us)
+	})
+}
+
+func BenchmarkCanonicalJSON(b *testing.B) {
+	for _, p := range benchPayloads {
+		doc, err := json.Marshal(p.users)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(p.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := Canonicalize(doc); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
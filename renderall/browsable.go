@@ -0,0 +1,155 @@
+package renderall
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"strings"
+)
+
+// BrowsableJSON built-in renderer. It writes the JSON payload as a
+// collapsible, highlighted HTML page for humans exploring an API.
+type BrowsableJSON struct {
+	Head
+	Title string
+	Hook  MarshalHook
+}
+
+var browsableTemplate = template.Must(template.New("browsable").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>{{ .Title }}</title>
+<style>
+body{font:14px/1.5 ui-monospace,Menlo,Consolas,monospace;margin:2em;color:#24292e}
+h1{font-size:1.2em}
+details{margin-left:1.5em}summary{cursor:pointer;margin-left:-1.5em}
+.entry{margin-left:1.5em}.key{color:#005cc5}.string{color:#22863a}
+.number{color:#e36209}.literal{color:#d73a49}.punct{color:#6a737d}
+</style></head>
+<body><h1>{{ .Title }}</h1><div class="json">{{ .Body }}</div></body></html>
+`))
+
+// Render a browsable JSON response.
+func (b BrowsableJSON) Render(w http.ResponseWriter, v interface{}) error {
+	v, err := prepareJSON(b.Hook, v)
+	if err != nil {
+		return err
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var body strings.Builder
+	if err := writeJSONHTML(&body, dec); err != nil {
+		return err
+	}
+
+	out := bufPool.Get()
+	defer bufPool.Put(out)
+	err = browsableTemplate.Execute(out, struct {
+		Title string
+		Body  template.HTML
+	}{b.Title, template.HTML(body.String())})
+	if err != nil {
+		return err
+	}
+
+	b.Head.Write(w)
+	out.WriteTo(w)
+	return nil
+}
+
+// writeJSONHTML converts the next JSON value from dec into nested markup,
+// keeping the original key order.
+func writeJSONHTML(b *strings.Builder, dec *json.Decoder) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch t := tok.(type) {
+	case json.Delim:
+		open, close := string(t), "]"
+		if t == '{' {
+			close = "}"
+		}
+		b.WriteString(`<details open><summary><span class="punct">` + open + `</span></summary>`)
+		for dec.More() {
+			b.WriteString(`<div class="entry">`)
+			if t == '{' {
+				key, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				b.WriteString(`<span class="key">` + template.HTMLEscapeString(jsonString(key.(string))) + `</span><span class="punct">: </span>`)
+			}
+			if err := writeJSONHTML(b, dec); err != nil {
+				return err
+			}
+			b.WriteString(`</div>`)
+		}
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+		b.WriteString(`</details><span class="punct">` + close + `</span>`)
+	case string:
+		b.WriteString(`<span class="string">` + template.HTMLEscapeString(jsonString(t)) + `</span>`)
+	case json.Number:
+		b.WriteString(`<span class="number">` + t.String() + `</span>`)
+	case bool:
+		if t {
+			b.WriteString(`<span class="literal">true</span>`)
+		} else {
+			b.WriteString(`<span class="literal">false</span>`)
+		}
+	case nil:
+		b.WriteString(`<span class="literal">null</span>`)
+	}
+	return nil
+}
+
+// jsonString quotes s as JSON without HTML escaping, which the page markup already does.
+func jsonString(s string) string {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// browsable reports whether a JSON render for req should be shown as HTML.
+func (r *Render) browsable(req *http.Request) bool {
+	return r.opt.BrowsableAPI && prefersHTML(req)
+}
+
+// browsableJSON renders v with the BrowsableJSON engine.
+func (r *Render) browsableJSON(w http.ResponseWriter, status int, req *http.Request, v interface{}) error {
+	addVary(w.Header(), "Accept")
+	head := Head{
+		ContentType: r.withCharset(ContentHTML),
+		Status:      status,
+	}
+
+	b := BrowsableJSON{
+		Head: head,
+		Hook: r.marshalHook(),
+	}
+	if req != nil {
+		b.Title = req.Method + " " + req.URL.RequestURI()
+	}
+	return r.render(w, req, b, v)
+}
+
+// addVary adds value to the Vary header unless it is already listed.
+func addVary(h http.Header, value string) {
+	for _, v := range h.Values(VaryHeader) {
+		for _, f := range strings.Split(v, ",") {
+			if f = strings.TrimSpace(f); f == "*" || strings.EqualFold(f, value) {
+				return
+			}
+		}
+	}
+	h.Add(VaryHeader, value)
+}
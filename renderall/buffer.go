@@ -1,30 +1,88 @@
 package renderall
 
 //shamelessly stolen from github.com/unrolled/render (MIT Licensed), which came from github.com/oxtoacart/bpool package (apache licensed)
-import "bytes"
+import (
+	"bytes"
+	"sync"
+)
+
+const (
+	// DefaultMaxPooledBuffer is the largest buffer NewBufferPool retains.
+	DefaultMaxPooledBuffer = 1 << 20
+	// DefaultMaxPooledBytes is the total buffer capacity NewBufferPool retains.
+	DefaultMaxPooledBytes = 16 << 20
+)
 
 // bufPool represents a reusable buffer pool for executing templates into.
 var bufPool *BufferPool
 
+// SetBufferPool replaces the buffer pool every Render shares, e.g. with one
+// of other limits. Call it before New, as renders in flight may still return
+// buffers to the old pool.
+func SetBufferPool(bp *BufferPool) {
+	bufPool = bp
+}
+
 // BufferPool implements a pool of bytes.Buffers in the form of a bounded channel.
 // Pulled from the github.com/oxtoacart/bpool package (Apache licensed).
+//
+// Buffers grow to fit the largest render written into them, so the pool
+// caps what it retains: buffers above a maximum capacity are dropped rather
+// than returned, as are any that would take the pool over its memory budget.
 type BufferPool struct {
-	c chan *bytes.Buffer
+	c         chan *bytes.Buffer
+	maxBuffer int
+	maxBytes  int64
+
+	mu    sync.Mutex
+	stats BufferPoolStats
 }
 
-// NewBufferPool creates a new BufferPool bounded to the given size.
+// BufferPoolStats describes the memory a BufferPool holds.
+type BufferPoolStats struct {
+	// Buffers is the number of buffers in the pool.
+	Buffers int
+	// Retained is the total capacity of the buffers in the pool, in bytes.
+	Retained int64
+	// PeakRetained is the high-water mark of Retained.
+	PeakRetained int64
+	// Largest is the capacity of the largest buffer ever put back.
+	Largest int
+	// Dropped counts buffers discarded for exceeding a limit or a full pool.
+	Dropped uint64
+}
+
+// NewBufferPool creates a new BufferPool bounded to the given size, with the
+// default memory limits.
 func NewBufferPool(size int) (bp *BufferPool) {
+	return NewBufferPoolLimits(size, DefaultMaxPooledBuffer, DefaultMaxPooledBytes)
+}
+
+// NewBufferPoolLimits creates a new BufferPool bounded to the given size that
+// retains no buffer larger than maxBuffer bytes and at most maxBytes of
+// buffers in total. Limits of zero or less are not enforced.
+func NewBufferPoolLimits(size, maxBuffer int, maxBytes int64) *BufferPool {
 	return &BufferPool{
-		c: make(chan *bytes.Buffer, size),
+		c:         make(chan *bytes.Buffer, size),
+		maxBuffer: maxBuffer,
+		maxBytes:  maxBytes,
 	}
 }
 
 // Get gets a Buffer from the BufferPool, or creates a new one if none are
-// available in the pool.
+// available in the pool. A nil BufferPool, as engines used before New have,
+// always creates one.
 func (bp *BufferPool) Get() (b *bytes.Buffer) {
+	if bp == nil {
+		return new(bytes.Buffer)
+	}
 	select {
 	case b = <-bp.c:
-	// reuse existing buffer
+		// reuse existing buffer
+		bp.mu.Lock()
+		bp.stats.Buffers--
+		bp.stats.Retained -= int64(b.Cap())
+		bp.mu.Unlock()
 	default:
 		// create new buffer
 		b = bytes.NewBuffer([]byte{})
@@ -32,11 +90,47 @@ func (bp *BufferPool) Get() (b *bytes.Buffer) {
 	return
 }
 
-// Put returns the given Buffer to the BufferPool.
+// Put returns the given Buffer to the BufferPool, unless it is over the
+// pool's limits.
 func (bp *BufferPool) Put(b *bytes.Buffer) {
+	if bp == nil {
+		return
+	}
 	b.Reset()
+	size := b.Cap()
+
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+	if size > bp.stats.Largest {
+		bp.stats.Largest = size
+	}
+	if (bp.maxBuffer > 0 && size > bp.maxBuffer) || (bp.maxBytes > 0 && bp.stats.Retained+int64(size) > bp.maxBytes) {
+		bp.stats.Dropped++
+		return
+	}
 	select {
 	case bp.c <- b:
+		bp.stats.Buffers++
+		bp.stats.Retained += int64(size)
+		if bp.stats.Retained > bp.stats.PeakRetained {
+			bp.stats.PeakRetained = bp.stats.Retained
+		}
 	default: // Discard the buffer if the pool is full.
+		bp.stats.Dropped++
 	}
 }
+
+// Stats returns the pool's current memory use and high-water marks.
+func (bp *BufferPool) Stats() BufferPoolStats {
+	if bp == nil {
+		return BufferPoolStats{}
+	}
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+	return bp.stats
+}
+
+// BufferPoolStats returns the stats of the buffer pool renders share.
+func (r *Render) BufferPoolStats() BufferPoolStats {
+	return bufPool.Stats()
+}
@@ -0,0 +1,36 @@
+package renderall
+
+import "testing"
+
+func TestCanonicalize(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"whitespace", `{ "a" : [ 1 , true , null ] }`, `{"a":[1,true,null]}`},
+		{"numbers", `[1E30, 4.50, 0.002, 0.000001, 1e-7, -0, 1E21, 123456789012345680000]`,
+			`[1e+30,4.5,0.002,0.000001,1e-7,0,1e+21,123456789012345680000]`},
+		{"strings", `"\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/"`, `"€$\u000f\nA'B\"\\\\\"/"`},
+		{"utf16 key order", `{"\u20ac":1,"\r":2,"\ud83d\ude00":3,"1":4,"\u0080":5,"\u00f6":6}`,
+			"{\"\\r\":2,\"1\":4,\"\u0080\":5,\"ö\":6,\"€\":1,\"😀\":3}"},
+		{"nested", `{"b":{"d":1,"c":2},"a":[]}`, `{"a":[],"b":{"c":2,"d":1}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Canonicalize([]byte(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanonicalizeErrors(t *testing.T) {
+	for _, in := range []string{``, `{"a":}`, `1 2`, `1e400`} {
+		if _, err := Canonicalize([]byte(in)); err == nil {
+			t.Errorf("Canonicalize(%q) succeeded", in)
+		}
+	}
+}
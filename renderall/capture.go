@@ -0,0 +1,131 @@
+package renderall
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// ResponseTooLargeError is returned when a render outgrows
+// Options.MaxResponseBytes.
+type ResponseTooLargeError struct {
+	Limit int64
+}
+
+func (e *ResponseTooLargeError) Error() string {
+	return fmt.Sprintf("renderall: response exceeds %d bytes", e.Limit)
+}
+
+// captureWriter buffers a render so the body can be inspected and headers
+// adjusted before anything reaches the client. Headers are shared with the
+// underlying writer, the status and body are held back until flush.
+type captureWriter struct {
+	w      http.ResponseWriter
+	status int
+	buf    *bytes.Buffer
+	// limit caps the body size when positive; err records overflowing it,
+	// or an invalid status.
+	limit int64
+	err   error
+}
+
+func newCaptureWriter(w http.ResponseWriter) *captureWriter {
+	return &captureWriter{w: w, buf: bufPool.Get()}
+}
+
+// Header returns the underlying writer's header map.
+func (c *captureWriter) Header() http.Header {
+	return c.w.Header()
+}
+
+// WriteHeader records the status code; only the first call counts.
+func (c *captureWriter) WriteHeader(status int) {
+	if c.status != 0 || c.err != nil {
+		return
+	}
+	if status < 100 || status > 599 {
+		c.err = &StatusError{Status: status}
+		return
+	}
+	c.status = status
+}
+
+// Write appends b to the buffered body, failing once the body would
+// outgrow the limit.
+func (c *captureWriter) Write(b []byte) (int, error) {
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+	if c.err != nil {
+		return 0, c.err
+	}
+	if c.limit > 0 && int64(c.buf.Len()+len(b)) > c.limit {
+		c.err = &ResponseTooLargeError{Limit: c.limit}
+		return 0, c.err
+	}
+	return c.buf.Write(b)
+}
+
+// flush sends the recorded status and body to the underlying writer.
+func (c *captureWriter) flush() error {
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+	// Post-render steps may have changed the length an engine sent.
+	if c.w.Header().Get(ContentLength) != "" {
+		c.w.Header().Set(ContentLength, strconv.Itoa(c.buf.Len()))
+	}
+	c.w.WriteHeader(c.status)
+	_, err := c.buf.WriteTo(c.w)
+	return err
+}
+
+// release returns the body buffer to the pool.
+func (c *captureWriter) release() {
+	bufPool.Put(c.buf)
+	c.buf = nil
+}
+
+// buffered reports whether renders must be captured before being sent.
+func (r *Render) buffered() bool {
+	return len(r.opt.DigestAlgorithms) > 0 || r.opt.Signature != nil || len(r.opt.PostRender) > 0 ||
+		r.opt.MaxResponseBytes > 0 || r.opt.ETag || r.opt.Compress
+}
+
+// renderBuffered runs e against a captureWriter and applies the post-render
+// steps to the captured response before sending it. Nothing reaches the
+// client if the engine or a step fails.
+func (r *Render) renderBuffered(w http.ResponseWriter, ctx *RenderContext) error {
+	c := newCaptureWriter(w)
+	c.limit = r.opt.MaxResponseBytes
+	defer c.release()
+
+	// Engines don't all report write errors, so check for overflow as well.
+	if err := ctx.Engine.Render(c, ctx.Data); err != nil {
+		return err
+	}
+	if c.err != nil {
+		return c.err
+	}
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+	if err := r.postRender(ctx, c); err != nil {
+		return err
+	}
+	if ctx.audit != nil {
+		ctx.audit.identity(c.status, c.buf.Bytes())
+	}
+	if err := r.encodeResponse(ctx.Request, c); err != nil {
+		return err
+	}
+	applyExtras(w)
+	if err := r.setDigests(c.Header(), c.buf.Bytes(), representation(c.status, c.buf.Bytes(), ctx.Data)); err != nil {
+		return err
+	}
+	if err := r.sign(c.Header(), c.status, c.buf.Bytes()); err != nil {
+		return err
+	}
+	return c.flush()
+}
@@ -0,0 +1,123 @@
+package renderall
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ContentEncoding header constant.
+	ContentEncoding = "Content-Encoding"
+	// ETagHeader header constant.
+	ETagHeader = "ETag"
+	// VaryHeader header constant.
+	VaryHeader = "Vary"
+)
+
+// minCompressBytes is the body size below which gzip is not worth its header.
+const minCompressBytes = 1024
+
+// acceptsGzip reports whether an Accept-Encoding header allows gzip.
+func acceptsGzip(accept string) bool {
+	ok := false
+	for _, part := range strings.Split(accept, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+		q := 1.0
+		if v, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if coding == "gzip" {
+			return q > 0
+		}
+		ok = q > 0
+	}
+	return ok
+}
+
+// compressible reports whether responses of contentType are worth gzipping.
+// Images other than SVG and archives are already compressed.
+func compressible(contentType string) bool {
+	mt, _, _ := strings.Cut(contentType, ";")
+	mt = strings.TrimSpace(mt)
+	switch {
+	case mt == ContentSVG:
+		return true
+	case strings.HasPrefix(mt, "image/"), strings.HasPrefix(mt, "video/"), strings.HasPrefix(mt, "audio/"):
+		return false
+	}
+	switch mt {
+	case ContentZip, ContentGzip, ContentXLSX, ContentPDF, "font/woff", "font/woff2":
+		return false
+	}
+	return true
+}
+
+// bodyETag returns a strong ETag for body.
+func bodyETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+}
+
+// encodeResponse sets the ETag for the captured body and gzips it for
+// requests that accept it. ETags are per encoding, the gzip variant of a
+// generated one gets a "-gzip" suffix and one set by the handler is made
+// weak, so caches never match one against the other, and
+// Vary: Accept-Encoding is sent whenever the body could have been either.
+// Responses offering byte ranges are left alone, as ranges address the
+// identity body.
+func (r *Render) encodeResponse(req *http.Request, c *captureWriter) error {
+	// A partial body's ETag and encoding belong to the whole representation.
+	if c.status == http.StatusPartialContent {
+		return nil
+	}
+	h := c.Header()
+	generated := false
+	if r.opt.ETag && c.status == http.StatusOK && h.Get(ETagHeader) == "" {
+		h.Set(ETagHeader, bodyETag(c.buf.Bytes()))
+		generated = true
+	}
+
+	if !r.opt.Compress || req == nil || h.Get(ContentEncoding) != "" || h.Get("Accept-Ranges") != "" ||
+		!bodyAllowed(c.status) || !compressible(h.Get(ContentType)) {
+		return nil
+	}
+	addVary(h, "Accept-Encoding")
+	if c.buf.Len() < minCompressBytes || !acceptsGzip(req.Header.Get("Accept-Encoding")) {
+		return nil
+	}
+
+	out := bufPool.Get()
+	defer bufPool.Put(out)
+	zw := gzip.NewWriter(out)
+	if _, err := zw.Write(c.buf.Bytes()); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	c.buf.Reset()
+	out.WriteTo(c.buf)
+	h.Set(ContentEncoding, "gzip")
+
+	if etag := h.Get(ETagHeader); generated {
+		h.Set(ETagHeader, strings.TrimSuffix(etag, `"`)+`-gzip"`)
+	} else if etag != "" && !strings.HasPrefix(etag, "W/") {
+		h.Set(ETagHeader, "W/"+etag)
+	}
+	return nil
+}
+
+// bodyAllowed reports whether responses with status carry a body.
+func bodyAllowed(status int) bool {
+	return status >= 200 && status != http.StatusNoContent && status != http.StatusNotModified
+}
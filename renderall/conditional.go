@@ -0,0 +1,173 @@
+package renderall
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// LastModifiedHeader header constant.
+const LastModifiedHeader = "Last-Modified"
+
+// Conditional renders a response only if the request's preconditions allow
+// it, answering 304 Not Modified or 412 Precondition Failed otherwise.
+// Create one with Render.Conditional and supply the resource's validators:
+//
+//	r.Conditional(w, req).ETag(etag).LastModified(mtime).JSON(http.StatusOK, v)
+type Conditional struct {
+	r        *Render
+	w        http.ResponseWriter
+	req      *http.Request
+	etag     string
+	modified time.Time
+}
+
+// Conditional starts a conditional render of the response to req.
+func (r *Render) Conditional(w http.ResponseWriter, req *http.Request) *Conditional {
+	return &Conditional{r: r, w: w, req: req}
+}
+
+// ETag sets the current entity tag, e.g. `"v42"` or `W/"v42"`. Unquoted
+// tags are quoted, after any W/ prefix.
+func (c *Conditional) ETag(etag string) *Conditional {
+	weak := ""
+	if strings.HasPrefix(etag, "W/") && len(etag) > 2 {
+		weak, etag = "W/", etag[2:]
+	}
+	if etag != "" && (len(etag) < 2 || etag[0] != '"' || etag[len(etag)-1] != '"') {
+		etag = `"` + etag + `"`
+	}
+	c.etag = weak + etag
+	return c
+}
+
+// LastModified sets the time the resource last changed.
+func (c *Conditional) LastModified(t time.Time) *Conditional {
+	c.modified = t.UTC().Truncate(time.Second)
+	return c
+}
+
+// done sends the validators and evaluates the preconditions in RFC 9110
+// section 13.2.2 order. It reports whether the response has been handled.
+// Preconditions only apply to responses that would otherwise be 2xx.
+func (c *Conditional) done(status int) bool {
+	h := c.w.Header()
+	if c.etag != "" {
+		h.Set(ETagHeader, c.etag)
+	}
+	if !c.modified.IsZero() {
+		h.Set(LastModifiedHeader, c.modified.Format(http.TimeFormat))
+	}
+	if status < 200 || status > 299 || c.req == nil {
+		return false
+	}
+
+	get := c.req.Method == http.MethodGet || c.req.Method == http.MethodHead
+	rh := c.req.Header
+	if im := rh.Get("If-Match"); im != "" {
+		if !etagMatch(im, c.etag, false) {
+			return c.fail(http.StatusPreconditionFailed)
+		}
+	} else if t, ok := headerTime(rh.Get("If-Unmodified-Since")); ok && !c.modified.IsZero() && c.modified.After(t) {
+		return c.fail(http.StatusPreconditionFailed)
+	}
+
+	if inm := rh.Get("If-None-Match"); inm != "" {
+		if etagMatch(inm, c.etag, true) {
+			if get {
+				return c.fail(http.StatusNotModified)
+			}
+			return c.fail(http.StatusPreconditionFailed)
+		}
+	} else if t, ok := headerTime(rh.Get("If-Modified-Since")); ok && get && !c.modified.IsZero() && !c.modified.After(t) {
+		return c.fail(http.StatusNotModified)
+	}
+	return false
+}
+
+// fail answers with status in place of the render.
+func (c *Conditional) fail(status int) bool {
+	if status == http.StatusNotModified {
+		h := c.w.Header()
+		for _, k := range []string{ContentType, ContentLength, ContentEncoding} {
+			h.Del(k)
+		}
+		c.w.WriteHeader(status)
+		return true
+	}
+	c.r.Status(c.w, c.req, status, nil)
+	return true
+}
+
+// etagMatch reports whether the If-Match or If-None-Match list matches
+// etag, using the weak comparison if weak is set and the strong one if not.
+// "*" matches any current representation, which a render always has.
+func etagMatch(list, etag string, weak bool) bool {
+	if strings.TrimSpace(list) == "*" {
+		return true
+	}
+	if etag == "" || (!weak && strings.HasPrefix(etag, "W/")) {
+		return false
+	}
+	for _, tag := range strings.Split(list, ",") {
+		tag = strings.TrimSpace(tag)
+		if weak {
+			if strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		} else if tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// headerTime parses an HTTP date header, ignoring blank or invalid values.
+func headerTime(v string) (time.Time, bool) {
+	if v == "" {
+		return time.Time{}, false
+	}
+	t, err := http.ParseTime(v)
+	return t, err == nil
+}
+
+// JSON renders v as JSON unless a precondition answers the request.
+func (c *Conditional) JSON(status int, v interface{}, jsonOpt ...JSONOptions) error {
+	if c.done(status) {
+		return nil
+	}
+	return c.r.JSON(c.w, status, v, jsonOpt...)
+}
+
+// XML renders v as XML unless a precondition answers the request.
+func (c *Conditional) XML(status int, v interface{}, xmlOpt ...XMLOptions) error {
+	if c.done(status) {
+		return nil
+	}
+	return c.r.XML(c.w, status, v, xmlOpt...)
+}
+
+// HTML renders the named template unless a precondition answers the request.
+func (c *Conditional) HTML(status int, name string, binding interface{}, htmlOpt ...HTMLOptions) error {
+	if c.done(status) {
+		return nil
+	}
+	return c.r.HTML(c.w, status, name, binding, htmlOpt...)
+}
+
+// Data writes v unless a precondition answers the request.
+func (c *Conditional) Data(status int, v []byte) error {
+	if c.done(status) {
+		return nil
+	}
+	return c.r.Data(c.w, status, v)
+}
+
+// Render renders e unless a precondition answers the request. Preconditions
+// are evaluated as for a 200 response.
+func (c *Conditional) Render(e Engine, data interface{}) error {
+	if c.done(http.StatusOK) {
+		return nil
+	}
+	return c.r.render(c.w, c.req, e, data)
+}
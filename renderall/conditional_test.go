@@ -0,0 +1,41 @@
+package renderall
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestConditionalETag(t *testing.T) {
+	tests := []struct {
+		etag, want string
+	}{
+		{"", ""},
+		{"v42", `"v42"`},
+		{`"v42"`, `"v42"`},
+		{"W/v42", `W/"v42"`},
+		{`W/"v42"`, `W/"v42"`},
+		{`"`, `"""`},
+	}
+	r := New(Options{})
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if err := r.Conditional(w, req).ETag(tt.etag).Data(http.StatusOK, []byte("x")); err != nil {
+			t.Fatal(err)
+		}
+		if got := w.Header().Get(ETagHeader); got != tt.want {
+			t.Errorf("ETag(%q) sent %q, want %q", tt.etag, got, tt.want)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("If-None-Match", `"v42"`)
+	if err := r.Conditional(w, req).ETag("W/v42").Data(http.StatusOK, []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusNotModified {
+		t.Errorf("weak match: status %d, want 304", w.Code)
+	}
+}
@@ -0,0 +1,11 @@
+package renderall
+
+// contextKey is the type of the request context keys owned by this package.
+type contextKey int
+
+const (
+	// startKey holds the time.Time a request started, set by Timed.
+	startKey contextKey = iota
+	// nonceKey holds the request's CSP nonce, set by Nonces.
+	nonceKey
+)
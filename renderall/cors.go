@@ -0,0 +1,137 @@
+package renderall
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORS headers.
+const (
+	AllowOriginHeader      = "Access-Control-Allow-Origin"
+	AllowCredentialsHeader = "Access-Control-Allow-Credentials"
+	AllowMethodsHeader     = "Access-Control-Allow-Methods"
+	AllowHeadersHeader     = "Access-Control-Allow-Headers"
+	ExposeHeadersHeader    = "Access-Control-Expose-Headers"
+	MaxAgeHeader           = "Access-Control-Max-Age"
+	RequestMethodHeader    = "Access-Control-Request-Method"
+	RequestHeadersHeader   = "Access-Control-Request-Headers"
+)
+
+// CORS is a cross-origin resource sharing policy. Set as Options.CORS, it
+// is applied to every render that knows its request, and answers
+// preflights through Render.Preflight.
+type CORS struct {
+	// Origins allowed to read responses, e.g. "https://app.example.com", or "*" for any.
+	AllowedOrigins []string
+	// AllowOrigin, if set, decides instead of AllowedOrigins.
+	AllowOrigin func(origin string) bool
+	// Methods allowed by preflights. Defaults to GET, HEAD, and POST.
+	AllowedMethods []string
+	// Request headers allowed by preflights. Defaults to those the preflight asks for.
+	AllowedHeaders []string
+	// Response headers scripts may read beyond the CORS safelisted ones.
+	ExposedHeaders []string
+	// Lets requests carry cookies and authorization. The origin is then echoed, never "*", and origins only "*" matches get no credentials.
+	AllowCredentials bool
+	// How long browsers may cache a preflight. Zero leaves it to the browser.
+	MaxAge time.Duration
+}
+
+// allowed reports whether origin may read responses, and whether it was
+// named, by AllowedOrigins or AllowOrigin, rather than only matched by "*".
+func (c *CORS) allowed(origin string) (ok, named bool) {
+	if c.AllowOrigin != nil {
+		ok = c.AllowOrigin(origin)
+		return ok, ok
+	}
+	for _, o := range c.AllowedOrigins {
+		if strings.EqualFold(o, origin) {
+			return true, true
+		}
+		if o == "*" {
+			ok = true
+		}
+	}
+	return ok, false
+}
+
+// wildcard reports whether responses can say "*" rather than echo the
+// origin, which also spares caches a Vary: Origin.
+func (c *CORS) wildcard() bool {
+	if c.AllowCredentials || c.AllowOrigin != nil {
+		return false
+	}
+	for _, o := range c.AllowedOrigins {
+		if o == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// allowOrigin sets the origin headers for req and reports whether its
+// origin is allowed.
+func (c *CORS) allowOrigin(h http.Header, req *http.Request) bool {
+	origin := req.Header.Get("Origin")
+	if c.wildcard() {
+		if origin == "" {
+			return false
+		}
+		h.Set(AllowOriginHeader, "*")
+		return true
+	}
+	addVary(h, "Origin")
+	if origin == "" {
+		return false
+	}
+	ok, named := c.allowed(origin)
+	if !ok {
+		return false
+	}
+	h.Set(AllowOriginHeader, origin)
+	// Credentials for whatever "*" matches would let any site read them.
+	if c.AllowCredentials && named {
+		h.Set(AllowCredentialsHeader, "true")
+	}
+	return true
+}
+
+// Apply sets the CORS headers of an actual, non-preflight, response to req.
+func (c *CORS) Apply(h http.Header, req *http.Request) {
+	if c.allowOrigin(h, req) && len(c.ExposedHeaders) > 0 {
+		h.Set(ExposeHeadersHeader, strings.Join(c.ExposedHeaders, ", "))
+	}
+}
+
+// Preflight answers a CORS preflight request with 204 No Content and the
+// Options.CORS method, header, and max age grants. Disallowed origins get
+// the 204 without grants, which browsers treat as a refusal.
+func (r *Render) Preflight(w http.ResponseWriter, req *http.Request) error {
+	c := r.opt.CORS
+	if c == nil {
+		return r.fail(w, fmt.Errorf("renderall: Preflight requires Options.CORS"))
+	}
+	h := w.Header()
+	addVary(h, RequestMethodHeader)
+	addVary(h, RequestHeadersHeader)
+	if c.allowOrigin(h, req) && req.Header.Get(RequestMethodHeader) != "" {
+		methods := c.AllowedMethods
+		if len(methods) == 0 {
+			methods = []string{http.MethodGet, http.MethodHead, http.MethodPost}
+		}
+		h.Set(AllowMethodsHeader, strings.Join(methods, ", "))
+		if len(c.AllowedHeaders) > 0 {
+			h.Set(AllowHeadersHeader, strings.Join(c.AllowedHeaders, ", "))
+		} else if asked := req.Header.Get(RequestHeadersHeader); asked != "" {
+			h.Set(AllowHeadersHeader, asked)
+		}
+		if c.MaxAge > 0 {
+			h.Set(MaxAgeHeader, strconv.Itoa(int(c.MaxAge/time.Second)))
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
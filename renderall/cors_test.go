@@ -0,0 +1,36 @@
+package renderall
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCORSCredentials(t *testing.T) {
+	tests := []struct {
+		name        string
+		cors        CORS
+		origin      string
+		allow       string
+		credentials string
+	}{
+		{"wildcard", CORS{AllowedOrigins: []string{"*"}}, "https://a.example", "*", ""},
+		{"named", CORS{AllowedOrigins: []string{"https://a.example"}, AllowCredentials: true}, "https://a.example", "https://a.example", "true"},
+		{"wildcard with credentials", CORS{AllowedOrigins: []string{"https://a.example", "*"}, AllowCredentials: true}, "https://evil.example", "https://evil.example", ""},
+		{"named beside wildcard", CORS{AllowedOrigins: []string{"*", "https://a.example"}, AllowCredentials: true}, "https://A.example", "https://A.example", "true"},
+		{"func", CORS{AllowOrigin: func(string) bool { return true }, AllowCredentials: true}, "https://b.example", "https://b.example", "true"},
+		{"refused", CORS{AllowedOrigins: []string{"https://a.example"}, AllowCredentials: true}, "https://b.example", "", ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Origin", tt.origin)
+		h := http.Header{}
+		tt.cors.Apply(h, req)
+		if got := h.Get(AllowOriginHeader); got != tt.allow {
+			t.Errorf("%s: %s = %q, want %q", tt.name, AllowOriginHeader, got, tt.allow)
+		}
+		if got := h.Get(AllowCredentialsHeader); got != tt.credentials {
+			t.Errorf("%s: %s = %q, want %q", tt.name, AllowCredentialsHeader, got, tt.credentials)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package renderall
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"html/template"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// NewNonce returns a random URL-safe base64 nonce suitable for CSP.
+func NewNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Nonces is middleware that stores a fresh CSP nonce in each request's
+// context, so handlers can read it with Nonce and HTML renders given the
+// request use the same value.
+func Nonces(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		nonce, err := NewNonce()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		next.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), nonceKey, nonce)))
+	})
+}
+
+// Nonce returns the CSP nonce stored by Nonces, or blank if there is none.
+func Nonce(req *http.Request) string {
+	if req == nil {
+		return ""
+	}
+	nonce, _ := req.Context().Value(nonceKey).(string)
+	return nonce
+}
+
+// cspNonce returns the request's nonce, or a fresh one for this response
+// read from Options.Random.
+func (r *Render) cspNonce(req *http.Request) (string, error) {
+	if nonce := Nonce(req); nonce != "" {
+		return nonce, nil
+	}
+	b := make([]byte, 16)
+	if _, err := io.ReadFull(r.random(), b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// cspFuncs sets the Content-Security-Policy header for the response and
+// exposes its nonce to templates.
+func (r *Render) cspFuncs(w http.ResponseWriter, nonce string) template.FuncMap {
+	if r.opt.ContentSecurityPolicy != "" {
+		w.Header().Set("Content-Security-Policy", strings.Replace(r.opt.ContentSecurityPolicy, "{nonce}", nonce, -1))
+	}
+
+	return template.FuncMap{
+		"cspNonce": func() string {
+			return nonce
+		},
+	}
+}
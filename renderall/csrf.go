@@ -0,0 +1,38 @@
+package renderall
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+)
+
+// defaultCSRFField matches the field name used by nosurf.
+const defaultCSRFField = "csrf_token"
+
+// csrfToken returns the token for req from Options.CSRFToken.
+func (r *Render) csrfToken(req *http.Request) (string, error) {
+	if r.opt.CSRFToken == nil {
+		return "", fmt.Errorf("renderall: Options.CSRFToken is not configured")
+	}
+	if req == nil {
+		return "", fmt.Errorf("renderall: CSRF template funcs require HTMLOptions.Request")
+	}
+	return r.opt.CSRFToken(req), nil
+}
+
+// csrfFuncs expose the request's CSRF token to templates.
+func (r *Render) csrfFuncs(req *http.Request) template.FuncMap {
+	return template.FuncMap{
+		"csrfToken": func() (string, error) {
+			return r.csrfToken(req)
+		},
+		"csrfField": func() (template.HTML, error) {
+			token, err := r.csrfToken(req)
+			if err != nil {
+				return "", err
+			}
+			return template.HTML(`<input type="hidden" name="` + template.HTMLEscapeString(r.opt.CSRFFieldName) +
+				`" value="` + template.HTMLEscapeString(token) + `">`), nil
+		},
+	}
+}
@@ -0,0 +1,149 @@
+package renderall
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"reflect"
+)
+
+const (
+	// ContentCSV header value for CSV data.
+	ContentCSV = "text/csv"
+	// ContentTSV header value for tab separated data.
+	ContentTSV = "text/tab-separated-values"
+)
+
+var utf8BOM = []byte("\xEF\xBB\xBF")
+
+// CSVOptions is a struct for overriding some rendering Options for specific CSV call.
+type CSVOptions struct {
+	// Field delimiter. Overrides Options.CSVComma when not zero.
+	Comma rune
+	// Writes a UTF-8 byte order mark even if Options.CSVBOM is false.
+	BOM bool
+	// Writes the Excel sep= hint line even if Options.CSVSepHint is false.
+	SepHint bool
+}
+
+// CSV built-in renderer. It renders a [][]string as is, or a slice of structs
+// with a header row, using the same tags as Table.
+type CSV struct {
+	Head
+	Comma rune
+	// BOM starts the body with a UTF-8 byte order mark, which Excel needs to
+	// read non-ASCII text correctly.
+	BOM bool
+	// SepHint writes a "sep=" line telling Excel the delimiter.
+	SepHint bool
+	Formats map[string]func(interface{}) string
+	// DisableRedaction writes struct fields tagged `render:"redact"` and
+	// `render:"omit"` as is.
+	DisableRedaction bool
+}
+
+// Render a CSV response.
+func (c CSV) Render(w http.ResponseWriter, v interface{}) error {
+	records, err := csvRecords(v, c.Formats, !c.DisableRedaction)
+	if err != nil {
+		return err
+	}
+
+	comma := c.Comma
+	if comma == 0 {
+		comma = ','
+	}
+
+	var buf bytes.Buffer
+	if c.BOM {
+		buf.Write(utf8BOM)
+	}
+	if c.SepHint {
+		fmt.Fprintf(&buf, "sep=%c\r\n", comma)
+	}
+	cw := csv.NewWriter(&buf)
+	cw.Comma = comma
+	cw.UseCRLF = true
+	if err := cw.WriteAll(records); err != nil {
+		return err
+	}
+
+	c.Head.Write(w)
+	buf.WriteTo(w)
+	return nil
+}
+
+// csvRecords converts v to CSV records, applying the redaction tags if redact.
+func csvRecords(v interface{}, formats map[string]func(interface{}) string, redact bool) ([][]string, error) {
+	if records, ok := v.([][]string); ok {
+		return records, nil
+	}
+
+	columns, items, err := tableRows("csv", v, redact)
+	if err != nil {
+		return nil, err
+	}
+	records := make([][]string, 0, len(items)+1)
+	header := make([]string, len(columns))
+	for i, col := range columns {
+		header[i] = col.header
+	}
+	records = append(records, header)
+	for _, item := range items {
+		record := make([]string, len(columns))
+		for i, col := range columns {
+			if col.redact {
+				record[i] = RedactedValue
+				continue
+			}
+			if item.Kind() != reflect.Struct {
+				continue
+			}
+			cell, err := tableCell(item.Field(col.index), col.format, formats)
+			if err != nil {
+				return nil, err
+			}
+			record[i] = cell
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// CSV renders v, a [][]string or a slice of structs, as CSV.
+func (r *Render) CSV(w http.ResponseWriter, status int, v interface{}, csvOpt ...CSVOptions) error {
+	return r.csv(w, status, ContentCSV, r.opt.CSVComma, v, csvOpt)
+}
+
+// TSV renders v like CSV, separated by tabs.
+func (r *Render) TSV(w http.ResponseWriter, status int, v interface{}, csvOpt ...CSVOptions) error {
+	return r.csv(w, status, ContentTSV, '\t', v, csvOpt)
+}
+
+func (r *Render) csv(w http.ResponseWriter, status int, contentType string, comma rune, v interface{}, csvOpt []CSVOptions) error {
+	opt := CSVOptions{}
+	if len(csvOpt) > 0 {
+		opt = csvOpt[0]
+	}
+	if opt.Comma != 0 {
+		comma = opt.Comma
+	}
+
+	head := Head{
+		ContentType: r.withCharset(contentType),
+		Status:      status,
+	}
+
+	c := CSV{
+		Head:    head,
+		Comma:   comma,
+		BOM:     r.opt.CSVBOM || opt.BOM,
+		SepHint: r.opt.CSVSepHint || opt.SepHint,
+		Formats: r.opt.TableFormats,
+
+		DisableRedaction: r.opt.DisableRedaction,
+	}
+
+	return r.Render(w, c, v)
+}
@@ -0,0 +1,25 @@
+package renderall
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCSVRedaction(t *testing.T) {
+	rows := []tableAccount{{"ann", "secret", 7}}
+
+	records, err := csvRecords(rows, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]string{{"Name", "Password"}, {"ann", RedactedValue}}; !reflect.DeepEqual(records, want) {
+		t.Errorf("redacted records = %q, want %q", records, want)
+	}
+	records, err = csvRecords(rows, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]string{{"Name", "Password", "Internal"}, {"ann", "secret", "7"}}; !reflect.DeepEqual(records, want) {
+		t.Errorf("records = %q, want %q", records, want)
+	}
+}
@@ -0,0 +1,266 @@
+package renderall
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// davNamespace is the namespace of WebDAV elements, written with the D prefix.
+const davNamespace = "DAV:"
+
+// DAVOptions is a struct for overriding some rendering Options for specific Multistatus call.
+type DAVOptions struct {
+	// Request being served, for PreRender hooks and request IDs. Defaults to nil.
+	Request *http.Request
+}
+
+// DAVMultistatus is an RFC 4918 multistatus body, written by Multistatus.
+type DAVMultistatus struct {
+	Responses []DAVResponse
+	// Description is the responsedescription of the whole multistatus. Omitted if blank.
+	Description string
+}
+
+// DAVResponse is the result for one or more resources of a multistatus.
+// A response carries either Propstats, for PROPFIND and PROPPATCH, or a
+// Status for all of its Hrefs, e.g. the members a DELETE failed on.
+type DAVResponse struct {
+	// Href of the resource. Only responses with a Status may list more than one.
+	Href []string
+	// Status of the resources, as an HTTP status code. Ignored if there are Propstats.
+	Status    int
+	Propstats []DAVPropstat
+	// Error is marshaled into the error element, e.g. a precondition. Omitted if nil.
+	Error interface{}
+	// Description is the responsedescription. Omitted if blank.
+	Description string
+	// Location the resource was moved to, for 3xx statuses. Omitted if blank.
+	Location string
+}
+
+// DAVPropstat groups the properties of a resource that share a status.
+type DAVPropstat struct {
+	Props []DAVProp
+	// Status of the properties, as an HTTP status code, e.g. 200 or 404.
+	Status int
+	// Error is marshaled into the error element. Omitted if nil.
+	Error interface{}
+	// Description is the responsedescription. Omitted if blank.
+	Description string
+}
+
+// DAVProp is a property of a resource. Names in the DAV: namespace are
+// written with its D prefix, and others declare their namespace.
+type DAVProp struct {
+	Name xml.Name
+	// Value of the property: text for strings, numbers, and booleans, the
+	// HTTP date for a time.Time as getlastmodified wants, an empty element
+	// for nil, e.g. a name only PROPFIND, and marshaled XML otherwise.
+	Value interface{}
+}
+
+// DAV built-in renderer. It writes a DAVMultistatus as XML.
+type DAV struct {
+	Head
+	// Prolog is written before the multistatus.
+	Prolog string
+}
+
+// Render a WebDAV multistatus response.
+func (d DAV) Render(w http.ResponseWriter, v interface{}) error {
+	return renderEncoded(w, d.Head, d, v)
+}
+
+// Encode writes the multistatus document to w.
+func (d DAV) Encode(w io.Writer, v interface{}) (Encoded, error) {
+	return encodeBuffered(w, d, d.Head.ContentType, v)
+}
+
+// encodeBuffer writes the multistatus document to buf.
+func (d DAV) encodeBuffer(buf *bytes.Buffer, v interface{}) error {
+	var ms DAVMultistatus
+	switch t := v.(type) {
+	case DAVMultistatus:
+		ms = t
+	case *DAVMultistatus:
+		ms = *t
+	default:
+		return fmt.Errorf("renderall: DAV cannot render %T, want DAVMultistatus", v)
+	}
+
+	buf.WriteString(d.Prolog)
+	buf.WriteString(`<D:multistatus xmlns:D="` + davNamespace + `">`)
+	for _, res := range ms.Responses {
+		if err := writeDAVResponse(buf, res); err != nil {
+			return err
+		}
+	}
+	writeDAVText(buf, "D:responsedescription", ms.Description)
+	buf.WriteString("</D:multistatus>")
+	return nil
+}
+
+// writeDAVResponse writes a response element.
+func writeDAVResponse(buf *bytes.Buffer, res DAVResponse) error {
+	switch {
+	case len(res.Href) == 0:
+		return fmt.Errorf("renderall: DAV response has no href")
+	case len(res.Propstats) > 0 && len(res.Href) > 1:
+		return fmt.Errorf("renderall: DAV response with propstat has %d hrefs, want 1", len(res.Href))
+	case len(res.Propstats) == 0 && res.Status == 0:
+		return fmt.Errorf("renderall: DAV response for %s has neither status nor propstat", res.Href[0])
+	}
+
+	buf.WriteString("<D:response>")
+	for _, href := range res.Href {
+		writeDAVText(buf, "D:href", href)
+	}
+	if len(res.Propstats) == 0 {
+		writeDAVText(buf, "D:status", davStatus(res.Status))
+	}
+	for _, ps := range res.Propstats {
+		buf.WriteString("<D:propstat><D:prop>")
+		for _, p := range ps.Props {
+			if err := writeDAVProp(buf, p); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("</D:prop>")
+		writeDAVText(buf, "D:status", davStatus(ps.Status))
+		if err := writeDAVError(buf, ps.Error); err != nil {
+			return err
+		}
+		writeDAVText(buf, "D:responsedescription", ps.Description)
+		buf.WriteString("</D:propstat>")
+	}
+	if err := writeDAVError(buf, res.Error); err != nil {
+		return err
+	}
+	writeDAVText(buf, "D:responsedescription", res.Description)
+	if res.Location != "" {
+		buf.WriteString("<D:location>")
+		writeDAVText(buf, "D:href", res.Location)
+		buf.WriteString("</D:location>")
+	}
+	buf.WriteString("</D:response>")
+	return nil
+}
+
+// writeDAVProp writes the property p.
+func writeDAVProp(buf *bytes.Buffer, p DAVProp) error {
+	if p.Name.Local == "" {
+		return fmt.Errorf("renderall: DAV property has no name")
+	}
+	name := p.Name.Local
+	buf.WriteString("<")
+	switch p.Name.Space {
+	case davNamespace:
+		name = "D:" + name
+		buf.WriteString(name)
+	case "":
+		buf.WriteString(name)
+	default:
+		buf.WriteString(name + ` xmlns="`)
+		xml.EscapeText(buf, []byte(p.Name.Space))
+		buf.WriteByte('"')
+	}
+
+	var text string
+	switch v := p.Value.(type) {
+	case nil:
+		buf.WriteString("/>")
+		return nil
+	case time.Time:
+		text = v.UTC().Format(http.TimeFormat)
+	default:
+		if s, ok := davScalar(reflect.ValueOf(v)); ok {
+			text = s
+			break
+		}
+		b, err := xml.Marshal(v)
+		if err != nil {
+			return err
+		}
+		buf.WriteByte('>')
+		buf.Write(b)
+		buf.WriteString("</" + name + ">")
+		return nil
+	}
+	buf.WriteByte('>')
+	xml.EscapeText(buf, []byte(text))
+	buf.WriteString("</" + name + ">")
+	return nil
+}
+
+// davScalar formats strings, numbers, and booleans of any kind, including
+// named types such as an int32 quota or a uint64 length, as text.
+func davScalar(v reflect.Value) (string, bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), true
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), true
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), true
+	}
+	return "", false
+}
+
+// writeDAVError writes the error element holding v, if any.
+func writeDAVError(buf *bytes.Buffer, v interface{}) error {
+	if v == nil {
+		return nil
+	}
+	b, err := xml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	buf.WriteString("<D:error>")
+	buf.Write(b)
+	buf.WriteString("</D:error>")
+	return nil
+}
+
+// writeDAVText writes the element name holding s, unless s is blank.
+func writeDAVText(buf *bytes.Buffer, name, s string) {
+	if s == "" {
+		return
+	}
+	buf.WriteString("<" + name + ">")
+	xml.EscapeText(buf, []byte(s))
+	buf.WriteString("</" + name + ">")
+}
+
+// davStatus is the status line WebDAV status elements hold.
+func davStatus(code int) string {
+	return "HTTP/1.1 " + strconv.Itoa(code) + " " + http.StatusText(code)
+}
+
+// Multistatus writes ms as an RFC 4918 multistatus response with status 207.
+func (r *Render) Multistatus(w http.ResponseWriter, ms DAVMultistatus, davOpt ...DAVOptions) error {
+	opt := DAVOptions{}
+	if len(davOpt) > 0 {
+		opt = davOpt[0]
+	}
+
+	head := Head{
+		ContentType: r.withCharset("application/xml"),
+		Status:      http.StatusMultiStatus,
+	}
+
+	d := DAV{
+		Head:   head,
+		Prolog: `<?xml version="1.0" encoding="` + r.opt.Charset + `"?>` + "\n",
+	}
+	return r.render(w, opt.Request, d, ms)
+}
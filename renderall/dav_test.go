@@ -0,0 +1,45 @@
+package renderall
+
+import (
+	"bytes"
+	"encoding/xml"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestWriteDAVProp(t *testing.T) {
+	type etag string
+	dav := func(local string) xml.Name { return xml.Name{Space: davNamespace, Local: local} }
+	tests := []struct {
+		prop DAVProp
+		want string
+	}{
+		{DAVProp{Name: dav("displayname"), Value: "a & b"}, `<D:displayname>a &amp; b</D:displayname>`},
+		{DAVProp{Name: dav("getetag"), Value: etag(`"x"`)}, `<D:getetag>&#34;x&#34;</D:getetag>`},
+		{DAVProp{Name: dav("getcontentlength"), Value: uint64(math.MaxUint64)}, `<D:getcontentlength>18446744073709551615</D:getcontentlength>`},
+		{DAVProp{Name: dav("quota-used-bytes"), Value: int32(-5)}, `<D:quota-used-bytes>-5</D:quota-used-bytes>`},
+		{DAVProp{Name: dav("getcontentlength"), Value: 42}, `<D:getcontentlength>42</D:getcontentlength>`},
+		{DAVProp{Name: xml.Name{Space: "urn:x", Local: "hidden"}, Value: true}, `<hidden xmlns="urn:x">true</hidden>`},
+		{DAVProp{Name: xml.Name{Local: "ratio"}, Value: 0.5}, `<ratio>0.5</ratio>`},
+		{DAVProp{Name: dav("getlastmodified"), Value: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}, `<D:getlastmodified>Wed, 01 May 2024 12:00:00 GMT</D:getlastmodified>`},
+		{DAVProp{Name: dav("resourcetype")}, `<D:resourcetype/>`},
+		{DAVProp{Name: dav("resourcetype"), Value: struct {
+			XMLName xml.Name `xml:"D:collection"`
+		}{}}, `<D:resourcetype><D:collection></D:collection></D:resourcetype>`},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := writeDAVProp(&buf, tt.prop); err != nil {
+			t.Errorf("%v: %v", tt.prop.Value, err)
+			continue
+		}
+		if buf.String() != tt.want {
+			t.Errorf("%T got %s, want %s", tt.prop.Value, buf.String(), tt.want)
+		}
+	}
+
+	if err := writeDAVProp(new(bytes.Buffer), DAVProp{}); err == nil {
+		t.Error("a property without a name succeeded")
+	}
+}
@@ -0,0 +1,74 @@
+package renderall
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"net/http"
+	"strings"
+)
+
+const (
+	// ContentDigest header constant.
+	ContentDigest = "Content-Digest"
+	// ReprDigest header constant.
+	ReprDigest = "Repr-Digest"
+)
+
+// digestAlgorithms are the RFC 9530 algorithms we can compute.
+var digestAlgorithms = map[string]func() hash.Hash{
+	"sha-256": sha256.New,
+	"sha-512": sha512.New,
+}
+
+// Digest returns an RFC 9530 digest field value for body, e.g.
+// `sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:`.
+func Digest(body []byte, algorithms ...string) (string, error) {
+	fields := make([]string, 0, len(algorithms))
+	for _, alg := range algorithms {
+		newHash, ok := digestAlgorithms[strings.ToLower(alg)]
+		if !ok {
+			return "", fmt.Errorf("renderall: unsupported digest algorithm %q", alg)
+		}
+		h := newHash()
+		h.Write(body)
+		fields = append(fields, strings.ToLower(alg)+"=:"+base64.StdEncoding.EncodeToString(h.Sum(nil))+":")
+	}
+	return strings.Join(fields, ", "), nil
+}
+
+// setDigests adds a Content-Digest header for the body as sent and a
+// Repr-Digest header for the whole representation, repr. They only differ
+// for partial responses; Repr-Digest is left out if repr is nil.
+func (r *Render) setDigests(h http.Header, content, repr []byte) error {
+	if len(r.opt.DigestAlgorithms) == 0 {
+		return nil
+	}
+	d, err := Digest(content, r.opt.DigestAlgorithms...)
+	if err != nil {
+		return err
+	}
+	h.Set(ContentDigest, d)
+	if repr == nil {
+		return nil
+	}
+	if d, err = Digest(repr, r.opt.DigestAlgorithms...); err != nil {
+		return err
+	}
+	h.Set(ReprDigest, d)
+	return nil
+}
+
+// representation returns the whole selected representation of a response
+// whose captured body is content: content itself, except for partial
+// responses, which are only ever sent for Data, whose bytes are the
+// representation. It is nil if that is unknown.
+func representation(status int, content []byte, data interface{}) []byte {
+	if status != http.StatusPartialContent {
+		return content
+	}
+	b, _ := data.([]byte)
+	return b
+}
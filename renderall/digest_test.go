@@ -0,0 +1,69 @@
+package renderall
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDigest(t *testing.T) {
+	body := []byte(`{"hello": "world"}`)
+	tests := []struct {
+		algs []string
+		want string
+	}{
+		{[]string{"sha-256"}, "sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:"},
+		{[]string{"SHA-512"}, "sha-512=:WZDPaVn/7XgHaAy8pmojAkGWoRx2UFChF41A2svX+TaPm+AbwAgBWnrIiYllu7BNNyealdVLvRwEmTHWXvJwew==:"},
+		{[]string{"sha-256", "sha-512"}, "sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:, sha-512=:WZDPaVn/7XgHaAy8pmojAkGWoRx2UFChF41A2svX+TaPm+AbwAgBWnrIiYllu7BNNyealdVLvRwEmTHWXvJwew==:"},
+	}
+	for _, tt := range tests {
+		got, err := Digest(body, tt.algs...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("Digest(%v) = %s, want %s", tt.algs, got, tt.want)
+		}
+	}
+	if _, err := Digest(body, "md5"); err == nil {
+		t.Error("Digest accepted md5")
+	}
+}
+
+func TestDigestHeaders(t *testing.T) {
+	r := New(Options{DataRanges: true, DigestAlgorithms: []string{"sha-256"}})
+	body := []byte("hello world")
+	full, _ := Digest(body, "sha-256")
+	part, _ := Digest(body[:3], "sha-256")
+
+	tests := []struct {
+		name          string
+		rangeHeader   string
+		status        int
+		content, repr string
+	}{
+		{"whole", "", http.StatusOK, full, full},
+		{"partial", "bytes=0-2", http.StatusPartialContent, part, full},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.rangeHeader != "" {
+				req.Header.Set("Range", tt.rangeHeader)
+			}
+			rec := httptest.NewRecorder()
+			if err := r.Data(rec, http.StatusOK, body, DataOptions{Request: req}); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Code != tt.status {
+				t.Fatalf("status %d, want %d", rec.Code, tt.status)
+			}
+			if got := rec.Header().Get(ContentDigest); got != tt.content {
+				t.Errorf("Content-Digest %s, want %s", got, tt.content)
+			}
+			if got := rec.Header().Get(ReprDigest); got != tt.repr {
+				t.Errorf("Repr-Digest %s, want %s", got, tt.repr)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package renderall
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/http"
+	"net/mail"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+)
+
+// EmailOptions is a struct for overriding some rendering Options for specific Email call.
+type EmailOptions struct {
+	// Layout template name. Overrides Options.EmailLayout when not blank.
+	Layout string
+	// Renders without any layout, even if Options.EmailLayout is set.
+	NoLayout bool
+	// Text template name for the plain text part. Defaults to the HTML template name plus ".txt".
+	Text string
+}
+
+// emailWriter collects an email part rendered by an HTML engine.
+type emailWriter struct {
+	bytes.Buffer
+	header http.Header
+}
+
+func (e *emailWriter) Header() http.Header {
+	return e.header
+}
+
+func (e *emailWriter) WriteHeader(int) {}
+
+// Email renders the named template as an email's HTML part, with the same
+// funcs as pages and Options.EmailLayout, and its ".txt" twin from the text
+// set as the plain text part. The text part is blank if there is no such
+// template. Options.EmailCSSInliner, if set, inlines the HTML part's styles.
+func (r *Render) Email(name string, binding interface{}, emailOpt ...EmailOptions) (html, text string, err error) {
+	opt := EmailOptions{}
+	if len(emailOpt) > 0 {
+		opt = emailOpt[0]
+	}
+	htmlOpt := HTMLOptions{Layout: opt.Layout, NoLayout: opt.NoLayout}
+	if htmlOpt.Layout == "" {
+		htmlOpt.Layout = r.opt.EmailLayout
+		htmlOpt.NoLayout = htmlOpt.NoLayout || r.opt.EmailLayout == ""
+	}
+
+	w := &emailWriter{header: http.Header{}}
+	h, binding, _, err := r.prepareHTML(w, http.StatusOK, name, binding, nil, []HTMLOptions{htmlOpt})
+	if err != nil {
+		return "", "", err
+	}
+	// Emails never talk to the live reload server.
+	h.Inject = nil
+	start := time.Now()
+	if err := h.Render(w, binding); err != nil {
+		return "", "", err
+	}
+	r.trackTemplate(h.Name, start)
+	html = w.String()
+	if r.opt.EmailCSSInliner != nil {
+		if html, err = r.opt.EmailCSSInliner(html); err != nil {
+			return "", "", err
+		}
+	}
+
+	textName := opt.Text
+	if textName == "" {
+		textName = name + ".txt"
+	}
+	set, err := r.textSet()
+	if err != nil {
+		return "", "", err
+	}
+	if set.Lookup(textName) != nil {
+		var buf bytes.Buffer
+		if err := set.ExecuteTemplate(&buf, textName, binding); err != nil {
+			return "", "", err
+		}
+		text = buf.String()
+	}
+	return html, text, nil
+}
+
+// EmailMessage renders the named email as a ready to send MIME message with
+// header, e.g. From, To, and Subject, and a multipart/alternative body of
+// the text and HTML parts. Non-ASCII header values are encoded, address
+// headers such as From and To address by address, and values containing
+// line breaks are rejected so they cannot inject headers.
+func (r *Render) EmailMessage(header textproto.MIMEHeader, name string, binding interface{}, emailOpt ...EmailOptions) ([]byte, error) {
+	html, text, err := r.Email(name, binding, emailOpt...)
+	if err != nil {
+		return nil, err
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	parts := []struct{ contentType, content string }{
+		{"text/plain", text},
+		{"text/html", html},
+	}
+	for _, p := range parts {
+		if p.content == "" && p.contentType == "text/plain" {
+			continue
+		}
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {p.contentType + "; charset=UTF-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qw := quotedprintable.NewWriter(pw)
+		if _, err := qw.Write([]byte(p.content)); err != nil {
+			return nil, err
+		}
+		if err := qw.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	keys := make([]string, 0, len(header))
+	for k := range header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if strings.ContainsAny(k, "\r\n:") {
+			return nil, fmt.Errorf("renderall: invalid email header name %q", k)
+		}
+		for _, v := range header[k] {
+			v, err := emailHeaderValue(k, v)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Fprintf(&msg, "%s: %s\r\n", k, v)
+		}
+	}
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", mw.Boundary())
+	body.WriteTo(&msg)
+	return msg.Bytes(), nil
+}
+
+// emailAddressHeaders hold address lists, whose display names are encoded
+// one by one.
+var emailAddressHeaders = map[string]bool{
+	"From": true, "Sender": true, "Reply-To": true, "To": true, "Cc": true, "Bcc": true,
+}
+
+// emailHeaderValue encodes v as the value of header k.
+func emailHeaderValue(k, v string) (string, error) {
+	if strings.ContainsAny(v, "\r\n") {
+		return "", fmt.Errorf("renderall: email header %s contains a line break", k)
+	}
+	if !emailAddressHeaders[textproto.CanonicalMIMEHeaderKey(k)] {
+		return mime.QEncoding.Encode("UTF-8", v), nil
+	}
+	list, err := mail.ParseAddressList(v)
+	if err != nil {
+		if isASCII(v) {
+			// Leave what net/mail cannot parse, such as groups, as written.
+			return v, nil
+		}
+		return "", fmt.Errorf("renderall: email header %s: %v", k, err)
+	}
+	addrs := make([]string, len(list))
+	for i, a := range list {
+		addrs[i] = a.String()
+	}
+	return strings.Join(addrs, ", "), nil
+}
+
+// isASCII reports whether s is all ASCII.
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,136 @@
+package renderall
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Encoded describes a body an Encoder wrote.
+type Encoded struct {
+	// ContentType is the media type of the body, with any charset.
+	ContentType string
+	// Length is the number of bytes written.
+	Length int64
+}
+
+// Encoder is the transport independent core of an engine: it writes the
+// body to any io.Writer, such as a file, a message queue, or standard
+// output, and reports what it wrote. The built-in engines implement it and
+// their Render methods only add the status and headers on top.
+type Encoder interface {
+	Encode(w io.Writer, v interface{}) (Encoded, error)
+}
+
+// Encode writes e's body for v to w. Engines that are not Encoders are run
+// against a header-only ResponseWriter, and their Content-Type reported.
+func Encode(w io.Writer, e Engine, v interface{}) (Encoded, error) {
+	if enc, ok := e.(Encoder); ok {
+		return enc.Encode(w, v)
+	}
+	ew := &encodeWriter{countWriter: countWriter{Writer: w}, header: http.Header{}}
+	err := e.Render(ew, v)
+	return Encoded{ContentType: ew.header.Get(ContentType), Length: ew.n}, err
+}
+
+// bufferEncoder is implemented by engines that build their whole body in
+// memory, such as templates, so renderEncoded has them build it in its own
+// buffer instead of copying one into another.
+type bufferEncoder interface {
+	encodeBuffer(buf *bytes.Buffer, v interface{}) error
+}
+
+// encodeBuffered is Encode for a bufferEncoder.
+func encodeBuffered(w io.Writer, e bufferEncoder, contentType string, v interface{}) (Encoded, error) {
+	out := bufPool.Get()
+	defer bufPool.Put(out)
+	if err := e.encodeBuffer(out, v); err != nil {
+		return Encoded{}, err
+	}
+	n, err := out.WriteTo(w)
+	return Encoded{ContentType: contentType, Length: n}, err
+}
+
+// renderEncoded is the HTTP layer over an Encoder: it encodes into a pooled
+// buffer so errors leave the response untouched, then writes head and body.
+func renderEncoded(w http.ResponseWriter, h Head, e Encoder, v interface{}) error {
+	out := bufPool.Get()
+	defer bufPool.Put(out)
+	var err error
+	if be, ok := e.(bufferEncoder); ok {
+		err = be.encodeBuffer(out, v)
+	} else {
+		_, err = e.Encode(out, v)
+	}
+	if err != nil {
+		return err
+	}
+
+	h.Write(w)
+	out.WriteTo(w)
+	return nil
+}
+
+// countWriter counts the bytes written through it.
+type countWriter struct {
+	io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(b []byte) (int, error) {
+	n, err := c.Writer.Write(b)
+	c.n += int64(n)
+	return n, err
+}
+
+// encodeWriter runs an Engine outside of an HTTP response, keeping its
+// headers and discarding its status.
+type encodeWriter struct {
+	countWriter
+	header http.Header
+}
+
+func (e *encodeWriter) Header() http.Header {
+	return e.header
+}
+
+func (e *encodeWriter) WriteHeader(int) {}
+
+// EncodeJSON writes v as a JSON document to w with the same Options as a
+// JSON response, including the envelope and schema validation.
+func (r *Render) EncodeJSON(w io.Writer, v interface{}, jsonOpt ...JSONOptions) (Encoded, error) {
+	opt := r.prepareJSONOptions(jsonOpt)
+	if err := r.validateSchema(opt.Request, v); err != nil {
+		return Encoded{}, err
+	}
+	if opt.Envelope {
+		v = r.envelope(v, opt)
+	}
+	return r.jsonEngine(http.StatusOK, opt.Request).Encode(w, v)
+}
+
+// EncodeXML writes v as an XML document to w with the same Options as an
+// XML response.
+func (r *Render) EncodeXML(w io.Writer, v interface{}, xmlOpt ...XMLOptions) (Encoded, error) {
+	opt := XMLOptions{}
+	if len(xmlOpt) > 0 {
+		opt = xmlOpt[0]
+	}
+	return r.xmlEngine(http.StatusOK, opt).Encode(w, v)
+}
+
+// EncodeHTML executes the named template to w with the same layout, funcs,
+// and globals as an HTML response. Funcs that set response headers, such as
+// preload hints, have no effect.
+func (r *Render) EncodeHTML(w io.Writer, name string, binding interface{}, htmlOpt ...HTMLOptions) (Encoded, error) {
+	ew := &encodeWriter{countWriter: countWriter{Writer: io.Discard}, header: http.Header{}}
+	h, binding, _, err := r.prepareHTML(ew, http.StatusOK, name, binding, nil, htmlOpt)
+	if err != nil {
+		return Encoded{}, err
+	}
+	// Only pages served to a browser talk to the live reload server.
+	h.Inject = nil
+	defer r.trackTemplate(h.Name, time.Now())
+	return h.Encode(w, binding)
+}
@@ -0,0 +1,66 @@
+package renderall
+
+import (
+	"bytes"
+	"encoding/xml"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	texttemplate "text/template"
+)
+
+func TestRenderMatchesEncode(t *testing.T) {
+	head := Head{ContentType: "test/type", Status: http.StatusAccepted}
+	html := template.Must(template.New("page").Parse(`<body>{{ . }}</body>`))
+	text := texttemplate.Must(texttemplate.New("text").Parse(`hello {{ . }}  `))
+	engines := []struct {
+		name   string
+		engine interface {
+			Engine
+			Encoder
+		}
+		v    interface{}
+		want string
+	}{
+		{"html", HTML{Head: head, Name: "page", Templates: html, Inject: []byte("<i>")}, "x", "<body>x<i></body>"},
+		{"text", TextTemplate{Head: head, Name: "text", Templates: text, Minify: bytes.TrimSpace}, "x", "hello x"},
+		{"soap", SOAP{Head: head, Version: SOAP11}, struct {
+			XMLName xml.Name `xml:"Ok"`
+		}{}, `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><Ok></Ok></soap:Body></soap:Envelope>`},
+		{"dav", DAV{Head: head}, DAVMultistatus{Responses: []DAVResponse{{Href: []string{"/a"}, Status: 404}}},
+			`<D:multistatus xmlns:D="DAV:"><D:response><D:href>/a</D:href><D:status>HTTP/1.1 404 Not Found</D:status></D:response></D:multistatus>`},
+		{"yaml", YAML{Head: head}, map[string]int{"a": 1}, "a: 1\n"},
+		{"msgpack", MsgPack{Head: head}, true, "\xc3"},
+	}
+	for _, tt := range engines {
+		var buf bytes.Buffer
+		enc, err := tt.engine.Encode(&buf, tt.v)
+		if err != nil {
+			t.Errorf("%s: Encode: %v", tt.name, err)
+			continue
+		}
+		if buf.String() != tt.want || enc.Length != int64(buf.Len()) || enc.ContentType != head.ContentType {
+			t.Errorf("%s: Encode wrote %q as %+v, want %q", tt.name, buf.String(), enc, tt.want)
+		}
+
+		rec := httptest.NewRecorder()
+		if err := tt.engine.Render(rec, tt.v); err != nil {
+			t.Errorf("%s: Render: %v", tt.name, err)
+			continue
+		}
+		if rec.Code != head.Status || rec.Body.String() != tt.want || rec.Header().Get(ContentType) != head.ContentType {
+			t.Errorf("%s: Render sent %d %q %q", tt.name, rec.Code, rec.Header().Get(ContentType), rec.Body.String())
+		}
+	}
+
+	// A failed encode leaves the response untouched.
+	rec := httptest.NewRecorder()
+	err := DAV{Head: head}.Render(rec, "not a multistatus")
+	if err == nil || rec.Body.Len() != 0 || len(rec.Header()) != 0 {
+		t.Errorf("failed Render: err %v, header %v, body %q", err, rec.Header(), rec.Body.String())
+	}
+	if _, err := Encode(new(bytes.Buffer), HTML{Name: "missing", Templates: html}, nil); err == nil {
+		t.Error("Encode of a missing template succeeded")
+	}
+}
@@ -0,0 +1,215 @@
+package renderall
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	// ContentJOSE header value for JWE compact serialization.
+	ContentJOSE = "application/jose"
+	// EncryptionKeyHeader names the key an Encrypted response is for.
+	EncryptionKeyHeader = "Encryption-Key-Id"
+)
+
+// EncryptionKey is a client's public key for Encrypted responses.
+type EncryptionKey struct {
+	// ID is sent as the JWE kid and the Encryption-Key-Id header.
+	ID string
+	// Public is an *rsa.PublicKey, encrypted to with RSA-OAEP-256, or an
+	// *ecdh.PublicKey on P-256, P-384, P-521, or X25519, agreed with by
+	// ECDH-ES.
+	Public crypto.PublicKey
+}
+
+// Encrypted wraps an Engine, encrypting its body for Key as a JWE (RFC 7516)
+// in compact serialization with A256GCM, for integrations that require
+// application-layer encryption:
+//
+//	e := renderall.Encrypted{Engine: renderall.JSON{Head: head}, Key: clientKey}
+//	r.Render(w, e, record)
+//
+// The inner content type is kept in the JWE cty parameter; the response is
+// sent as application/jose with the key's ID in Encryption-Key-Id.
+type Encrypted struct {
+	Engine Engine
+	Key    EncryptionKey
+}
+
+// Render an encrypted response.
+func (e Encrypted) Render(w http.ResponseWriter, v interface{}) error {
+	c := newCaptureWriter(w)
+	defer c.release()
+	if err := e.Engine.Render(c, v); err != nil {
+		return err
+	}
+	if c.err != nil {
+		return c.err
+	}
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+
+	jwe, err := sealJWE(e.Key, c.buf.Bytes(), w.Header().Get(ContentType))
+	if err != nil {
+		return err
+	}
+	w.Header().Set(ContentType, ContentJOSE)
+	w.Header().Del(ContentLength)
+	if e.Key.ID != "" {
+		w.Header().Set(EncryptionKeyHeader, e.Key.ID)
+	}
+	w.WriteHeader(c.status)
+	w.Write(jwe)
+	return nil
+}
+
+// Encode writes the encrypted body to w.
+func (e Encrypted) Encode(w io.Writer, v interface{}) (Encoded, error) {
+	var buf bytes.Buffer
+	inner, err := Encode(&buf, e.Engine, v)
+	if err != nil {
+		return Encoded{}, err
+	}
+	jwe, err := sealJWE(e.Key, buf.Bytes(), inner.ContentType)
+	if err != nil {
+		return Encoded{}, err
+	}
+	n, err := w.Write(jwe)
+	return Encoded{ContentType: ContentJOSE, Length: int64(n)}, err
+}
+
+// jweHeader is the JWE protected header.
+type jweHeader struct {
+	Alg string      `json:"alg"`
+	Enc string      `json:"enc"`
+	Kid string      `json:"kid,omitempty"`
+	Cty string      `json:"cty,omitempty"`
+	Epk interface{} `json:"epk,omitempty"`
+}
+
+// sealJWE encrypts plaintext for key with A256GCM.
+func sealJWE(key EncryptionKey, plaintext []byte, cty string) ([]byte, error) {
+	h := jweHeader{Enc: "A256GCM", Kid: key.ID, Cty: cty}
+	var cek, encryptedKey []byte
+	switch pub := key.Public.(type) {
+	case *rsa.PublicKey:
+		h.Alg = "RSA-OAEP-256"
+		cek = make([]byte, 32)
+		if _, err := rand.Read(cek); err != nil {
+			return nil, err
+		}
+		var err error
+		if encryptedKey, err = rsa.EncryptOAEP(sha256.New(), rand.Reader, pub, cek, nil); err != nil {
+			return nil, err
+		}
+	case *ecdh.PublicKey:
+		h.Alg = "ECDH-ES"
+		priv, err := pub.Curve().GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		z, err := priv.ECDH(pub)
+		if err != nil {
+			return nil, err
+		}
+		if h.Epk, err = ecdhJWK(priv.PublicKey()); err != nil {
+			return nil, err
+		}
+		cek = concatKDF(z, h.Enc, 256)
+	default:
+		return nil, fmt.Errorf("renderall: unsupported encryption key type %T", key.Public)
+	}
+
+	hb, err := json.Marshal(h)
+	if err != nil {
+		return nil, err
+	}
+	enc := base64.RawURLEncoding
+	protected := enc.EncodeToString(hb)
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	iv := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+	sealed := gcm.Seal(nil, iv, plaintext, []byte(protected))
+	ciphertext, tag := sealed[:len(sealed)-gcm.Overhead()], sealed[len(sealed)-gcm.Overhead():]
+
+	parts := []string{
+		protected,
+		enc.EncodeToString(encryptedKey),
+		enc.EncodeToString(iv),
+		enc.EncodeToString(ciphertext),
+		enc.EncodeToString(tag),
+	}
+	return []byte(strings.Join(parts, ".")), nil
+}
+
+// ecdhJWK is the JWK of an ephemeral ECDH public key.
+func ecdhJWK(pub *ecdh.PublicKey) (map[string]string, error) {
+	enc := base64.RawURLEncoding
+	b := pub.Bytes()
+	var crv string
+	switch pub.Curve() {
+	case ecdh.X25519():
+		return map[string]string{"kty": "OKP", "crv": "X25519", "x": enc.EncodeToString(b)}, nil
+	case ecdh.P256():
+		crv = "P-256"
+	case ecdh.P384():
+		crv = "P-384"
+	case ecdh.P521():
+		crv = "P-521"
+	default:
+		return nil, fmt.Errorf("renderall: unsupported ECDH curve %v", pub.Curve())
+	}
+	// NIST keys are the uncompressed point: 0x04, then x and y.
+	size := (len(b) - 1) / 2
+	return map[string]string{
+		"kty": "EC",
+		"crv": crv,
+		"x":   enc.EncodeToString(b[1 : 1+size]),
+		"y":   enc.EncodeToString(b[1+size:]),
+	}, nil
+}
+
+// concatKDF derives the ECDH-ES content encryption key from the shared
+// secret z, per RFC 7518 section 4.6.2, with empty party info.
+func concatKDF(z []byte, alg string, bits int) []byte {
+	var other bytes.Buffer
+	binary.Write(&other, binary.BigEndian, uint32(len(alg)))
+	other.WriteString(alg)
+	binary.Write(&other, binary.BigEndian, uint32(0)) // PartyUInfo
+	binary.Write(&other, binary.BigEndian, uint32(0)) // PartyVInfo
+	binary.Write(&other, binary.BigEndian, uint32(bits))
+
+	var out []byte
+	for counter := uint32(1); len(out) < bits/8; counter++ {
+		h := sha256.New()
+		binary.Write(h, binary.BigEndian, counter)
+		h.Write(z)
+		h.Write(other.Bytes())
+		out = h.Sum(out)
+	}
+	return out[:bits/8]
+}
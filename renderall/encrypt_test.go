@@ -0,0 +1,153 @@
+package renderall
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// openJWE decrypts a compact JWE sealed by sealJWE, as a client would.
+func openJWE(t *testing.T, jwe string, priv interface{}) (jweHeader, []byte) {
+	t.Helper()
+	parts := strings.Split(jwe, ".")
+	if len(parts) != 5 {
+		t.Fatalf("JWE has %d parts, want 5", len(parts))
+	}
+	enc := base64.RawURLEncoding
+	var raw [5][]byte
+	for i, p := range parts {
+		var err error
+		if raw[i], err = enc.DecodeString(p); err != nil {
+			t.Fatalf("JWE part %d: %v", i, err)
+		}
+	}
+	var h struct {
+		jweHeader
+		Epk map[string]string `json:"epk"`
+	}
+	if err := json.Unmarshal(raw[0], &h); err != nil {
+		t.Fatal(err)
+	}
+	if h.Enc != "A256GCM" {
+		t.Fatalf("enc %q, want A256GCM", h.Enc)
+	}
+
+	var cek []byte
+	switch k := priv.(type) {
+	case *rsa.PrivateKey:
+		if h.Alg != "RSA-OAEP-256" {
+			t.Fatalf("alg %q, want RSA-OAEP-256", h.Alg)
+		}
+		var err error
+		if cek, err = rsa.DecryptOAEP(sha256.New(), nil, k, raw[1], nil); err != nil {
+			t.Fatal(err)
+		}
+	case *ecdh.PrivateKey:
+		if h.Alg != "ECDH-ES" || len(raw[1]) != 0 {
+			t.Fatalf("alg %q with %d byte key, want ECDH-ES with none", h.Alg, len(raw[1]))
+		}
+		point, err := enc.DecodeString(h.Epk["x"])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if h.Epk["kty"] == "EC" {
+			// NIST keys are the uncompressed point: 0x04, then x and y.
+			y, err := enc.DecodeString(h.Epk["y"])
+			if err != nil {
+				t.Fatal(err)
+			}
+			point = append(append([]byte{4}, point...), y...)
+		}
+		epk, err := k.Curve().NewPublicKey(point)
+		if err != nil {
+			t.Fatal(err)
+		}
+		z, err := k.ECDH(epk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cek = concatKDF(z, h.Enc, 256)
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := gcm.Open(nil, raw[2], append(raw[3], raw[4]...), []byte(parts[0]))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	return h.jweHeader, plaintext
+}
+
+func TestEncrypted(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	xKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pKey, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		pub  interface{}
+		priv interface{}
+	}{
+		{"RSA-OAEP-256", &rsaKey.PublicKey, rsaKey},
+		{"ECDH-ES X25519", xKey.PublicKey(), xKey},
+		{"ECDH-ES P-256", pKey.PublicKey(), pKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRender(Options{})
+			e := Encrypted{
+				Engine: r.jsonEngine(201, nil),
+				Key:    EncryptionKey{ID: "client-1", Public: tt.pub},
+			}
+			rec := httptest.NewRecorder()
+			if err := r.Render(rec, e, map[string]int{"n": 1}); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Code != 201 {
+				t.Errorf("status %d, want 201", rec.Code)
+			}
+			if got := rec.Header().Get(ContentType); got != ContentJOSE {
+				t.Errorf("Content-Type %q, want %q", got, ContentJOSE)
+			}
+			if got := rec.Header().Get(EncryptionKeyHeader); got != "client-1" {
+				t.Errorf("%s %q, want client-1", EncryptionKeyHeader, got)
+			}
+
+			h, plaintext := openJWE(t, rec.Body.String(), tt.priv)
+			if h.Kid != "client-1" || !strings.HasPrefix(h.Cty, ContentJSON) {
+				t.Errorf("header kid %q cty %q", h.Kid, h.Cty)
+			}
+			if string(plaintext) != `{"n":1}` {
+				t.Errorf("plaintext %s", plaintext)
+			}
+		})
+	}
+
+	e := Encrypted{Engine: JSON{}, Key: EncryptionKey{Public: "not a key"}}
+	if _, err := Encode(new(strings.Builder), e, 1); err == nil {
+		t.Error("Encrypted with an unsupported key succeeded")
+	}
+}
@@ -0,0 +1,108 @@
+package renderall
+
+import (
+	"context"
+	"net/http"
+)
+
+// EngineV2 is the request and context aware interface for responses. req may
+// be nil when rendering outside of an HTTP handler.
+type EngineV2 interface {
+	RenderContext(ctx context.Context, w http.ResponseWriter, req *http.Request, v interface{}) error
+}
+
+// AdaptEngine lifts an Engine to EngineV2. Engines that already implement
+// EngineV2 are returned as is.
+func AdaptEngine(e Engine) EngineV2 {
+	if v2, ok := e.(EngineV2); ok {
+		return v2
+	}
+	return adaptedEngine{e}
+}
+
+type adaptedEngine struct {
+	Engine
+}
+
+func (a adaptedEngine) RenderContext(ctx context.Context, w http.ResponseWriter, req *http.Request, v interface{}) error {
+	return renderContext(ctx, a.Engine, w, v)
+}
+
+// renderContext runs e unless ctx is already done, so abandoned requests
+// skip the marshaling work.
+func renderContext(ctx context.Context, e Engine, w http.ResponseWriter, v interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return e.Render(w, v)
+}
+
+// boundEngine binds an EngineV2 to a context and request so it can run
+// through the Engine pipeline.
+type boundEngine struct {
+	ctx    context.Context
+	req    *http.Request
+	engine EngineV2
+}
+
+func (b boundEngine) Render(w http.ResponseWriter, v interface{}) error {
+	return b.engine.RenderContext(b.ctx, w, b.req, v)
+}
+
+// RenderContext is the EngineV2 counterpart of Render.
+func (r *Render) RenderContext(ctx context.Context, w http.ResponseWriter, req *http.Request, e EngineV2, data interface{}) error {
+	return r.render(w, req, boundEngine{ctx: ctx, req: req, engine: e}, data)
+}
+
+// RenderContext implements EngineV2.
+func (d Data) RenderContext(ctx context.Context, w http.ResponseWriter, req *http.Request, v interface{}) error {
+	return renderContext(ctx, d, w, v)
+}
+
+// RenderContext implements EngineV2.
+func (h HTML) RenderContext(ctx context.Context, w http.ResponseWriter, req *http.Request, v interface{}) error {
+	return renderContext(ctx, h, w, v)
+}
+
+// RenderContext implements EngineV2.
+func (j JSON) RenderContext(ctx context.Context, w http.ResponseWriter, req *http.Request, v interface{}) error {
+	return renderContext(ctx, j, w, v)
+}
+
+// RenderContext implements EngineV2.
+func (j JSONP) RenderContext(ctx context.Context, w http.ResponseWriter, req *http.Request, v interface{}) error {
+	return renderContext(ctx, j, w, v)
+}
+
+// RenderContext implements EngineV2.
+func (x XML) RenderContext(ctx context.Context, w http.ResponseWriter, req *http.Request, v interface{}) error {
+	return renderContext(ctx, x, w, v)
+}
+
+// RenderContext implements EngineV2.
+func (b BrowsableJSON) RenderContext(ctx context.Context, w http.ResponseWriter, req *http.Request, v interface{}) error {
+	return renderContext(ctx, b, w, v)
+}
+
+// unwrapEngine returns the engine e wraps, through any number of the
+// package's wrappers, or e itself.
+func unwrapEngine(e Engine) Engine {
+	for {
+		switch t := e.(type) {
+		case boundEngine:
+			inner, ok := t.engine.(Engine)
+			if !ok {
+				return e
+			}
+			e = inner
+		case adaptedEngine:
+			e = t.Engine
+		case Encrypted:
+			e = t.Engine
+		case registeredEngine:
+			e = t.Engine
+		default:
+			return e
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package renderall
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Envelope is the consistent response shape emitted when envelope mode is enabled.
+type Envelope struct {
+	Data   interface{}            `json:"data"`
+	Meta   map[string]interface{} `json:"meta,omitempty"`
+	Errors []interface{}          `json:"errors,omitempty"`
+}
+
+// MetaFunc populates the meta of an enveloped response. The request may be nil
+// if the caller did not supply one through JSONOptions.
+type MetaFunc func(req *http.Request, meta map[string]interface{})
+
+// Timed is middleware that records when a request started, for use by TimingMeta.
+func Timed(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx := context.WithValue(req.Context(), startKey, time.Now())
+		next.ServeHTTP(w, req.WithContext(ctx))
+	})
+}
+
+// requestStart returns the start time recorded by Timed, if any.
+func requestStart(req *http.Request) (time.Time, bool) {
+	if req == nil {
+		return time.Time{}, false
+	}
+	start, ok := req.Context().Value(startKey).(time.Time)
+	return start, ok
+}
+
+// RequestIDMeta returns a MetaFunc that copies the named request header into meta["request_id"].
+func RequestIDMeta(header string) MetaFunc {
+	return func(req *http.Request, meta map[string]interface{}) {
+		if req == nil {
+			return
+		}
+		if id := req.Header.Get(header); id != "" {
+			meta["request_id"] = id
+		}
+	}
+}
+
+// TimingMeta records the milliseconds elapsed since the request passed through Timed.
+func TimingMeta(req *http.Request, meta map[string]interface{}) {
+	if start, ok := requestStart(req); ok {
+		meta["elapsed_ms"] = float64(time.Since(start)) / float64(time.Millisecond)
+	}
+}
+
+func (r *Render) prepareJSONOptions(jsonOpt []JSONOptions) JSONOptions {
+	opt := JSONOptions{Envelope: r.opt.Envelope}
+	if len(jsonOpt) > 0 {
+		o := jsonOpt[0]
+		opt.Envelope = (opt.Envelope || o.Envelope) && !o.Raw
+		opt.Request = o.Request
+		opt.Meta = o.Meta
+		opt.Errors = o.Errors
+	}
+	return opt
+}
+
+// envelope wraps v in an Envelope, running the MetaFuncs and merging any per-call meta.
+func (r *Render) envelope(v interface{}, opt JSONOptions) Envelope {
+	meta := map[string]interface{}{}
+	for _, f := range r.opt.MetaFuncs {
+		f(opt.Request, meta)
+	}
+	if id := r.RequestID(opt.Request); id != "" {
+		meta["request_id"] = id
+	}
+	for k, val := range opt.Meta {
+		meta[k] = val
+	}
+	if len(meta) == 0 {
+		meta = nil
+	}
+	return Envelope{Data: v, Meta: meta, Errors: opt.Errors}
+}
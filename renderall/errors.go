@@ -0,0 +1,163 @@
+package renderall
+
+import (
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// APIError is the standard error body written by Error and ErrorXML.
+type APIError struct {
+	XMLName xml.Name      `json:"-" xml:"error"`
+	Status  int           `json:"status" xml:"status"`
+	Code    string        `json:"code" xml:"code"`
+	Message string        `json:"message" xml:"message"`
+	Details []interface{} `json:"details,omitempty" xml:"details>detail,omitempty"`
+	// RequestID correlates the error with logs, see Options.RequestIDHeader.
+	RequestID string `json:"request_id,omitempty" xml:"request_id,omitempty"`
+}
+
+// Error implements the error interface.
+func (e APIError) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// ContentProblemJSON header value for RFC 9457 problem details.
+const ContentProblemJSON = "application/problem+json"
+
+// Problem is an RFC 9457 problem details body, written by Problem.
+type Problem struct {
+	Type     string `json:"type,omitempty"`
+	Title    string `json:"title,omitempty"`
+	Status   int    `json:"status,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// RetryAfter is an extension member holding the seconds to wait, for throttled responses.
+	RetryAfter int `json:"retry_after,omitempty"`
+}
+
+// ErrorRegistry maps Go errors to HTTP status codes and error codes.
+type ErrorRegistry struct {
+	mu      sync.RWMutex
+	entries []errorEntry
+}
+
+type errorEntry struct {
+	match  func(error) bool
+	status int
+	code   string
+}
+
+// NewErrorRegistry creates an empty ErrorRegistry.
+func NewErrorRegistry() *ErrorRegistry {
+	return &ErrorRegistry{}
+}
+
+// Register maps errors matching target (per errors.Is) to the given status and code.
+func (reg *ErrorRegistry) Register(target error, status int, code string) {
+	reg.RegisterFunc(func(err error) bool { return errors.Is(err, target) }, status, code)
+}
+
+// RegisterType maps errors whose chain contains a value of the same type as
+// example to the given status and code.
+func (reg *ErrorRegistry) RegisterType(example error, status int, code string) {
+	t := reflect.TypeOf(example)
+	reg.RegisterFunc(func(err error) bool {
+		for ; err != nil; err = errors.Unwrap(err) {
+			if reflect.TypeOf(err) == t {
+				return true
+			}
+		}
+		return false
+	}, status, code)
+}
+
+// RegisterFunc maps errors for which match returns true to the given status and code.
+func (reg *ErrorRegistry) RegisterFunc(match func(error) bool, status int, code string) {
+	reg.mu.Lock()
+	reg.entries = append(reg.entries, errorEntry{match: match, status: status, code: code})
+	reg.mu.Unlock()
+}
+
+// Lookup returns the status and code registered for err. Entries are checked
+// in registration order.
+func (reg *ErrorRegistry) Lookup(err error) (status int, code string, ok bool) {
+	if reg == nil {
+		return 0, "", false
+	}
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	for _, e := range reg.entries {
+		if e.match(err) {
+			return e.status, e.code, true
+		}
+	}
+	return 0, "", false
+}
+
+// newAPIError builds an APIError, defaulting the code to the snake cased status text.
+func newAPIError(status int, code, message string, details []interface{}) APIError {
+	if code == "" {
+		code = strings.ToLower(strings.Replace(http.StatusText(status), " ", "_", -1))
+	}
+	return APIError{Status: status, Code: code, Message: message, Details: details}
+}
+
+// Error writes a standard JSON error body. When envelope mode is enabled the
+// error is placed in the envelope errors instead.
+func (r *Render) Error(w http.ResponseWriter, status int, code, message string, details ...interface{}) error {
+	return r.errorBody(w, nil, newAPIError(status, code, message, details))
+}
+
+// errorBody is Error for a known request, whose ID the body carries.
+func (r *Render) errorBody(w http.ResponseWriter, req *http.Request, e APIError) error {
+	e.RequestID = r.RequestID(req)
+	if r.opt.Envelope {
+		return r.JSON(w, e.Status, nil, JSONOptions{Errors: []interface{}{e}, Request: req})
+	}
+	return r.JSON(w, e.Status, e, JSONOptions{Request: req})
+}
+
+// Problem writes p as application/problem+json, defaulting its status and
+// title from status.
+func (r *Render) Problem(w http.ResponseWriter, status int, p Problem) error {
+	if p.Status == 0 {
+		p.Status = status
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(status)
+	}
+	head := Head{
+		ContentType: r.withCharset(ContentProblemJSON),
+		Status:      status,
+	}
+	j := JSON{
+		Head:         head,
+		Indent:       r.opt.IndentJSON,
+		Prefix:       r.opt.PrefixJSON,
+		UnEscapeHTML: r.opt.UnEscapeHTML,
+	}
+	return r.Render(w, j, p)
+}
+
+// ErrorXML writes a standard XML error body.
+func (r *Render) ErrorXML(w http.ResponseWriter, status int, code, message string, details ...interface{}) error {
+	return r.XML(w, status, newAPIError(status, code, message, details))
+}
+
+// RenderError writes err as a standard JSON error body. An APIError is written
+// as is; other errors are resolved through Options.Errors, and unregistered
+// errors become a generic 500 so internal messages are not leaked.
+func (r *Render) RenderError(w http.ResponseWriter, err error) error {
+	var apiErr APIError
+	if errors.As(err, &apiErr) {
+		return r.Error(w, apiErr.Status, apiErr.Code, apiErr.Message, apiErr.Details...)
+	}
+	if status, code, ok := r.opt.Errors.Lookup(err); ok {
+		return r.Error(w, status, code, err.Error())
+	}
+	return r.Error(w, http.StatusInternalServerError, "", http.StatusText(http.StatusInternalServerError))
+}
@@ -0,0 +1,99 @@
+package renderall
+
+import "net/http"
+
+// extrasWriter holds headers and cookies back until the render writes its
+// status, so they always land after the engine's own headers and the
+// handler can attach them in one place.
+type extrasWriter struct {
+	http.ResponseWriter
+	header      http.Header
+	cookies     []*http.Cookie
+	applied     bool
+	wroteHeader bool
+}
+
+// extras returns w as an extrasWriter, wrapping it only once.
+func extras(w http.ResponseWriter) *extrasWriter {
+	if e, ok := w.(*extrasWriter); ok {
+		return e
+	}
+	return &extrasWriter{ResponseWriter: w, header: http.Header{}}
+}
+
+// WithHeader returns w with key set to value on the rendered response,
+// replacing any value the renderer set:
+//
+//	r.JSON(renderall.WithHeader(w, "X-Request-ID", id), http.StatusOK, v)
+func WithHeader(w http.ResponseWriter, key, value string) http.ResponseWriter {
+	e := extras(w)
+	e.header.Add(key, value)
+	return e
+}
+
+// WithCookie returns w with c set on the rendered response. Invalid cookies
+// are dropped, as with http.SetCookie.
+func WithCookie(w http.ResponseWriter, c *http.Cookie) http.ResponseWriter {
+	e := extras(w)
+	e.cookies = append(e.cookies, c)
+	return e
+}
+
+// apply sets the headers and cookies, once.
+func (e *extrasWriter) apply() {
+	if e.applied {
+		return
+	}
+	e.applied = true
+	h := e.Header()
+	for k, v := range e.header {
+		h[k] = append([]string(nil), v...)
+	}
+	for _, c := range e.cookies {
+		http.SetCookie(e.ResponseWriter, c)
+	}
+}
+
+// applyExtras sets the headers and cookies attached to w, if any, ahead of
+// post-render steps such as signing that need the final header.
+func applyExtras(w http.ResponseWriter) {
+	for {
+		switch v := w.(type) {
+		case *extrasWriter:
+			v.apply()
+			return
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = v.Unwrap()
+		default:
+			return
+		}
+	}
+}
+
+func (e *extrasWriter) WriteHeader(status int) {
+	if !e.wroteHeader {
+		e.wroteHeader = true
+		e.apply()
+	}
+	e.ResponseWriter.WriteHeader(status)
+}
+
+func (e *extrasWriter) Write(b []byte) (int, error) {
+	if !e.wroteHeader {
+		e.WriteHeader(http.StatusOK)
+	}
+	return e.ResponseWriter.Write(b)
+}
+
+// Flush sends any buffered data to the client.
+func (e *extrasWriter) Flush() {
+	if !e.wroteHeader {
+		e.WriteHeader(http.StatusOK)
+	}
+	http.NewResponseController(e.ResponseWriter).Flush()
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (e *extrasWriter) Unwrap() http.ResponseWriter {
+	return e.ResponseWriter
+}
@@ -0,0 +1,427 @@
+package renderall
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"strings"
+)
+
+const (
+	// ContentFHIRJSON header value for HL7 FHIR resources as JSON.
+	ContentFHIRJSON = "application/fhir+json"
+	// ContentFHIRXML header value for HL7 FHIR resources as XML.
+	ContentFHIRXML = "application/fhir+xml"
+)
+
+// fhirNamespace is the namespace of FHIR XML.
+const fhirNamespace = "http://hl7.org/fhir"
+
+// FHIROptions is a struct for overriding some rendering Options for specific FHIR call.
+type FHIROptions struct {
+	// Request being served, whose _format parameter or Accept header picks JSON or XML. Defaults to nil, for JSON.
+	Request *http.Request
+	// FHIR version sent as the content type's fhirVersion parameter, e.g. "4.0". Defaults to blank ("") for none.
+	Version string
+}
+
+// FHIRBundle is a Bundle resource whose entries are streamed as they are
+// produced, for search results too large to hold in memory.
+type FHIRBundle struct {
+	ID string `json:"id,omitempty"`
+	// Type of the bundle, e.g. "searchset" or "history".
+	Type      string `json:"type"`
+	Timestamp string `json:"timestamp,omitempty"`
+	// Total number of matches, for search and history bundles.
+	Total *int       `json:"total,omitempty"`
+	Link  []FHIRLink `json:"link,omitempty"`
+	// Entries yields the entries in order. Rendering stops at the first
+	// error, which leaves the response truncated as the status was sent.
+	Entries iter.Seq2[FHIRBundleEntry, error] `json:"-"`
+}
+
+// FHIRLink is a Bundle link, e.g. the next page of a search.
+type FHIRLink struct {
+	Relation string `json:"relation"`
+	URL      string `json:"url"`
+}
+
+// FHIRBundleEntry is an entry of a FHIRBundle.
+type FHIRBundleEntry struct {
+	FullURL string `json:"fullUrl,omitempty"`
+	// Resource marshals to a FHIR resource's JSON, including resourceType.
+	Resource interface{}       `json:"resource,omitempty"`
+	Search   *FHIRBundleSearch `json:"search,omitempty"`
+}
+
+// FHIRBundleSearch is the search information of a FHIRBundleEntry.
+type FHIRBundleSearch struct {
+	Mode  string   `json:"mode,omitempty"`
+	Score *float64 `json:"score,omitempty"`
+}
+
+// FHIR built-in renderer. Resources are marshaled to their JSON form, and
+// converted from it for XML, so their fields must be in the order the FHIR
+// specification defines them. A FHIRBundle is streamed entry by entry.
+type FHIR struct {
+	Head
+	XML bool
+	// Hook runs on each resource and bundle entry before it is marshaled,
+	// e.g. to redact fields.
+	Hook MarshalHook
+	// Indent and UnEscapeHTML apply to JSON output as they do for JSON.
+	Indent       bool
+	UnEscapeHTML bool
+}
+
+// Render a FHIR response.
+func (f FHIR) Render(w http.ResponseWriter, v interface{}) error {
+	if _, ok := v.(FHIRBundle); !ok {
+		return renderEncoded(w, f.Head, f, v)
+	}
+
+	w.Header().Set(ContentType, f.Head.ContentType)
+	hw := &headWriter{ResponseWriter: w, status: f.Head.Status}
+	if _, err := f.Encode(hw, v); err != nil {
+		return err
+	}
+	hw.writeHeader()
+	return nil
+}
+
+// Encode writes the resource, or streams the bundle, to w.
+func (f FHIR) Encode(w io.Writer, v interface{}) (Encoded, error) {
+	cw := &countWriter{Writer: w}
+	var err error
+	if b, ok := v.(FHIRBundle); ok {
+		err = f.bundle(cw, b)
+	} else {
+		err = f.resource(cw, v)
+	}
+	return Encoded{ContentType: f.Head.ContentType, Length: cw.n}, err
+}
+
+// marshal writes the JSON form of v through the hook, indented at depth
+// levels when Indent is set, without a trailing newline.
+func (f FHIR) marshal(v interface{}, depth int) ([]byte, error) {
+	v, err := prepareJSON(f.Hook, v)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(!f.UnEscapeHTML)
+	if f.Indent && !f.XML {
+		enc.SetIndent(strings.Repeat("  ", depth), "  ")
+	}
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte{'\n'}), nil
+}
+
+// resource writes a single resource.
+func (f FHIR) resource(w io.Writer, v interface{}) error {
+	b, err := f.marshal(v, 0)
+	if err != nil {
+		return err
+	}
+	if !f.XML {
+		if f.Indent {
+			// As JSON does, indented output ends with a newline.
+			b = append(b, '\n')
+		}
+		_, err = w.Write(b)
+		return err
+	}
+
+	o, err := decodeFHIR(b)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := writeFHIRResource(&buf, o, true); err != nil {
+		return err
+	}
+	_, err = buf.WriteTo(w)
+	return err
+}
+
+// bundle writes the bundle's own elements, then each entry as it is yielded.
+func (f FHIR) bundle(w io.Writer, b FHIRBundle) error {
+	head := struct {
+		ResourceType string `json:"resourceType"`
+		FHIRBundle
+	}{"Bundle", b}
+	hb, err := f.marshal(head, 0)
+	if err != nil {
+		return err
+	}
+	// Where the entries go in indented JSON, and what closes them.
+	entryIndent, closing := "", "]}"
+	if f.Indent {
+		entryIndent, closing = "\n    ", "\n  ]\n}\n"
+	}
+
+	var buf bytes.Buffer
+	if f.XML {
+		o, err := decodeFHIR(hb)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(`<Bundle xmlns="` + fhirNamespace + `">`)
+		if err := writeFHIRChildren(&buf, o, true, ""); err != nil {
+			return err
+		}
+	} else {
+		// Reopen the object to append the entries.
+		buf.Write(bytes.TrimRight(hb[:len(hb)-1], "\n"))
+		if f.Indent {
+			buf.WriteString(",\n  \"entry\": [")
+		} else {
+			buf.WriteString(`,"entry":[`)
+		}
+	}
+
+	first := true
+	if b.Entries != nil {
+		for entry, err := range b.Entries {
+			if err != nil {
+				return err
+			}
+			if _, err := buf.WriteTo(w); err != nil {
+				return err
+			}
+			eb, err := f.marshal(entry, 2)
+			if err != nil {
+				return err
+			}
+			if f.XML {
+				o, err := decodeFHIR(eb)
+				if err != nil {
+					return err
+				}
+				err = writeFHIRElement(&buf, "entry", o, nil)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			if !first {
+				buf.WriteByte(',')
+			}
+			first = false
+			buf.WriteString(entryIndent)
+			buf.Write(eb)
+		}
+	}
+
+	switch {
+	case f.XML:
+		buf.WriteString("</Bundle>")
+	case first && f.Indent:
+		buf.WriteString("]\n}\n")
+	default:
+		buf.WriteString(closing)
+	}
+	_, err = buf.WriteTo(w)
+	return err
+}
+
+// decodeFHIR decodes the JSON form of a resource or element.
+func decodeFHIR(b []byte) (*jsonObject, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	v, err := decodeOrdered(dec)
+	if err != nil {
+		return nil, err
+	}
+	o, ok := v.(*jsonObject)
+	if !ok {
+		return nil, fmt.Errorf("renderall: FHIR resource is not a JSON object")
+	}
+	return o, nil
+}
+
+// writeFHIRResource writes o, which names its resourceType, as XML.
+func writeFHIRResource(buf *bytes.Buffer, o *jsonObject, root bool) error {
+	typ, _ := o.vals["resourceType"].(string)
+	if typ == "" {
+		return fmt.Errorf("renderall: FHIR resource has no resourceType")
+	}
+	buf.WriteString("<" + typ)
+	if root {
+		buf.WriteString(` xmlns="` + fhirNamespace + `"`)
+	}
+	buf.WriteByte('>')
+	if err := writeFHIRChildren(buf, o, true, typ); err != nil {
+		return err
+	}
+	buf.WriteString("</" + typ + ">")
+	return nil
+}
+
+// writeFHIRChildren writes the members of o as elements, except those XML
+// carries as attributes: the id of elements and the url of extensions.
+// Primitive extensions in _name members are merged into their element.
+func writeFHIRChildren(buf *bytes.Buffer, o *jsonObject, resource bool, name string) error {
+	for _, k := range o.keys {
+		if k == "resourceType" || (!resource && fhirAttribute(name, k)) {
+			continue
+		}
+		if strings.HasPrefix(k, "_") {
+			// Extensions of absent primitives are written on their own.
+			if _, ok := o.vals[k[1:]]; !ok {
+				if err := writeFHIRElement(buf, k[1:], nil, o.vals[k]); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if err := writeFHIRElement(buf, k, o.vals[k], o.vals["_"+k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fhirAttribute reports whether the member key of element name is an XML
+// attribute.
+func fhirAttribute(name, key string) bool {
+	return key == "id" || (key == "url" && (name == "extension" || name == "modifierExtension"))
+}
+
+// writeFHIRAttributes writes the attributes of element name held in o.
+func writeFHIRAttributes(buf *bytes.Buffer, o *jsonObject, name string) {
+	if o == nil {
+		return
+	}
+	for _, k := range o.keys {
+		if s, ok := o.vals[k].(string); ok && fhirAttribute(name, k) {
+			buf.WriteString(" " + k + `="`)
+			xml.EscapeText(buf, []byte(s))
+			buf.WriteByte('"')
+		}
+	}
+}
+
+// writeFHIRElement writes the member name with value v and, for
+// primitives, the extension object ext.
+func writeFHIRElement(buf *bytes.Buffer, name string, v, ext interface{}) error {
+	switch t := v.(type) {
+	case []interface{}:
+		exts, _ := ext.([]interface{})
+		for i, item := range t {
+			var e interface{}
+			if i < len(exts) {
+				e = exts[i]
+			}
+			if err := writeFHIRElement(buf, name, item, e); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *jsonObject:
+		if _, ok := t.vals["resourceType"]; ok {
+			buf.WriteString("<" + name + ">")
+			if err := writeFHIRResource(buf, t, false); err != nil {
+				return err
+			}
+			buf.WriteString("</" + name + ">")
+			return nil
+		}
+		buf.WriteString("<" + name)
+		writeFHIRAttributes(buf, t, name)
+		buf.WriteByte('>')
+		if err := writeFHIRChildren(buf, t, false, name); err != nil {
+			return err
+		}
+		buf.WriteString("</" + name + ">")
+		return nil
+	}
+
+	// Narrative is XHTML, embedded as is.
+	if s, ok := v.(string); ok && name == "div" {
+		buf.WriteString(s)
+		return nil
+	}
+
+	e, _ := ext.(*jsonObject)
+	if v == nil && e == nil {
+		return nil
+	}
+	buf.WriteString("<" + name)
+	writeFHIRAttributes(buf, e, name)
+	if v != nil {
+		buf.WriteString(` value="`)
+		xml.EscapeText(buf, []byte(fmt.Sprint(v)))
+		buf.WriteByte('"')
+	}
+	if e == nil || len(e.keys) == 0 || (len(e.keys) == 1 && e.keys[0] == "id") {
+		buf.WriteString("/>")
+		return nil
+	}
+	buf.WriteByte('>')
+	if err := writeFHIRChildren(buf, e, false, name); err != nil {
+		return err
+	}
+	buf.WriteString("</" + name + ">")
+	return nil
+}
+
+// fhirContentType negotiates JSON or XML for req: the _format parameter
+// wins over the Accept header, and JSON is the default.
+func fhirContentType(req *http.Request) string {
+	if req == nil {
+		return ContentFHIRJSON
+	}
+	if format := req.URL.Query().Get("_format"); format != "" {
+		if strings.Contains(format, "xml") {
+			return ContentFHIRXML
+		}
+		return ContentFHIRJSON
+	}
+	switch Negotiate(req.Header.Get("Accept"), ContentFHIRJSON, ContentFHIRXML, ContentJSON, "application/xml", ContentXML) {
+	case ContentFHIRXML, "application/xml", ContentXML:
+		return ContentFHIRXML
+	}
+	return ContentFHIRJSON
+}
+
+// FHIR writes a FHIR resource, or streams a FHIRBundle, as JSON or XML per
+// the request.
+func (r *Render) FHIR(w http.ResponseWriter, status int, v interface{}, fhirOpt ...FHIROptions) error {
+	opt := FHIROptions{}
+	if len(fhirOpt) > 0 {
+		opt = fhirOpt[0]
+	}
+
+	contentType := fhirContentType(opt.Request)
+	isXML := contentType == ContentFHIRXML
+	contentType = r.withCharset(contentType)
+	if opt.Version != "" {
+		contentType += "; fhirVersion=" + opt.Version
+	}
+	if opt.Request != nil {
+		addVary(w.Header(), "Accept")
+	}
+
+	head := Head{
+		ContentType: contentType,
+		Status:      status,
+	}
+
+	j := r.jsonEngine(status, opt.Request)
+	f := FHIR{
+		Head:         head,
+		XML:          isXML,
+		Hook:         j.Hook,
+		Indent:       j.Indent,
+		UnEscapeHTML: j.UnEscapeHTML,
+	}
+	return r.render(w, opt.Request, f, v)
+}
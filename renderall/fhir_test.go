@@ -0,0 +1,81 @@
+package renderall
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type fhirPatient struct {
+	ResourceType string `json:"resourceType"`
+	ID           string `json:"id"`
+	SSN          string `json:"ssn" render:"redact"`
+	Note         string `json:"note"`
+}
+
+func fhirBundle(entries ...FHIRBundleEntry) FHIRBundle {
+	return FHIRBundle{Type: "searchset", Entries: func(yield func(FHIRBundleEntry, error) bool) {
+		for _, e := range entries {
+			if !yield(e, nil) {
+				return
+			}
+		}
+	}}
+}
+
+func TestFHIR(t *testing.T) {
+	patient := fhirPatient{ResourceType: "Patient", ID: "p1", SSN: "078-05-1120", Note: "<b>"}
+	tests := []struct {
+		name   string
+		opt    Options
+		format string
+		v      interface{}
+	}{
+		{"resource", Options{}, "json", patient},
+		{"resource indented", Options{IndentJSON: true, UnEscapeHTML: true}, "json", patient},
+		{"resource xml", Options{}, "xml", patient},
+		{"bundle", Options{}, "json", fhirBundle(FHIRBundleEntry{Resource: patient}, FHIRBundleEntry{Resource: patient})},
+		{"bundle indented", Options{IndentJSON: true}, "json", fhirBundle(FHIRBundleEntry{Resource: patient}, FHIRBundleEntry{Resource: patient})},
+		{"empty bundle indented", Options{IndentJSON: true}, "json", fhirBundle()},
+		{"bundle xml", Options{}, "xml", fhirBundle(FHIRBundleEntry{Resource: patient})},
+	}
+	for _, tt := range tests {
+		r := newTestRender(tt.opt)
+		req := httptest.NewRequest(http.MethodGet, "/Patient?_format="+tt.format, nil)
+		rec := httptest.NewRecorder()
+		if err := r.FHIR(rec, http.StatusOK, tt.v, FHIROptions{Request: req}); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		body := rec.Body.String()
+		if strings.Contains(body, "078-05-1120") {
+			t.Errorf("%s: redacted field leaked: %s", tt.name, body)
+		}
+		if tt.format == "xml" {
+			if !strings.Contains(body, `<ssn value="[REDACTED]"/>`) {
+				t.Errorf("%s: %s", tt.name, body)
+			}
+			continue
+		}
+
+		if !json.Valid(rec.Body.Bytes()) {
+			t.Fatalf("%s: invalid JSON %s", tt.name, body)
+		}
+		if tt.opt.IndentJSON {
+			// Indented output matches what json.Indent makes of it.
+			var want bytes.Buffer
+			json.Indent(&want, bytes.TrimSpace(rec.Body.Bytes()), "", "  ")
+			want.WriteByte('\n')
+			if body != want.String() {
+				t.Errorf("%s: got\n%s\nwant\n%s", tt.name, body, want.String())
+			}
+		} else if strings.HasSuffix(body, "\n") {
+			t.Errorf("%s: compact output ends with a newline", tt.name)
+		}
+		if escaped := strings.Contains(body, `\u003cb\u003e`); strings.Contains(body, "Patient") && escaped == tt.opt.UnEscapeHTML {
+			t.Errorf("%s: HTML escaped %v with UnEscapeHTML %v", tt.name, escaped, tt.opt.UnEscapeHTML)
+		}
+	}
+}
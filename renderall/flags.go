@@ -0,0 +1,39 @@
+package renderall
+
+import (
+	"html/template"
+	"net/http"
+)
+
+// FlagProvider evaluates feature flags for a request, for the flag template
+// func. The request is nil for renders not given one.
+type FlagProvider interface {
+	Enabled(req *http.Request, flag string) bool
+}
+
+// FlagFunc adapts an ordinary function to a FlagProvider.
+type FlagFunc func(req *http.Request, flag string) bool
+
+// Enabled calls f(req, flag).
+func (f FlagFunc) Enabled(req *http.Request, flag string) bool {
+	return f(req, flag)
+}
+
+// flagFuncs expose feature flags to templates. Each flag is evaluated once
+// per render, so a template branching on it twice sees the same answer.
+func (r *Render) flagFuncs(req *http.Request) template.FuncMap {
+	seen := map[string]bool{}
+	return template.FuncMap{
+		"flag": func(name string) bool {
+			if r.opt.FlagProvider == nil {
+				return false
+			}
+			on, ok := seen[name]
+			if !ok {
+				on = r.opt.FlagProvider.Enabled(req, name)
+				seen[name] = on
+			}
+			return on
+		},
+	}
+}
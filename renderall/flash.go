@@ -0,0 +1,197 @@
+package renderall
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Flash levels.
+const (
+	FlashInfo    = "info"
+	FlashSuccess = "success"
+	FlashWarning = "warning"
+	FlashError   = "error"
+)
+
+// Flash is a one-shot message shown on the next HTML render.
+type Flash struct {
+	Level   string `json:"level"`
+	Message string `json:"message"`
+}
+
+// FlashStore persists flashes between requests.
+type FlashStore interface {
+	// Add queues f for the client making req.
+	Add(w http.ResponseWriter, req *http.Request, f Flash) error
+	// Pop returns and clears the client's queued flashes.
+	Pop(w http.ResponseWriter, req *http.Request) ([]Flash, error)
+}
+
+// ErrBadFlash is the error for a flash cookie that fails verification.
+var ErrBadFlash = errors.New("renderall: invalid flash cookie")
+
+// CookieFlashStore is a FlashStore keeping flashes in a cookie, optionally
+// signed with an HMAC so clients cannot forge messages.
+type CookieFlashStore struct {
+	// Cookie name. Default is "_flash".
+	Name string
+	// Cookie path. Default is "/".
+	Path string
+	// Signing secret. Cookies are unsigned if empty.
+	Secret []byte
+	// Marks the cookie Secure.
+	Secure bool
+}
+
+func (s *CookieFlashStore) name() string {
+	if s.Name == "" {
+		return "_flash"
+	}
+	return s.Name
+}
+
+func (s *CookieFlashStore) path() string {
+	if s.Path == "" {
+		return "/"
+	}
+	return s.Path
+}
+
+func (s *CookieFlashStore) mac(payload string) string {
+	m := hmac.New(sha256.New, s.Secret)
+	m.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(m.Sum(nil))
+}
+
+func (s *CookieFlashStore) encode(flashes []Flash) (string, error) {
+	b, err := json.Marshal(flashes)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(b)
+	if len(s.Secret) > 0 {
+		payload += "." + s.mac(payload)
+	}
+	return payload, nil
+}
+
+func (s *CookieFlashStore) decode(value string) ([]Flash, error) {
+	payload := value
+	if len(s.Secret) > 0 {
+		i := strings.LastIndexByte(value, '.')
+		if i < 0 || !hmac.Equal([]byte(value[i+1:]), []byte(s.mac(value[:i]))) {
+			return nil, ErrBadFlash
+		}
+		payload = value[:i]
+	}
+	b, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, ErrBadFlash
+	}
+	var flashes []Flash
+	if err := json.Unmarshal(b, &flashes); err != nil {
+		return nil, ErrBadFlash
+	}
+	return flashes, nil
+}
+
+// pending returns the flashes queued so far: those already set on this
+// response, otherwise those sent with the request.
+func (s *CookieFlashStore) pending(w http.ResponseWriter, req *http.Request) []Flash {
+	for _, c := range (&http.Response{Header: w.Header()}).Cookies() {
+		if c.Name == s.name() {
+			flashes, _ := s.decode(c.Value)
+			return flashes
+		}
+	}
+	if c, err := req.Cookie(s.name()); err == nil {
+		flashes, _ := s.decode(c.Value)
+		return flashes
+	}
+	return nil
+}
+
+// setCookie replaces any flash cookie already set on the response.
+func (s *CookieFlashStore) setCookie(w http.ResponseWriter, c *http.Cookie) {
+	h := w.Header()
+	kept := h["Set-Cookie"][:0]
+	for _, line := range h["Set-Cookie"] {
+		if !strings.HasPrefix(line, s.name()+"=") {
+			kept = append(kept, line)
+		}
+	}
+	if len(kept) == 0 {
+		h.Del("Set-Cookie")
+	} else {
+		h["Set-Cookie"] = kept
+	}
+	http.SetCookie(w, c)
+}
+
+// Add implements FlashStore.
+func (s *CookieFlashStore) Add(w http.ResponseWriter, req *http.Request, f Flash) error {
+	value, err := s.encode(append(s.pending(w, req), f))
+	if err != nil {
+		return err
+	}
+	s.setCookie(w, &http.Cookie{Name: s.name(), Value: value, Path: s.path(), HttpOnly: true, Secure: s.Secure, SameSite: http.SameSiteLaxMode})
+	return nil
+}
+
+// Pop implements FlashStore.
+func (s *CookieFlashStore) Pop(w http.ResponseWriter, req *http.Request) ([]Flash, error) {
+	c, err := req.Cookie(s.name())
+	if err != nil {
+		return nil, nil
+	}
+	s.setCookie(w, &http.Cookie{Name: s.name(), Path: s.path(), MaxAge: -1, HttpOnly: true, Secure: s.Secure})
+
+	// A tampered or stale cookie is dropped rather than failing the page.
+	flashes, err := s.decode(c.Value)
+	if err == ErrBadFlash {
+		return nil, nil
+	}
+	return flashes, err
+}
+
+var defaultFlashStore = &CookieFlashStore{}
+
+func (r *Render) flashStore() FlashStore {
+	if r.opt.FlashStore != nil {
+		return r.opt.FlashStore
+	}
+	return defaultFlashStore
+}
+
+// Flash queues a message for the next HTML render for this client. Call it
+// before writing the response, typically ahead of a redirect.
+func (r *Render) Flash(w http.ResponseWriter, req *http.Request, level, msg string) error {
+	return r.flashStore().Add(w, req, Flash{Level: level, Message: msg})
+}
+
+// flashFuncs expose flashes to templates. They are popped from the store on
+// the first call to flashes in a render, so they show exactly once. Renders
+// without HTMLOptions.Request see no flashes.
+func (r *Render) flashFuncs(w http.ResponseWriter, req *http.Request) template.FuncMap {
+	var once sync.Once
+	var flashes []Flash
+	var err error
+	return template.FuncMap{
+		"flashes": func() ([]Flash, error) {
+			if req == nil {
+				return nil, nil
+			}
+			once.Do(func() {
+				flashes, err = r.flashStore().Pop(w, req)
+			})
+			return flashes, err
+		},
+	}
+}
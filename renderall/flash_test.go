@@ -0,0 +1,54 @@
+package renderall
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFlash(t *testing.T) {
+	store := &CookieFlashStore{Secret: []byte("k")}
+	r := New(Options{
+		Directory:  templateDir(t, map[string]string{"page.tmpl": `{{ range flashes }}[{{ .Level }} {{ .Message }}]{{ end }}`}),
+		FlashStore: store,
+	})
+
+	// Flashes queued in one response are all sent in a single cookie.
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	if err := r.Flash(w, req, FlashSuccess, "saved"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Flash(w, req, FlashWarning, "<b>check</b>"); err != nil {
+		t.Fatal(err)
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || !cookies[0].HttpOnly {
+		t.Fatalf("cookies = %v, want one HttpOnly flash cookie", cookies)
+	}
+
+	page := func(c *http.Cookie) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(c)
+		if err := r.HTML(w, http.StatusOK, "page", nil, HTMLOptions{Request: req}); err != nil {
+			t.Fatal(err)
+		}
+		return w
+	}
+
+	w = page(cookies[0])
+	if got, want := w.Body.String(), "[success saved][warning &lt;b&gt;check&lt;/b&gt;]"; got != want {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+	if c := w.Result().Cookies(); len(c) != 1 || c[0].MaxAge >= 0 {
+		t.Errorf("cookies after showing = %v, want the flash cookie cleared", c)
+	}
+
+	forged := *cookies[0]
+	forged.Value = forged.Value[:len(forged.Value)-2] + "xx"
+	if got := page(&forged).Body.String(); got != "" {
+		t.Errorf("forged cookie showed %q", got)
+	}
+}
@@ -0,0 +1,216 @@
+package renderall
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// FormField describes one input generated from a struct field. Fields are
+// configured with struct tags:
+//
+//	Email string `form:"email" label:"Email address" input:"email" placeholder:"you@example.com" required:"true"`
+//
+// form sets the input name ("-" skips the field), label the label text,
+// input the input type ("-" also skips, "textarea" renders a textarea), and
+// placeholder and required the matching attributes. Types default from the
+// Go type: bool is a checkbox, numbers are number inputs, time.Time is a date.
+type FormField struct {
+	Name        string
+	Label       string
+	Type        string
+	Value       string
+	Checked     bool
+	Placeholder string
+	Required    bool
+	Errors      []string
+	// Class is the error class when the field has errors, otherwise blank.
+	Class string
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// FormFields builds the fields for the struct v. Submitted values, when
+// given, take precedence over the struct's so invalid input that could not
+// be decoded into it is still shown back to the user.
+func FormFields(v interface{}, errs ValidationErrors, submitted map[string][]string, errorClass string) ([]FormField, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, fmt.Errorf("renderall: form of nil %T", v)
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("renderall: form requires a struct, got %T", v)
+	}
+
+	var fields []FormField
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" || sf.Tag.Get("form") == "-" || sf.Tag.Get("input") == "-" {
+			continue
+		}
+		fv := rv.Field(i)
+		if sf.Anonymous || (fv.Kind() == reflect.Struct && sf.Type != timeType) {
+			continue
+		}
+
+		f := FormField{
+			Name:        sf.Tag.Get("form"),
+			Label:       sf.Tag.Get("label"),
+			Type:        sf.Tag.Get("input"),
+			Placeholder: sf.Tag.Get("placeholder"),
+			Required:    sf.Tag.Get("required") == "true",
+		}
+		if f.Name == "" {
+			f.Name = sf.Name
+		}
+		if f.Label == "" {
+			f.Label = humanize(sf.Name)
+		}
+		if f.Type == "" {
+			f.Type = inputType(sf.Type)
+		}
+
+		f.Value = formValue(fv)
+		if f.Type == "checkbox" {
+			f.Checked = fv.Kind() == reflect.Bool && fv.Bool()
+			f.Value = "true"
+		}
+		if values, ok := submitted[f.Name]; ok {
+			if f.Type == "checkbox" {
+				f.Checked = len(values) > 0 && values[0] != "" && values[0] != "false"
+			} else if len(values) > 0 {
+				f.Value = values[0]
+			}
+		}
+
+		f.Errors = errs[f.Name]
+		if len(f.Errors) == 0 {
+			f.Errors = errs[sf.Name]
+		}
+		if len(f.Errors) > 0 {
+			f.Class = errorClass
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+func inputType(t reflect.Type) string {
+	if t == timeType {
+		return "date"
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "checkbox"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	}
+	return "text"
+}
+
+func formValue(v reflect.Value) string {
+	if v.Type() == timeType {
+		t := v.Interface().(time.Time)
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format("2006-01-02")
+	}
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// humanize turns a Go field name like "FirstName" into "First Name".
+func humanize(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, c := range runes {
+		if i > 0 && unicode.IsUpper(c) && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+			b.WriteByte(' ')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+var formFieldTemplate = template.Must(template.New("field").Parse(
+	`<div class="field{{ with .Class }} {{ . }}{{ end }}">` +
+		`{{ if eq .Type "checkbox" }}` +
+		`<label><input type="checkbox" id="{{ .Name }}" name="{{ .Name }}" value="{{ .Value }}"{{ if .Checked }} checked{{ end }}{{ if .Required }} required{{ end }}> {{ .Label }}</label>` +
+		`{{ else }}` +
+		`<label for="{{ .Name }}">{{ .Label }}</label>` +
+		`{{ if eq .Type "textarea" }}` +
+		`<textarea id="{{ .Name }}" name="{{ .Name }}"{{ with .Placeholder }} placeholder="{{ . }}"{{ end }}{{ if .Required }} required{{ end }}>{{ .Value }}</textarea>` +
+		`{{ else }}` +
+		`<input type="{{ .Type }}" id="{{ .Name }}" name="{{ .Name }}" value="{{ .Value }}"{{ with .Placeholder }} placeholder="{{ . }}"{{ end }}{{ if .Required }} required{{ end }}>` +
+		`{{ end }}{{ end }}` +
+		`{{ range .Errors }}<span class="field-message">{{ . }}</span>{{ end }}` +
+		`</div>`))
+
+// HTML renders the field as a labelled input with its error messages.
+func (f FormField) HTML() (template.HTML, error) {
+	var buf bytes.Buffer
+	if err := formFieldTemplate.Execute(&buf, f); err != nil {
+		return "", err
+	}
+	return template.HTML(buf.String()), nil
+}
+
+// formFuncs expose the form helpers, drawing submitted values from req and
+// error messages from errs.
+func (r *Render) formFuncs(req *http.Request, errs ValidationErrors) template.FuncMap {
+	var submitted map[string][]string
+	if req != nil && req.PostForm != nil {
+		submitted = req.PostForm
+	}
+	fields := func(v interface{}) ([]FormField, error) {
+		return FormFields(v, errs, submitted, r.opt.FormErrorClass)
+	}
+
+	return template.FuncMap{
+		"formFields": fields,
+		"form": func(v interface{}) (template.HTML, error) {
+			fs, err := fields(v)
+			if err != nil {
+				return "", err
+			}
+			var out template.HTML
+			for _, f := range fs {
+				h, err := f.HTML()
+				if err != nil {
+					return "", err
+				}
+				out += h
+			}
+			return out, nil
+		},
+		"formField": func(v interface{}, name string) (template.HTML, error) {
+			fs, err := fields(v)
+			if err != nil {
+				return "", err
+			}
+			for _, f := range fs {
+				if f.Name == name {
+					return f.HTML()
+				}
+			}
+			return "", fmt.Errorf("renderall: no form field %q in %T", name, v)
+		},
+	}
+}
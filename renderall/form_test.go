@@ -0,0 +1,63 @@
+package renderall
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type signupForm struct {
+	Email    string    `form:"email" label:"Email address" input:"email" placeholder:"you@example.com" required:"true"`
+	Age      int       `form:"age"`
+	Born     time.Time `form:"born"`
+	Terms    bool      `form:"terms"`
+	Bio      string    `input:"textarea"`
+	Password string    `form:"-"`
+	internal string
+}
+
+func TestFormFields(t *testing.T) {
+	v := &signupForm{Email: "a@b", Born: time.Date(1990, 5, 6, 0, 0, 0, 0, time.UTC), Terms: true}
+	errs := ValidationErrors{"age": {"must be a number"}, "Bio": {"too short"}}
+	submitted := map[string][]string{"age": {"twelve"}, "terms": {""}}
+	fields, err := FormFields(v, errs, submitted, "is-invalid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []FormField{
+		{Name: "email", Label: "Email address", Type: "email", Value: "a@b", Placeholder: "you@example.com", Required: true},
+		{Name: "age", Label: "Age", Type: "number", Value: "twelve", Errors: []string{"must be a number"}, Class: "is-invalid"},
+		{Name: "born", Label: "Born", Type: "date", Value: "1990-05-06"},
+		{Name: "terms", Label: "Terms", Type: "checkbox", Value: "true", Checked: false},
+		{Name: "Bio", Label: "Bio", Type: "textarea", Errors: []string{"too short"}, Class: "is-invalid"},
+	}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("fields =\n%+v\nwant\n%+v", fields, want)
+	}
+
+	h, err := fields[1].HTML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, part := range []string{`<div class="field is-invalid">`, `<input type="number" id="age" name="age" value="twelve">`, `<span class="field-message">must be a number</span>`} {
+		if !strings.Contains(string(h), part) {
+			t.Errorf("HTML lacks %s: %s", part, h)
+		}
+	}
+
+	if _, err := FormFields((*signupForm)(nil), nil, nil, ""); err == nil {
+		t.Error("nil struct accepted")
+	}
+	if _, err := FormFields(1, nil, nil, ""); err == nil {
+		t.Error("non-struct accepted")
+	}
+}
+
+func TestHumanize(t *testing.T) {
+	for name, want := range map[string]string{"FirstName": "First Name", "HTTPServer": "HTTP Server", "ID": "ID", "userID": "user ID"} {
+		if got := humanize(name); got != want {
+			t.Errorf("humanize(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
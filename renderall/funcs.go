@@ -0,0 +1,39 @@
+package renderall
+
+import (
+	"html/template"
+	"net/http"
+)
+
+// builtinFuncs are the template funcs every template is parsed with. User
+// Funcs may override them.
+func (r *Render) builtinFuncs() template.FuncMap {
+	return mergeFuncs(r.assetFuncs(), r.sanitizeFuncs(), r.urlFuncs(), r.inlineFuncs(""), jsonLDFuncs(), statusFuncs())
+}
+
+// requestFuncs builds the template funcs that depend on the response being
+// rendered, setting any headers they require.
+func (r *Render) requestFuncs(w http.ResponseWriter, opt HTMLOptions, nonce string) template.FuncMap {
+	return mergeFuncs(
+		r.cspFuncs(w, nonce),
+		r.inlineFuncs(nonce),
+		r.csrfFuncs(opt.Request),
+		r.flashFuncs(w, opt.Request),
+		r.formFuncs(opt.Request, nil),
+		r.tableFuncs(opt.Request),
+		r.flagFuncs(opt.Request),
+		r.navFuncs(opt.Request),
+		r.requestIDFuncs(opt.Request),
+	)
+}
+
+// mergeFuncs combines maps, later maps taking precedence.
+func mergeFuncs(maps ...template.FuncMap) template.FuncMap {
+	funcs := template.FuncMap{}
+	for _, m := range maps {
+		for k, v := range m {
+			funcs[k] = v
+		}
+	}
+	return funcs
+}
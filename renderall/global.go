@@ -0,0 +1,53 @@
+package renderall
+
+import (
+	"html/template"
+	"net/http"
+)
+
+// globalData returns the Options.GlobalData value for req, or nil.
+func (r *Render) globalData(req *http.Request) interface{} {
+	if r.opt.GlobalData == nil {
+		return nil
+	}
+	return r.opt.GlobalData(req)
+}
+
+// mergeGlobal merges global into an HTML binding. A nil binding becomes the
+// global data, and map bindings gain the keys of a map global they lack.
+// Other bindings are left as is; templates reach the data through the
+// global func either way.
+func mergeGlobal(binding, global interface{}) interface{} {
+	if global == nil {
+		return binding
+	}
+	if binding == nil {
+		return global
+	}
+	b, ok := binding.(map[string]interface{})
+	if !ok {
+		return binding
+	}
+	g, ok := global.(map[string]interface{})
+	if !ok {
+		return binding
+	}
+
+	merged := make(map[string]interface{}, len(b)+len(g))
+	for k, v := range g {
+		merged[k] = v
+	}
+	for k, v := range b {
+		merged[k] = v
+	}
+	return merged
+}
+
+// globalFuncs expose the global data to templates whatever the binding.
+func globalFuncs(global interface{}) template.FuncMap {
+	return template.FuncMap{
+		"global": func() interface{} {
+			return global
+		},
+	}
+}
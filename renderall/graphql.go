@@ -0,0 +1,117 @@
+package renderall
+
+import (
+	"errors"
+	"net/http"
+)
+
+// ContentGraphQLResponse header value for GraphQL-over-HTTP responses.
+const ContentGraphQLResponse = "application/graphql-response+json"
+
+// GraphQLResponse is a GraphQL response body, written by GraphQL.
+type GraphQLResponse struct {
+	// Data is the execution result, sent as null if nil.
+	Data       interface{}
+	Errors     []GraphQLError
+	Extensions map[string]interface{}
+	// RequestError marks a request that was not executed, e.g. one that
+	// failed to parse or validate. Its response has no data entry.
+	RequestError bool
+	// Status of a request error. Defaults to 400 for
+	// application/graphql-response+json and 200 for application/json, which
+	// only uses 4xx for requests that are not well-formed, such as
+	// unparseable bodies: set Status to send one regardless.
+	Status int
+}
+
+// MarshalJSON writes the data, errors, and extensions entries, leaving
+// data out of request errors.
+func (g GraphQLResponse) MarshalJSON() ([]byte, error) {
+	m := newJSONMembers()
+	if !g.RequestError {
+		if err := m.add("data", g.Data); err != nil {
+			return nil, err
+		}
+	}
+	if len(g.Errors) > 0 {
+		if err := m.add("errors", g.Errors); err != nil {
+			return nil, err
+		}
+	}
+	if len(g.Extensions) > 0 {
+		if err := m.add("extensions", g.Extensions); err != nil {
+			return nil, err
+		}
+	}
+	return m.close(), nil
+}
+
+// GraphQLError is an entry of a GraphQL response's errors.
+type GraphQLError struct {
+	Message    string                 `json:"message"`
+	Locations  []GraphQLLocation      `json:"locations,omitempty"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// GraphQLLocation is a position in the GraphQL document an error refers to.
+type GraphQLLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Error implements the error interface.
+func (e GraphQLError) Error() string {
+	return e.Message
+}
+
+// graphQLContentType negotiates the response media type. Clients that send
+// no Accept header, or accept neither type, get what legacy servers send,
+// application/json, and the new type otherwise wins ties.
+func graphQLContentType(req *http.Request) string {
+	if req == nil || req.Header.Get("Accept") == "" {
+		return ContentJSON
+	}
+	if ct := Negotiate(req.Header.Get("Accept"), ContentGraphQLResponse, ContentJSON); ct != "" {
+		return ct
+	}
+	return ContentJSON
+}
+
+// GraphQL writes res per the GraphQL-over-HTTP specification, as
+// application/graphql-response+json if req accepts it and application/json
+// otherwise. Executed requests are sent with 200, even when data is null
+// because of field errors; request errors as described by
+// GraphQLResponse.Status.
+func (r *Render) GraphQL(w http.ResponseWriter, req *http.Request, res GraphQLResponse) error {
+	ct := graphQLContentType(req)
+	status := http.StatusOK
+	if res.RequestError {
+		switch {
+		case res.Status != 0:
+			status = res.Status
+		case ct == ContentGraphQLResponse:
+			status = http.StatusBadRequest
+		}
+	}
+
+	addVary(w.Header(), "Accept")
+	j := r.protocolJSON(status, req)
+	j.Head.ContentType = r.withCharset(ct)
+	return r.render(w, req, j, res)
+}
+
+// GraphQLRequestError writes a request error of errs, e.g. parse or
+// validation failures, with status as for GraphQLResponse.Status. Errors
+// that are not GraphQLErrors contribute their message.
+func (r *Render) GraphQLRequestError(w http.ResponseWriter, req *http.Request, status int, errs ...error) error {
+	res := GraphQLResponse{RequestError: true, Status: status}
+	for _, err := range errs {
+		var gqlErr GraphQLError
+		if !errors.As(err, &gqlErr) {
+			gqlErr = GraphQLError{Message: err.Error()}
+		}
+		res.Errors = append(res.Errors, gqlErr)
+	}
+	return r.GraphQL(w, req, res)
+}
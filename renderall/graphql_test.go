@@ -0,0 +1,32 @@
+package renderall
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGraphQL(t *testing.T) {
+	r := New(Options{PrefixJSON: []byte(")]}',\n")})
+	tests := []struct {
+		accept string
+		res    GraphQLResponse
+		code   int
+		body   string
+	}{
+		{ContentGraphQLResponse, GraphQLResponse{Data: map[string]string{"a": "<b>"}}, 200, `{"data":{"a":"\u003cb\u003e"}}`},
+		{ContentGraphQLResponse, GraphQLResponse{RequestError: true, Errors: []GraphQLError{{Message: "bad"}}}, 400, `{"errors":[{"message":"bad"}]}`},
+		{ContentJSON, GraphQLResponse{RequestError: true, Errors: []GraphQLError{{Message: "bad"}}}, 200, `{"errors":[{"message":"bad"}]}`},
+		{ContentJSON, GraphQLResponse{Extensions: map[string]interface{}{"cost": 1}}, 200, `{"data":null,"extensions":{"cost":1}}`},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/graphql", nil)
+		req.Header.Set("Accept", tt.accept)
+		if err := r.GraphQL(w, req, tt.res); err != nil {
+			t.Fatal(err)
+		}
+		if w.Code != tt.code || w.Body.String() != tt.body {
+			t.Errorf("%s: got %d %s, want %d %s", tt.accept, w.Code, w.Body, tt.code, tt.body)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package renderall
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Health statuses, following the IETF health check response format.
+const (
+	HealthPass = "pass"
+	HealthWarn = "warn"
+	HealthFail = "fail"
+)
+
+// HealthCheck is the result of checking one dependency.
+type HealthCheck struct {
+	Name     string        `json:"name"`
+	Status   string        `json:"status"`
+	Message  string        `json:"message,omitempty"`
+	Duration time.Duration `json:"-"`
+	// Milliseconds is filled in from Duration when rendered.
+	Milliseconds float64 `json:"duration_ms,omitempty"`
+}
+
+// HealthReport is the body Health renders.
+type HealthReport struct {
+	// Status of the service. A blank status is the worst of the checks.
+	Status  string        `json:"status"`
+	Version string        `json:"version,omitempty"`
+	Checks  []HealthCheck `json:"checks,omitempty"`
+}
+
+// HealthOptions is a struct for overriding some rendering Options for specific Health call.
+type HealthOptions struct {
+	// Request being served, used to negotiate the compact text form. Defaults to nil.
+	Request *http.Request
+}
+
+func healthRank(status string) int {
+	switch status {
+	case HealthFail:
+		return 2
+	case HealthWarn:
+		return 1
+	}
+	return 0
+}
+
+// resolve fills in the report status and check durations.
+func (h HealthReport) resolve() HealthReport {
+	checks := make([]HealthCheck, len(h.Checks))
+	status := HealthPass
+	for i, c := range h.Checks {
+		if c.Status == "" {
+			c.Status = HealthPass
+		}
+		if c.Duration > 0 {
+			c.Milliseconds = float64(c.Duration) / float64(time.Millisecond)
+		}
+		if healthRank(c.Status) > healthRank(status) {
+			status = c.Status
+		}
+		checks[i] = c
+	}
+	h.Checks = checks
+	if h.Status == "" {
+		h.Status = status
+	}
+	return h
+}
+
+// text is the compact form of the report: the status on the first line and
+// one line per check.
+func (h HealthReport) text() []byte {
+	var buf bytes.Buffer
+	fmt.Fprintln(&buf, h.Status)
+	for _, c := range h.Checks {
+		if c.Message != "" {
+			fmt.Fprintf(&buf, "%s %s: %s\n", c.Name, c.Status, c.Message)
+		} else {
+			fmt.Fprintf(&buf, "%s %s\n", c.Name, c.Status)
+		}
+	}
+	return buf.Bytes()
+}
+
+// Health renders a health report as JSON, or as compact text when the
+// request prefers text/plain. A zero status is 503 if the report fails and
+// 200 otherwise. The report is never enveloped.
+func (r *Render) Health(w http.ResponseWriter, status int, report HealthReport, healthOpt ...HealthOptions) error {
+	opt := HealthOptions{}
+	if len(healthOpt) > 0 {
+		opt = healthOpt[0]
+	}
+
+	report = report.resolve()
+	if status == 0 {
+		status = http.StatusOK
+		if report.Status == HealthFail {
+			status = http.StatusServiceUnavailable
+		}
+	}
+	w.Header().Set("Cache-Control", "no-store")
+
+	if opt.Request != nil && Negotiate(opt.Request.Header.Get("Accept"), ContentJSON, ContentText) == ContentText {
+		d := Data{
+			Head: Head{
+				ContentType: r.withCharset(ContentText),
+				Status:      status,
+			},
+		}
+		return r.render(w, opt.Request, d, report.text())
+	}
+	return r.JSON(w, status, report, JSONOptions{Raw: true, Request: opt.Request})
+}
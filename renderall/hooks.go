@@ -0,0 +1,60 @@
+package renderall
+
+import "net/http"
+
+// RenderContext is the state handed to PreRender and PostRender hooks.
+type RenderContext struct {
+	// Request being served. Nil unless the render was given one, e.g.
+	// through JSONOptions, HTMLOptions, Auto, or RenderContext.
+	Request *http.Request
+	// Header of the response. Changes are sent with the response.
+	Header http.Header
+	// Engine rendering the response.
+	Engine Engine
+	// Data is the value or binding being rendered. PreRender hooks may replace it.
+	Data interface{}
+	// Status is the rendered status code. Only set for PostRender hooks,
+	// which may change it.
+	Status int
+	// Body is the rendered body. Only set for PostRender hooks, which may
+	// replace it.
+	Body []byte
+	// Nonce is the response's CSP nonce: the one HTML templates were given,
+	// even if generated for the render, else the one stored by Nonces.
+	Nonce string
+
+	// audit records the body for Options.Audit, if the render is audited.
+	audit *auditWriter
+}
+
+func (r *Render) preRender(ctx *RenderContext) error {
+	for _, hook := range r.opt.PreRender {
+		if err := hook(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// postRender runs the PostRender hooks over the captured response and
+// stores their changes back into it.
+func (r *Render) postRender(ctx *RenderContext, c *captureWriter) error {
+	if len(r.opt.PostRender) == 0 {
+		return nil
+	}
+
+	ctx.Status = c.status
+	ctx.Body = c.buf.Bytes()
+	for _, hook := range r.opt.PostRender {
+		if err := hook(ctx); err != nil {
+			return err
+		}
+	}
+
+	c.status = ctx.Status
+	body := ctx.Body
+	c.buf.Reset()
+	c.buf.Write(body)
+	ctx.Body = nil
+	return nil
+}
@@ -0,0 +1,120 @@
+package renderall
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+)
+
+// spillWriter buffers the start of a body so small responses can be sent
+// with a Content-Length, and switches to streaming, which net/http sends
+// chunked, once the body outgrows limit.
+type spillWriter struct {
+	w       http.ResponseWriter
+	head    Head
+	buf     bytes.Buffer
+	limit   int
+	spilled bool
+}
+
+func (s *spillWriter) Write(b []byte) (int, error) {
+	if s.spilled {
+		return s.w.Write(b)
+	}
+	if s.buf.Len()+len(b) <= s.limit {
+		return s.buf.Write(b)
+	}
+
+	s.spilled = true
+	s.head.Write(s.w)
+	if _, err := s.buf.WriteTo(s.w); err != nil {
+		return 0, err
+	}
+	return s.w.Write(b)
+}
+
+// close sends a body that never spilled.
+func (s *spillWriter) close() error {
+	if s.spilled {
+		return nil
+	}
+	s.w.Header().Set(ContentLength, strconv.Itoa(s.buf.Len()))
+	s.head.Write(s.w)
+	_, err := s.buf.WriteTo(s.w)
+	return err
+}
+
+// renderHybridJSON buffers up to StreamThreshold bytes and streams the rest.
+// Top level slices are encoded an element at a time so memory stays bounded
+// by the largest element rather than the whole response. Errors before the
+// threshold is reached leave the response untouched.
+func (j JSON) renderHybridJSON(w http.ResponseWriter, v interface{}) error {
+	s := &spillWriter{w: w, head: j.Head, limit: j.StreamThreshold}
+	if len(j.Prefix) > 0 {
+		s.Write(j.Prefix)
+	}
+
+	var err error
+	rv := reflect.ValueOf(v)
+	if _, ok := v.(json.Marshaler); !ok && rv.IsValid() && !rv.IsZero() &&
+		(rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array) && rv.Type().Elem().Kind() != reflect.Uint8 {
+		err = j.encodeElements(s, rv)
+	} else {
+		err = j.encode(s, v, "")
+		if err == nil && j.Indent {
+			_, err = s.Write([]byte{'\n'})
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return s.close()
+}
+
+// encodeElements writes the JSON array rv one element at a time, formatted
+// byte for byte as the buffered encoder would.
+func (j JSON) encodeElements(s *spillWriter, rv reflect.Value) error {
+	open, sep, end := "[", ",", "]"
+	if j.Indent {
+		open, sep, end = "[\n  ", ",\n  ", "\n]\n"
+		if rv.Len() == 0 {
+			open, end = "[", "]\n"
+		}
+	}
+
+	if _, err := s.Write([]byte(open)); err != nil {
+		return err
+	}
+	for i := 0; i < rv.Len(); i++ {
+		if i > 0 {
+			if _, err := s.Write([]byte(sep)); err != nil {
+				return err
+			}
+		}
+		if err := j.encode(s, rv.Index(i).Interface(), "  "); err != nil {
+			return err
+		}
+	}
+	_, err := s.Write([]byte(end))
+	return err
+}
+
+// encode writes v without the trailing newline json.Encoder adds. prefix is
+// the indent of nested lines when Indent is set.
+func (j JSON) encode(s *spillWriter, v interface{}, prefix string) error {
+	st := getJSONState()
+	defer putJSONState(st)
+	st.enc.SetEscapeHTML(!j.UnEscapeHTML)
+	if j.Indent {
+		st.enc.SetIndent(prefix, "  ")
+	} else {
+		st.enc.SetIndent("", "")
+	}
+	if err := st.enc.Encode(v); err != nil {
+		return err
+	}
+	_, err := s.Write(bytes.TrimSuffix(st.buf.Bytes(), []byte{'\n'}))
+	return err
+}
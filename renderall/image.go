@@ -0,0 +1,145 @@
+package renderall
+
+import (
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// ContentPNG header value for PNG images.
+	ContentPNG = "image/png"
+	// ContentJPEG header value for JPEG images.
+	ContentJPEG = "image/jpeg"
+	// ContentGIF header value for GIF images.
+	ContentGIF = "image/gif"
+)
+
+// ImageOptions is a struct for overriding some rendering Options for specific Image call.
+type ImageOptions struct {
+	// JPEG and plugin quality from 1 to 100. Defaults to 90.
+	Quality int
+	// PNG compression level. Defaults to png.DefaultCompression.
+	Compression png.CompressionLevel
+}
+
+// ImageEncoder writes img to w in one format.
+type ImageEncoder func(w io.Writer, img image.Image, opt ImageOptions) error
+
+type imageFormat struct {
+	contentType string
+	encode      ImageEncoder
+}
+
+// pngBuffers reuses the PNG encoder's scratch buffers between renders.
+type pngBuffers struct {
+	pool sync.Pool
+}
+
+func (p *pngBuffers) Get() *png.EncoderBuffer {
+	b, _ := p.pool.Get().(*png.EncoderBuffer)
+	return b
+}
+
+func (p *pngBuffers) Put(b *png.EncoderBuffer) {
+	p.pool.Put(b)
+}
+
+var pngPool = &pngBuffers{}
+
+var builtinImageFormats = map[string]imageFormat{
+	"png": {ContentPNG, func(w io.Writer, img image.Image, opt ImageOptions) error {
+		enc := png.Encoder{CompressionLevel: opt.Compression, BufferPool: pngPool}
+		return enc.Encode(w, img)
+	}},
+	"jpeg": {ContentJPEG, func(w io.Writer, img image.Image, opt ImageOptions) error {
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: opt.Quality})
+	}},
+	"gif": {ContentGIF, func(w io.Writer, img image.Image, opt ImageOptions) error {
+		return gif.Encode(w, img, nil)
+	}},
+}
+
+// Image built-in renderer. It encodes an image.Image into a pooled buffer
+// and sends it with a Content-Length.
+type Image struct {
+	Head
+	Encode  ImageEncoder
+	Options ImageOptions
+}
+
+// Render an image response.
+func (i Image) Render(w http.ResponseWriter, v interface{}) error {
+	img, ok := v.(image.Image)
+	if !ok {
+		return fmt.Errorf("renderall: image requires an image.Image, got %T", v)
+	}
+
+	buf := bufPool.Get()
+	defer bufPool.Put(buf)
+	if err := i.Encode(buf, img, i.Options); err != nil {
+		return err
+	}
+
+	w.Header().Set(ContentLength, strconv.Itoa(buf.Len()))
+	i.Head.Write(w)
+	buf.WriteTo(w)
+	return nil
+}
+
+// RegisterImageFormat adds an image format, such as WebP from a plugin
+// encoder, or replaces a built-in one. Names are case insensitive.
+func (r *Render) RegisterImageFormat(name, contentType string, enc ImageEncoder) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.imageFormats == nil {
+		r.imageFormats = map[string]imageFormat{}
+	}
+	r.imageFormats[strings.ToLower(name)] = imageFormat{contentType, enc}
+}
+
+// Image encodes img in the named format: "png", "jpeg" (or "jpg"), "gif",
+// or one added with RegisterImageFormat.
+func (r *Render) Image(w http.ResponseWriter, status int, img image.Image, format string, imageOpt ...ImageOptions) error {
+	opt := ImageOptions{}
+	if len(imageOpt) > 0 {
+		opt = imageOpt[0]
+	}
+	if opt.Quality == 0 {
+		opt.Quality = 90
+	}
+
+	name := strings.ToLower(format)
+	if name == "jpg" {
+		name = "jpeg"
+	}
+	r.lock.RLock()
+	f, ok := r.imageFormats[name]
+	r.lock.RUnlock()
+	if !ok {
+		f, ok = builtinImageFormats[name]
+	}
+	if !ok {
+		return r.fail(w, fmt.Errorf("renderall: unknown image format %q", format))
+	}
+
+	head := Head{
+		ContentType: f.contentType,
+		Status:      status,
+	}
+
+	i := Image{
+		Head:    head,
+		Encode:  f.encode,
+		Options: opt,
+	}
+
+	return r.Render(w, i, img)
+}
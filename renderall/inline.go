@@ -0,0 +1,99 @@
+package renderall
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"strings"
+)
+
+// defaultInlineMaxBytes caps inlined assets when Options.InlineMaxBytes is 0.
+const defaultInlineMaxBytes = 16 << 10
+
+// inlineAsset reads the named asset from Options.Assets for inlining,
+// resolving it through the asset manifest. Reads are cached unless in
+// development mode, and assets over the size cap are an error so a large
+// file can't silently bloat every page.
+func (r *Render) inlineAsset(name string) ([]byte, error) {
+	if manifest, err := r.manifest(); err != nil {
+		return nil, err
+	} else if entry, ok := manifest[name]; ok {
+		name = entry.File
+	}
+	if v, ok := r.inlineCache.Load(name); ok {
+		return v.([]byte), nil
+	}
+	if r.opt.Assets == nil {
+		return nil, fmt.Errorf("renderall: cannot inline %q, Options.Assets is nil", name)
+	}
+
+	data, err := fs.ReadFile(r.opt.Assets, name)
+	if err != nil {
+		return nil, err
+	}
+	limit := r.opt.InlineMaxBytes
+	if limit == 0 {
+		limit = defaultInlineMaxBytes
+	}
+	if len(data) > limit {
+		return nil, fmt.Errorf("renderall: cannot inline %q, %d bytes is over the %d byte cap", name, len(data), limit)
+	}
+	if !r.opt.IsDevelopment {
+		r.inlineCache.Store(name, data)
+	}
+	return data, nil
+}
+
+// inlineFuncs are the asset inlining template funcs. Inline blocks carry the
+// CSP nonce, if the render has one.
+func (r *Render) inlineFuncs(nonce string) template.FuncMap {
+	attr := ""
+	if nonce != "" {
+		attr = ` nonce="` + template.HTMLEscapeString(nonce) + `"`
+	}
+	return template.FuncMap{
+		"inlineCSS": func(name string) (template.HTML, error) {
+			data, err := r.inlineAsset(name)
+			if err != nil {
+				return "", err
+			}
+			css := escapeEndTag(string(data), "style")
+			return template.HTML("<style" + attr + ">" + css + "</style>"), nil
+		},
+		"inlineJS": func(name string) (template.HTML, error) {
+			data, err := r.inlineAsset(name)
+			if err != nil {
+				return "", err
+			}
+			js := escapeEndTag(string(data), "script")
+			return template.HTML("<script" + attr + ">" + js + "</script>"), nil
+		},
+		"dataURI": func(name string) (template.URL, error) {
+			data, err := r.inlineAsset(name)
+			if err != nil {
+				return "", err
+			}
+			return template.URL("data:" + r.MIMEType(name, data) + ";base64," + base64.StdEncoding.EncodeToString(data)), nil
+		},
+	}
+}
+
+// escapeEndTag escapes every end tag of the element tag in s, in any case,
+// so inlined content cannot close the element early.
+func escapeEndTag(s, tag string) string {
+	var b strings.Builder
+	last := 0
+	for i := 0; i+2+len(tag) <= len(s); i++ {
+		if s[i] == '<' && s[i+1] == '/' && strings.EqualFold(s[i+2:i+2+len(tag)], tag) {
+			b.WriteString(s[last:i])
+			b.WriteString(`<\/`)
+			last = i + 2
+		}
+	}
+	if last == 0 {
+		return s
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
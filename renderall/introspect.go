@@ -0,0 +1,61 @@
+package renderall
+
+import "sort"
+
+// TemplateNode is a template and the templates it invokes with
+// {{ template }} or {{ block }}. Calls made through yield and partial depend
+// on the page being rendered and are not included.
+type TemplateNode struct {
+	Name  string
+	Calls []*TemplateNode
+}
+
+// TemplateNames returns the sorted names of every template in the default set.
+func (r *Render) TemplateNames() []string {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	var names []string
+	for _, t := range r.templates.Templates() {
+		// The set's root is only a container unless a file shares its name.
+		if t.Tree != nil {
+			names = append(names, t.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// HasTemplate reports whether the default set has a template called name.
+func (r *Render) HasTemplate(name string) bool {
+	t := r.TemplateLookup(name)
+	return t != nil && t.Tree != nil
+}
+
+// TemplateTree returns the execution dependencies of the named template, or
+// nil if there is no such template. A template calling one of its ancestors
+// appears again as a leaf rather than being expanded.
+func (r *Render) TemplateTree(name string) *TemplateNode {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	ancestors := map[string]bool{}
+	var build func(name string) *TemplateNode
+	build = func(name string) *TemplateNode {
+		node := &TemplateNode{Name: name}
+		t := r.templates.Lookup(name)
+		if t == nil || t.Tree == nil || ancestors[name] {
+			return node
+		}
+		ancestors[name] = true
+		for _, call := range templateCalls(t.Tree.Root) {
+			node.Calls = append(node.Calls, build(call))
+		}
+		delete(ancestors, name)
+		return node
+	}
+
+	if t := r.templates.Lookup(name); t == nil || t.Tree == nil {
+		return nil
+	}
+	return build(name)
+}
@@ -0,0 +1,26 @@
+package renderall
+
+import (
+	"encoding/json"
+	"html/template"
+)
+
+// ContentJSONLD header value for JSON-LD data.
+const ContentJSONLD = "application/ld+json"
+
+// JSONLD returns v as an application/ld+json script block. encoding/json
+// escapes <, >, and &, so the data can never close the script element early.
+func JSONLD(v interface{}) (template.HTML, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return template.HTML(`<script type="` + ContentJSONLD + `">` + string(b) + `</script>`), nil
+}
+
+// jsonLDFuncs is the jsonld template func.
+func jsonLDFuncs() template.FuncMap {
+	return template.FuncMap{
+		"jsonld": JSONLD,
+	}
+}
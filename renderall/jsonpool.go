@@ -0,0 +1,38 @@
+package renderall
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+)
+
+// maxPooledJSON is the largest buffer returned to the pool, so one huge
+// response doesn't pin its memory for the life of the process.
+const maxPooledJSON = 1 << 20
+
+// jsonState is an encoder bound to its own scratch buffer, pooled so JSON
+// renders don't allocate either per call.
+type jsonState struct {
+	buf bytes.Buffer
+	enc *json.Encoder
+}
+
+var jsonStatePool = sync.Pool{
+	New: func() interface{} {
+		s := &jsonState{}
+		s.enc = json.NewEncoder(&s.buf)
+		return s
+	},
+}
+
+func getJSONState() *jsonState {
+	return jsonStatePool.Get().(*jsonState)
+}
+
+func putJSONState(s *jsonState) {
+	if s.buf.Cap() > maxPooledJSON {
+		return
+	}
+	s.buf.Reset()
+	jsonStatePool.Put(s)
+}
@@ -0,0 +1,94 @@
+package renderall
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// JSON-RPC 2.0 error codes reserved by the specification.
+const (
+	JSONRPCParseError     = -32700
+	JSONRPCInvalidRequest = -32600
+	JSONRPCMethodNotFound = -32601
+	JSONRPCInvalidParams  = -32602
+	JSONRPCInternalError  = -32603
+)
+
+// JSONRPCError is the error member of a JSON-RPC 2.0 response.
+type JSONRPCError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// Error implements the error interface.
+func (e JSONRPCError) Error() string {
+	return "jsonrpc " + strconv.Itoa(e.Code) + ": " + e.Message
+}
+
+// JSONRPCResponse is a JSON-RPC 2.0 response, written by JSONRPC.
+type JSONRPCResponse struct {
+	// ID echoes the request's id: a string, a number, or a json.RawMessage
+	// of either. Nil is sent as null, for requests whose id could not be
+	// read.
+	ID interface{}
+	// Result of a successful call, sent as null if nil.
+	Result interface{}
+	// Error, if set, is sent instead of Result.
+	Error *JSONRPCError
+}
+
+// JSONRPCResult is the successful response to the call id.
+func JSONRPCResult(id, result interface{}) JSONRPCResponse {
+	return JSONRPCResponse{ID: id, Result: result}
+}
+
+// JSONRPCErrorResponse is the failed response to the call id. A
+// JSONRPCError in err's chain is sent as is; other errors become an
+// internal error so their messages are not leaked.
+func JSONRPCErrorResponse(id interface{}, err error) JSONRPCResponse {
+	var rpcErr JSONRPCError
+	if !errors.As(err, &rpcErr) {
+		rpcErr = JSONRPCError{Code: JSONRPCInternalError, Message: "Internal error"}
+	}
+	return JSONRPCResponse{ID: id, Error: &rpcErr}
+}
+
+// MarshalJSON writes the jsonrpc member and exactly one of result and error.
+func (res JSONRPCResponse) MarshalJSON() ([]byte, error) {
+	name, v := "result", res.Result
+	if res.Error != nil {
+		name, v = "error", res.Error
+	}
+	m := newJSONMembers()
+	if err := m.add("jsonrpc", "2.0"); err != nil {
+		return nil, err
+	}
+	if err := m.add(name, v); err != nil {
+		return nil, err
+	}
+	if err := m.add("id", res.ID); err != nil {
+		return nil, err
+	}
+	return m.close(), nil
+}
+
+// JSONRPC writes a single JSON-RPC 2.0 response. Errors are still sent with
+// 200, as the status describes the transport rather than the call.
+func (r *Render) JSONRPC(w http.ResponseWriter, req *http.Request, res JSONRPCResponse) error {
+	return r.render(w, req, r.protocolJSON(http.StatusOK, req), res)
+}
+
+// JSONRPCBatch writes the responses to a batch request as an array, in any
+// order. Notifications have no response, so a batch of only notifications
+// leaves res empty and is answered with 204 No Content. An empty batch
+// request is itself invalid: answer it with a single JSONRPCInvalidRequest
+// error instead.
+func (r *Render) JSONRPCBatch(w http.ResponseWriter, req *http.Request, res []JSONRPCResponse) error {
+	if len(res) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+	return r.render(w, req, r.protocolJSON(http.StatusOK, req), res)
+}
@@ -0,0 +1,35 @@
+package renderall
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestJSONRPC(t *testing.T) {
+	r := New(Options{PrefixJSON: []byte(")]}',\n")})
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/rpc", nil)
+	err := r.JSONRPCBatch(w, req, []JSONRPCResponse{
+		JSONRPCResult(1, []int{19}),
+		JSONRPCErrorResponse("b", errors.New("db down")),
+		JSONRPCErrorResponse(nil, JSONRPCError{Code: JSONRPCInvalidRequest, Message: "Invalid Request"}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"jsonrpc":"2.0","result":[19],"id":1},` +
+		`{"jsonrpc":"2.0","error":{"code":-32603,"message":"Internal error"},"id":"b"},` +
+		`{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":null}]`
+	if got := w.Body.String(); got != want {
+		t.Errorf("body = %s, want %s", got, want)
+	}
+
+	w = httptest.NewRecorder()
+	if err := r.JSONRPCBatch(w, req, nil); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != 204 || w.Body.Len() != 0 {
+		t.Errorf("notification batch = %d %q, want 204", w.Code, w.Body)
+	}
+}
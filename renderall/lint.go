@@ -0,0 +1,330 @@
+package renderall
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template/parse"
+)
+
+// LintKind classifies a LintIssue.
+type LintKind string
+
+// Kinds of LintIssue.
+const (
+	// LintSyntax is a template that does not parse.
+	LintSyntax LintKind = "syntax"
+	// LintUndefinedFunc is a call to a function no funcmap provides.
+	LintUndefinedFunc LintKind = "undefined-func"
+	// LintMissingTemplate is a {{ template }} call to a template that does not exist.
+	LintMissingTemplate LintKind = "missing-template"
+	// LintMissingPartial is a page without a block its layout requires through RequireBlocks.
+	LintMissingPartial LintKind = "missing-partial"
+	// LintUnusedDefine is a {{ define }} block nothing calls.
+	LintUnusedDefine LintKind = "unused-define"
+)
+
+// LintIssue is a problem Lint found in a template file.
+type LintIssue struct {
+	// Theme is the Options.Themes set the file belongs to, blank for the default set.
+	Theme string
+	// Template is the file, named like the templates it holds, e.g. "users/show".
+	Template string
+	// Line is 1-based, or 0 if the issue concerns the whole file.
+	Line    int
+	Kind    LintKind
+	Message string
+}
+
+func (i LintIssue) String() string {
+	where := i.Template
+	if i.Line > 0 {
+		where += ":" + strconv.Itoa(i.Line)
+	}
+	if i.Theme != "" {
+		where = i.Theme + ": " + where
+	}
+	return fmt.Sprintf("%s: %s (%s)", where, i.Message, i.Kind)
+}
+
+// templateBuiltins are the functions text/template predefines.
+var templateBuiltins = []string{
+	"and", "call", "eq", "ge", "gt", "html", "index", "js", "le", "len", "lt",
+	"ne", "not", "or", "print", "printf", "println", "slice", "urlquery",
+}
+
+// Lint statically checks the template sources of the default set and every
+// theme, without executing them, for calls to undefined functions, calls to
+// missing templates, {{ define }} blocks nothing calls, and, with
+// RequireBlocks, pages lacking a block their layout's partial calls require.
+// Unlike compiling, it keeps going past the first problem. Issues are
+// sorted by theme, file, and line.
+//
+// Pages are told apart from partials by convention: any file that neither
+// calls yield nor is called by another template is taken to be a page. Only
+// partial calls with a literal name are checked.
+//
+// See the package level Lint to check templates that may not compile.
+func (r *Render) Lint() []LintIssue {
+	_, walk := r.walkDefault()
+	issues := r.lintSet("", walk)
+
+	themes := make([]string, 0, len(r.opt.Themes))
+	for name := range r.opt.Themes {
+		themes = append(themes, name)
+	}
+	sort.Strings(themes)
+	for _, name := range themes {
+		issues = append(issues, r.lintSet(name, r.walkSet(r.opt.Themes[name]))...)
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		a, b := issues[i], issues[j]
+		if a.Theme != b.Theme {
+			return a.Theme < b.Theme
+		}
+		if a.Template != b.Template {
+			return a.Template < b.Template
+		}
+		return a.Line < b.Line
+	})
+	return issues
+}
+
+// Lint is like Render.Lint, but lints the templates options would compile
+// without compiling them, so a template that does not parse or calls an
+// undefined function is reported rather than making New panic. Options NewE
+// would reject, such as a missing template directory, are returned as an
+// error.
+// Templates are never watched for changes. It suits a test, so CI fails
+// when templates break:
+//
+//	func TestTemplates(t *testing.T) {
+//		issues, err := renderall.Lint(options)
+//		if err != nil {
+//			t.Fatal(err)
+//		}
+//		for _, issue := range issues {
+//			t.Error(issue)
+//		}
+//	}
+func Lint(options Options) ([]LintIssue, error) {
+	if err := options.validate(); err != nil {
+		return nil, err
+	}
+	options.ReloadTemplates = false
+	r, err := newRender(options, func(*Render) error { return nil })
+	if err != nil {
+		return nil, err
+	}
+	return r.Lint(), nil
+}
+
+// lintSet lints the sources walk passes, as compile would add them to one set.
+func (r *Render) lintSet(theme string, walk func(parseFunc) error) []LintIssue {
+	var issues []LintIssue
+	report := func(tree *parse.Tree, node parse.Node, kind LintKind, format string, args ...interface{}) {
+		issue := LintIssue{Theme: theme, Template: tree.ParseName, Kind: kind, Message: fmt.Sprintf(format, args...)}
+		if node != nil {
+			issue.Template, issue.Line = lintLocation(tree, node)
+		}
+		issues = append(issues, issue)
+	}
+
+	left, right := r.opt.Delims.Left, r.opt.Delims.Right
+	trees := map[string]*parse.Tree{}
+	err := walk(func(name string, buf []byte) error {
+		t := parse.New(name)
+		t.Mode = parse.SkipFuncCheck
+		parsed := map[string]*parse.Tree{}
+		if _, err := t.Parse(string(buf), left, right, parsed); err != nil {
+			issues = append(issues, LintIssue{Theme: theme, Template: name, Kind: LintSyntax, Message: err.Error()})
+			return nil
+		}
+		// Later sources replace earlier ones, unless they are empty.
+		for n, tree := range parsed {
+			if old := trees[n]; old == nil || !parse.IsEmptyTree(tree.Root) {
+				trees[n] = tree
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		issues = append(issues, LintIssue{Theme: theme, Kind: LintSyntax, Message: err.Error()})
+	}
+
+	funcs := map[string]bool{}
+	for _, name := range templateBuiltins {
+		funcs[name] = true
+	}
+	for name := range r.builtinFuncs() {
+		funcs[name] = true
+	}
+	for _, fm := range r.opt.Funcs {
+		for name := range fm {
+			funcs[name] = true
+		}
+	}
+	for name := range helperFuncs {
+		funcs[name] = true
+	}
+
+	names := make([]string, 0, len(trees))
+	for name := range trees {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	called := map[string]bool{}
+	layouts := map[string]bool{}
+	// Literal partial names by the file calling them, in call order.
+	fileParts := map[string][]string{}
+	var partials []string
+	for _, name := range names {
+		tree := trees[name]
+		lintWalk(tree.Root, func(node parse.Node) {
+			switch n := node.(type) {
+			case *parse.IdentifierNode:
+				if n.Ident == "yield" {
+					layouts[tree.ParseName] = true
+				}
+				if !funcs[n.Ident] {
+					report(tree, n, LintUndefinedFunc, "function %q not defined", n.Ident)
+				}
+			case *parse.TemplateNode:
+				called[n.Name] = true
+				if _, builtin := builtinTemplates[n.Name]; trees[n.Name] == nil && !builtin {
+					report(tree, n, LintMissingTemplate, "template %q not defined", n.Name)
+				}
+			case *parse.CommandNode:
+				if name, ok := partialCall(n); ok {
+					fileParts[tree.ParseName] = append(fileParts[tree.ParseName], name)
+					partials = append(partials, name)
+				}
+			}
+		})
+	}
+
+	// A define is used if it is called, rendered by name from Go, or is the
+	// block some partial call would pick for a page.
+	used := func(name string) bool {
+		if called[name] || name == r.opt.Layout || name == r.opt.FallbackTemplate || name == r.opt.EmailLayout {
+			return true
+		}
+		for _, t := range r.opt.ErrorTemplates {
+			if name == t {
+				return true
+			}
+		}
+		for _, p := range partials {
+			if strings.HasPrefix(name, p+"-") {
+				return true
+			}
+		}
+		return false
+	}
+
+	var layoutNames []string
+	for name := range layouts {
+		layoutNames = append(layoutNames, name)
+	}
+	sort.Strings(layoutNames)
+
+	for _, name := range names {
+		tree := trees[name]
+		if tree.Name != tree.ParseName {
+			if !used(name) {
+				report(tree, tree.Root, LintUnusedDefine, "template %q defined but never used", name)
+			}
+			continue
+		}
+		if !r.opt.RequireBlocks || layouts[name] || called[name] || parse.IsEmptyTree(tree.Root) {
+			continue
+		}
+		if _, builtin := builtinTemplates[name]; builtin || used(name) {
+			continue
+		}
+		for _, layout := range layoutNames {
+			seen := map[string]bool{}
+			for _, p := range fileParts[layout] {
+				block := p + "-" + name
+				if !seen[block] && trees[block] == nil {
+					report(tree, nil, LintMissingPartial, "page lacks block %q required by layout %q", block, layout)
+				}
+				seen[block] = true
+			}
+		}
+	}
+	return issues
+}
+
+// partialCall returns the name a {{ partial "name" }} command passes.
+func partialCall(n *parse.CommandNode) (string, bool) {
+	if len(n.Args) != 2 {
+		return "", false
+	}
+	if id, ok := n.Args[0].(*parse.IdentifierNode); !ok || id.Ident != "partial" {
+		return "", false
+	}
+	s, ok := n.Args[1].(*parse.StringNode)
+	if !ok {
+		return "", false
+	}
+	return s.Text, true
+}
+
+// lintLocation returns the file and line of node.
+func lintLocation(tree *parse.Tree, node parse.Node) (string, int) {
+	location, _ := tree.ErrorContext(node)
+	// location is "file:line:column".
+	location = location[:strings.LastIndex(location, ":")]
+	i := strings.LastIndex(location, ":")
+	line, _ := strconv.Atoi(location[i+1:])
+	return location[:i], line
+}
+
+// lintWalk calls fn for node and everything under it.
+func lintWalk(node parse.Node, fn func(parse.Node)) {
+	if node == nil {
+		return
+	}
+	fn(node)
+	branch := func(b *parse.BranchNode) {
+		lintWalk(b.Pipe, fn)
+		lintWalk(b.List, fn)
+		lintWalk(b.ElseList, fn)
+	}
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Nodes {
+			lintWalk(c, fn)
+		}
+	case *parse.ActionNode:
+		lintWalk(n.Pipe, fn)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Cmds {
+			lintWalk(c, fn)
+		}
+	case *parse.CommandNode:
+		for _, c := range n.Args {
+			lintWalk(c, fn)
+		}
+	case *parse.ChainNode:
+		lintWalk(n.Node, fn)
+	case *parse.TemplateNode:
+		lintWalk(n.Pipe, fn)
+	case *parse.IfNode:
+		branch(&n.BranchNode)
+	case *parse.RangeNode:
+		branch(&n.BranchNode)
+	case *parse.WithNode:
+		branch(&n.BranchNode)
+	}
+}
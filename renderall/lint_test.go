@@ -0,0 +1,37 @@
+package renderall
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	dir := templateDir(t, map[string]string{
+		"layout.tmpl":     `<html>{{ partial "css" }}{{ template "menu" }}{{ yield }}</html>`,
+		"home.tmpl":       "{{ define \"css-home\" }}x{{ end }}\n{{ nope }}{{ template \"missing\" }}",
+		"about.tmpl":      `about`,
+		"broken.tmpl":     `{{ if }}`,
+		"shared/nav.tmpl": `{{ define "orphan" }}{{ end }}{{ define "menu" }}{{ end }}`,
+	})
+	issues, err := Lint(Options{Directory: dir, Layout: "layout", RequireBlocks: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []LintIssue{
+		{Template: "about", Kind: LintMissingPartial, Message: `page lacks block "css-about" required by layout "layout"`},
+		{Template: "broken", Kind: LintSyntax, Message: "template: broken:1: missing value for if"},
+		{Template: "home", Line: 2, Kind: LintUndefinedFunc, Message: `function "nope" not defined`},
+		{Template: "home", Line: 2, Kind: LintMissingTemplate, Message: `template "missing" not defined`},
+		{Template: "shared/nav", Line: 1, Kind: LintUnusedDefine, Message: `template "orphan" defined but never used`},
+	}
+	if !reflect.DeepEqual(issues, want) {
+		t.Errorf("issues =\n%v\nwant\n%v", issues, want)
+	}
+	if got := want[2].String(); got != `home:2: function "nope" not defined (undefined-func)` {
+		t.Errorf("String() = %q", got)
+	}
+
+	if _, err := Lint(Options{Directory: dir + "/missing"}); err == nil {
+		t.Error("missing directory accepted")
+	}
+}
@@ -0,0 +1,193 @@
+package renderall
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const defaultLiveReloadPath = "/_renderall/livereload"
+
+// liveReloadInterval is how often the watcher polls for changes.
+var liveReloadInterval = 500 * time.Millisecond
+
+// liveReload tracks the browsers waiting for a reload and the watcher
+// telling them when to.
+type liveReload struct {
+	mu      sync.Mutex
+	clients map[chan struct{}]struct{}
+	started bool
+}
+
+// subscribe registers a client, starting the watcher on first use.
+func (l *liveReload) subscribe(r *Render) chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.clients == nil {
+		l.clients = map[chan struct{}]struct{}{}
+	}
+	if !l.started {
+		l.started = true
+		go l.watch(r)
+	}
+	ch := make(chan struct{}, 1)
+	l.clients[ch] = struct{}{}
+	return ch
+}
+
+func (l *liveReload) unsubscribe(ch chan struct{}) {
+	l.mu.Lock()
+	delete(l.clients, ch)
+	l.mu.Unlock()
+}
+
+func (l *liveReload) broadcast() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for ch := range l.clients {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// watch polls the templates and assets for changes for the life of the
+// process. Templates from Options.Loader are watched through the loader,
+// which with ReloadTemplates already broadcasts once they are recompiled.
+func (l *liveReload) watch(r *Render) {
+	if r.opt.Loader != nil && !r.opt.ReloadTemplates {
+		go r.opt.Loader.Watch(context.Background(), l.broadcast)
+	}
+	last := r.sourceSnapshot()
+	for range time.Tick(liveReloadInterval) {
+		if snap := r.sourceSnapshot(); snap != last {
+			last = snap
+			l.broadcast()
+		}
+	}
+}
+
+// sourceSnapshot fingerprints the name, size, and mtime of every template
+// and asset file on disk or in Options.Assets.
+func (r *Render) sourceSnapshot() uint64 {
+	h := fnv.New64a()
+	record := func(path string, info os.FileInfo) {
+		fmt.Fprintf(h, "%s %d %d\n", path, info.Size(), info.ModTime().UnixNano())
+	}
+
+	var roots []string
+	if r.opt.Loader == nil {
+		roots = append(roots, r.opt.Directory)
+	}
+	for _, themeRoots := range r.opt.Themes {
+		roots = append(roots, themeRoots...)
+	}
+	for _, root := range roots {
+		filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() {
+				record(path, info)
+			}
+			return nil
+		})
+	}
+	if r.opt.Assets != nil {
+		fs.WalkDir(r.opt.Assets, ".", func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			if info, err := d.Info(); err == nil {
+				record("assets/"+path, info)
+			}
+			return nil
+		})
+	}
+	return h.Sum64()
+}
+
+// LiveReloadHandler serves the server-sent events stream the injected live
+// reload script listens on. Mount it at Options.LiveReloadPath.
+func (r *Render) LiveReloadHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		rc := http.NewResponseController(w)
+		w.Header().Set(ContentType, "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		// Flushing sends the 200, unless no writer in the chain can flush.
+		if err := rc.Flush(); errors.Is(err, http.ErrNotSupported) {
+			w.Header().Del("Cache-Control")
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+
+		ch := r.reload.subscribe(r)
+		defer r.reload.unsubscribe(ch)
+
+		if r.streamWrite(w, rc, "retry: 1000\n\n") != nil {
+			return
+		}
+		heartbeat := time.NewTicker(15 * time.Second)
+		defer heartbeat.Stop()
+		for {
+			event := ": ping\n\n"
+			select {
+			case <-req.Context().Done():
+				return
+			case <-heartbeat.C:
+			case <-ch:
+				event = "event: reload\ndata: {}\n\n"
+			}
+			if r.streamWrite(w, rc, event) != nil {
+				return
+			}
+		}
+	})
+}
+
+// liveReloadScript returns the script injected into development HTML
+// renders, or nil outside development mode.
+func (r *Render) liveReloadScript(nonce string) []byte {
+	if !r.opt.IsDevelopment || r.opt.DisableLiveReload {
+		return nil
+	}
+	return []byte(`<script nonce="` + template.HTMLEscapeString(nonce) + `">` +
+		`(function(){var es=new EventSource('` + template.JSEscapeString(r.opt.LiveReloadPath) + `');` +
+		`es.addEventListener("reload",function(){location.reload()})})();</script>`)
+}
+
+var bodyEnd = []byte("</body>")
+
+// injectBeforeBodyEnd inserts b before the last closing body tag of the
+// document in buf, or appends it if there is none.
+func injectBeforeBodyEnd(buf *bytes.Buffer, b []byte) {
+	doc := buf.Bytes()
+	i := lastIndexFold(doc, bodyEnd)
+	if i < 0 {
+		buf.Write(b)
+		return
+	}
+	tail := append([]byte(nil), doc[i:]...)
+	buf.Truncate(i)
+	buf.Write(b)
+	buf.Write(tail)
+}
+
+// lastIndexFold is bytes.LastIndex ignoring case. It compares windows of the
+// original bytes, so unlike searching a lowered copy its offsets stay valid
+// in documents whose runes change length when lowered, such as U+0130.
+func lastIndexFold(s, sep []byte) int {
+	for i := len(s) - len(sep); i >= 0; i-- {
+		if bytes.EqualFold(s[i:i+len(sep)], sep) {
+			return i
+		}
+	}
+	return -1
+}
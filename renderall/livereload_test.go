@@ -0,0 +1,134 @@
+package renderall
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestInjectBeforeBodyEnd(t *testing.T) {
+	tests := []struct{ doc, want string }{
+		{"<body>a</body></html>", "<body>a<s></body></html>"},
+		{"<BODY>a</BODY>", "<BODY>a<s></BODY>"},
+		{"<p>a</body><p>b</body>", "<p>a</body><p>b<s></body>"},
+		{"fragment", "fragment<s>"},
+	}
+	for _, tt := range tests {
+		buf := bytes.NewBufferString(tt.doc)
+		injectBeforeBodyEnd(buf, []byte("<s>"))
+		if buf.String() != tt.want {
+			t.Errorf("inject into %q = %q, want %q", tt.doc, buf, tt.want)
+		}
+	}
+}
+
+func TestLiveReloadScript(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "page.tmpl"), []byte("<body>hi</body>"), 0o644)
+	for _, tt := range []struct {
+		opt    Options
+		inject bool
+	}{
+		{Options{Directory: dir, IsDevelopment: true}, true},
+		{Options{Directory: dir, IsDevelopment: true, DisableLiveReload: true}, false},
+		{Options{Directory: dir}, false},
+	} {
+		w := httptest.NewRecorder()
+		if err := New(tt.opt).HTML(w, 200, "page", nil); err != nil {
+			t.Fatal(err)
+		}
+		body := w.Body.String()
+		if got := strings.Contains(body, "EventSource('"+defaultLiveReloadPath+"')"); got != tt.inject {
+			t.Errorf("%+v: script injected %v, want %v: %s", tt.opt, got, tt.inject, body)
+		}
+		if tt.inject && !strings.HasSuffix(body, "</script></body>") {
+			t.Errorf("script not before </body>: %s", body)
+		}
+	}
+}
+
+// nextEvent reads the stream up to the next event name.
+func nextEvent(t *testing.T, r *bufio.Reader) string {
+	t.Helper()
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name, ok := strings.CutPrefix(line, "event: "); ok {
+			return strings.TrimSpace(name)
+		}
+	}
+}
+
+// reloadStream opens the live reload stream of r.
+func reloadStream(t *testing.T, r *Render) *bufio.Reader {
+	t.Helper()
+	srv := httptest.NewServer(r.LiveReloadHandler())
+	t.Cleanup(srv.Close)
+	res, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { res.Body.Close() })
+	if ct := res.Header.Get(ContentType); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	return bufio.NewReader(res.Body)
+}
+
+func TestLiveReloadDirectory(t *testing.T) {
+	defer func(d time.Duration) { liveReloadInterval = d }(liveReloadInterval)
+	liveReloadInterval = 10 * time.Millisecond
+
+	dir := t.TempDir()
+	page := filepath.Join(dir, "page.tmpl")
+	os.WriteFile(page, []byte("a"), 0o644)
+	stream := reloadStream(t, New(Options{Directory: dir, IsDevelopment: true}))
+	if line, _ := stream.ReadString('\n'); line != "retry: 1000\n" {
+		t.Fatalf("first line %q", line)
+	}
+	time.Sleep(5 * liveReloadInterval)
+	os.WriteFile(page, []byte("changed"), 0o644)
+	if name := nextEvent(t, stream); name != "reload" {
+		t.Errorf("event %q, want reload", name)
+	}
+}
+
+// signalLoader is a TemplateLoader whose Watch reports a change on each
+// send to changes.
+type signalLoader struct {
+	TemplateLoader
+	changes chan struct{}
+}
+
+func (l signalLoader) Watch(ctx context.Context, changed func()) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-l.changes:
+			changed()
+		}
+	}
+}
+
+func TestLiveReloadLoader(t *testing.T) {
+	l := signalLoader{FSLoader(fstest.MapFS{"page.tmpl": {Data: []byte("a")}}), make(chan struct{})}
+	stream := reloadStream(t, New(Options{Loader: l, IsDevelopment: true}))
+	if line, _ := stream.ReadString('\n'); line != "retry: 1000\n" {
+		t.Fatalf("first line %q", line)
+	}
+	l.changes <- struct{}{}
+	if name := nextEvent(t, stream); name != "reload" {
+		t.Errorf("event %q, want reload", name)
+	}
+}
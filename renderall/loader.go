@@ -0,0 +1,131 @@
+package renderall
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"html/template"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// TemplateLoader sources template files, letting templates live somewhere
+// other than local disk, such as object storage, git, or a database.
+type TemplateLoader interface {
+	// List returns the slash separated names of every file the loader holds.
+	List() ([]string, error)
+	// ReadFile returns the contents of the named file.
+	ReadFile(name string) ([]byte, error)
+	// Watch calls changed whenever the files may have changed until ctx is
+	// done. Loaders that cannot watch return nil straight away.
+	Watch(ctx context.Context, changed func()) error
+}
+
+// FSLoader returns a TemplateLoader reading from fsys. Watch polls the file
+// listing, sizes, and modification times.
+func FSLoader(fsys fs.FS) TemplateLoader {
+	return fsLoader{fsys}
+}
+
+// DirLoader returns a TemplateLoader reading from the directory dir on disk.
+func DirLoader(dir string) TemplateLoader {
+	return fsLoader{os.DirFS(dir)}
+}
+
+type fsLoader struct {
+	fsys fs.FS
+}
+
+func (l fsLoader) List() ([]string, error) {
+	var names []string
+	err := fs.WalkDir(l.fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			names = append(names, name)
+		}
+		return nil
+	})
+	return names, err
+}
+
+func (l fsLoader) ReadFile(name string) ([]byte, error) {
+	return fs.ReadFile(l.fsys, name)
+}
+
+func (l fsLoader) Watch(ctx context.Context, changed func()) error {
+	last := l.snapshot()
+	ticker := time.NewTicker(liveReloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if snap := l.snapshot(); snap != last {
+				last = snap
+				changed()
+			}
+		}
+	}
+}
+
+func (l fsLoader) snapshot() uint64 {
+	h := fnv.New64a()
+	fs.WalkDir(l.fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			fmt.Fprintf(h, "%s %d %d\n", name, info.Size(), info.ModTime().UnixNano())
+		}
+		return nil
+	})
+	return h.Sum64()
+}
+
+// compileLoader compiles every template the loader holds into new sets.
+func (r *Render) compileLoader(l TemplateLoader) (*template.Template, *texttemplate.Template, error) {
+	return r.compile(".", r.walkLoader(l))
+}
+
+// walkLoader returns a walk over the templates the loader holds.
+func (r *Render) walkLoader(l TemplateLoader) func(parseFunc) error {
+	return func(parse parseFunc) error {
+		names, err := l.List()
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			ext := path.Ext(name)
+			for _, extension := range r.opt.Extensions {
+				if ext == extension {
+					buf, err := l.ReadFile(name)
+					if err != nil {
+						return err
+					}
+					if err := parse(strings.TrimSuffix(name, ext), buf); err != nil {
+						return err
+					}
+					break
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// watchTemplates recompiles the templates whenever Options.Loader reports a
+// change, keeping the current set if they fail to parse.
+func (r *Render) watchTemplates() {
+	r.opt.Loader.Watch(context.Background(), func() {
+		if r.compileTemplates() == nil {
+			r.reload.broadcast()
+		}
+	})
+}
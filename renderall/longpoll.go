@@ -0,0 +1,124 @@
+package renderall
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"time"
+)
+
+// LongPollOptions is a struct for overriding some rendering Options for specific LongPoll call.
+type LongPollOptions struct {
+	// Time to wait for a value before giving up. Defaults to 30 seconds.
+	Timeout time.Duration
+	// Interval between whitespace heartbeats that keep idle proxies from
+	// closing the connection. Defaults to 15 seconds; negative disables them.
+	Heartbeat time.Duration
+}
+
+// committedWriter ignores WriteHeader for a response whose status has
+// already been sent by a heartbeat.
+type committedWriter struct {
+	http.ResponseWriter
+}
+
+func (committedWriter) WriteHeader(int) {}
+
+// Flush sends any buffered data to the client.
+func (c committedWriter) Flush() {
+	http.NewResponseController(c.ResponseWriter).Flush()
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (c committedWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
+
+// LongPoll holds the request open until a value arrives on ch, which may be
+// a channel of any element type, and renders it as JSON. If the timeout
+// passes or ch is closed first the response is 204 No Content. Heartbeats
+// commit a 200 status, so a timeout after the first one ends the body with
+// null instead. A cancelled request returns its context's error without
+// writing anything further.
+func (r *Render) LongPoll(w http.ResponseWriter, req *http.Request, ch interface{}, pollOpt ...LongPollOptions) error {
+	opt := LongPollOptions{}
+	if len(pollOpt) > 0 {
+		opt = pollOpt[0]
+	}
+	if opt.Timeout <= 0 {
+		opt.Timeout = 30 * time.Second
+	}
+	if opt.Heartbeat == 0 {
+		opt.Heartbeat = 15 * time.Second
+	}
+
+	cv := reflect.ValueOf(ch)
+	if cv.Kind() != reflect.Chan || cv.Type().ChanDir()&reflect.RecvDir == 0 {
+		return r.fail(w, fmt.Errorf("renderall: long poll requires a receivable channel, got %T", ch))
+	}
+
+	timeout := time.NewTimer(opt.Timeout)
+	defer timeout.Stop()
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: cv},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(req.Context().Done())},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(timeout.C)},
+	}
+	if opt.Heartbeat > 0 {
+		heartbeat := time.NewTicker(opt.Heartbeat)
+		defer heartbeat.Stop()
+		cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(heartbeat.C)})
+	}
+
+	rc := http.NewResponseController(w)
+	started := false
+	for {
+		chosen, v, ok := reflect.Select(cases)
+		switch chosen {
+		case 0:
+			if ok {
+				if started {
+					return r.longPollValue(committedWriter{w}, req, v.Interface())
+				}
+				return r.JSON(w, http.StatusOK, v.Interface(), JSONOptions{Request: req})
+			}
+			return r.longPollTimeout(w, started)
+		case 1:
+			return req.Context().Err()
+		case 2:
+			return r.longPollTimeout(w, started)
+		default:
+			if !started {
+				started = true
+				w.Header().Set(ContentType, r.withCharset(ContentJSON))
+				w.Header().Set("Cache-Control", "no-store")
+				w.WriteHeader(http.StatusOK)
+			}
+			if err := r.streamWrite(w, rc, "\n"); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// longPollValue ends a long poll whose status a heartbeat already sent. The
+// value is encoded straight onto w: the render pipeline would set headers,
+// such as compression and ETags, too late for the client to see, and an
+// error page can no longer replace the body.
+func (r *Render) longPollValue(w committedWriter, req *http.Request, v interface{}) error {
+	if _, err := r.jsonEngine(http.StatusOK, req).Encode(w, v); err != nil {
+		return err
+	}
+	w.Flush()
+	return nil
+}
+
+// longPollTimeout ends a long poll that received nothing.
+func (r *Render) longPollTimeout(w http.ResponseWriter, started bool) error {
+	if started {
+		_, err := w.Write([]byte("null"))
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
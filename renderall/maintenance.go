@@ -0,0 +1,91 @@
+package renderall
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maintenanceMode is the state SetMaintenance switches on.
+type maintenanceMode struct {
+	message string
+}
+
+// SetMaintenance switches maintenance mode on or off at runtime. While on,
+// every render is replaced by a 503 with Retry-After. Requests preferring
+// HTML get Options.ErrorTemplates[503] or the built-in maintenance page,
+// with message as the ErrorPage Message, and everything else the JSON
+// error body. Options.MaintenanceBypass can let some requests through.
+func (r *Render) SetMaintenance(on bool, message string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if on {
+		r.maintenance = &maintenanceMode{message: message}
+	} else {
+		r.maintenance = nil
+	}
+}
+
+// Maintenance reports whether maintenance mode is on, and its message.
+func (r *Render) Maintenance() (bool, string) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	if r.maintenance == nil {
+		return false, ""
+	}
+	return true, r.maintenance.message
+}
+
+// inMaintenance returns the maintenance mode a render to req is subject to,
+// or nil.
+func (r *Render) inMaintenance(req *http.Request) *maintenanceMode {
+	r.lock.RLock()
+	m := r.maintenance
+	r.lock.RUnlock()
+	if m == nil || req != nil && r.opt.MaintenanceBypass != nil && r.opt.MaintenanceBypass(req) {
+		return nil
+	}
+	return m
+}
+
+// renderMaintenance writes the maintenance response in place of a render.
+// It runs the engines directly, since going through render would end up
+// back here.
+func (r *Render) renderMaintenance(w http.ResponseWriter, req *http.Request, m *maintenanceMode) error {
+	retry := r.opt.MaintenanceRetryAfter
+	if retry <= 0 {
+		retry = 5 * time.Minute
+	}
+	w.Header().Set(RetryAfter, strconv.Itoa(ceilSeconds(retry)))
+	status := http.StatusServiceUnavailable
+
+	if prefersHTML(req) {
+		opt := HTMLOptions{Request: req}
+		name, ok := r.opt.ErrorTemplates[status]
+		if !ok {
+			name, opt.NoLayout = MaintenanceTemplate, true
+		}
+		page := r.errorPage(req, status)
+		page.Message = m.message
+		h, binding, _, err := r.prepareHTML(w, status, name, page, nil, []HTMLOptions{opt})
+		if err != nil {
+			return err
+		}
+		return h.Render(w, binding)
+	}
+
+	message := m.message
+	if message == "" {
+		message = http.StatusText(status)
+	}
+	j := JSON{
+		Head: Head{
+			ContentType: r.withCharset(ContentJSON),
+			Status:      status,
+		},
+		Indent: r.opt.IndentJSON,
+	}
+	e := newAPIError(status, "maintenance", message, nil)
+	e.RequestID = r.RequestID(req)
+	return j.Render(w, e)
+}
@@ -0,0 +1,135 @@
+package renderall
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"strings"
+	"sync"
+)
+
+// ManifestEntry is a built asset listed in a front-end build manifest.
+type ManifestEntry struct {
+	// File is the fingerprinted output path, e.g. "assets/main.4889e940.js".
+	File string `json:"file"`
+	// CSS lists stylesheets extracted from the entry.
+	CSS []string `json:"css"`
+}
+
+// ParseManifest reads a Vite manifest or a flat source to output map as
+// written by esbuild and webpack manifest plugins.
+func ParseManifest(data []byte) (map[string]ManifestEntry, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	manifest := make(map[string]ManifestEntry, len(raw))
+	for name, v := range raw {
+		var file string
+		if err := json.Unmarshal(v, &file); err == nil {
+			manifest[name] = ManifestEntry{File: file}
+			continue
+		}
+		var entry ManifestEntry
+		if err := json.Unmarshal(v, &entry); err != nil {
+			return nil, fmt.Errorf("renderall: manifest entry %q: %v", name, err)
+		}
+		manifest[name] = entry
+	}
+	return manifest, nil
+}
+
+// assetManifest lazily loads Options.AssetManifest from Options.Assets.
+type assetManifest struct {
+	once    sync.Once
+	entries map[string]ManifestEntry
+	err     error
+}
+
+func (r *Render) manifest() (map[string]ManifestEntry, error) {
+	if r.opt.AssetManifest == "" {
+		return nil, nil
+	}
+	r.assetManifest.once.Do(func() {
+		if r.opt.Assets == nil {
+			r.assetManifest.err = fmt.Errorf("renderall: Options.AssetManifest requires Options.Assets")
+			return
+		}
+		data, err := fs.ReadFile(r.opt.Assets, r.opt.AssetManifest)
+		if err != nil {
+			r.assetManifest.err = err
+			return
+		}
+		r.assetManifest.entries, r.assetManifest.err = ParseManifest(data)
+	})
+	return r.assetManifest.entries, r.assetManifest.err
+}
+
+// devServer reports whether assets are served by the front-end dev server.
+func (r *Render) devServer() bool {
+	return r.opt.IsDevelopment && r.opt.AssetDevServer != ""
+}
+
+// builtAsset resolves a source asset name to its built file, which is the
+// name itself when there is no manifest entry.
+func (r *Render) builtAsset(name string) (ManifestEntry, error) {
+	manifest, err := r.manifest()
+	if err != nil {
+		return ManifestEntry{}, err
+	}
+	if entry, ok := manifest[name]; ok {
+		return entry, nil
+	}
+	if manifest != nil {
+		return ManifestEntry{}, fmt.Errorf("renderall: asset %q is not in the manifest", name)
+	}
+	return ManifestEntry{File: name}, nil
+}
+
+// AssetURL returns the URL for a source asset: on the dev server in
+// development mode, otherwise the fingerprinted file under Options.AssetPrefix.
+// In TestMode it is the source name under AssetPrefix.
+func (r *Render) AssetURL(name string) (string, error) {
+	if r.devServer() {
+		return strings.TrimRight(r.opt.AssetDevServer, "/") + "/" + strings.TrimLeft(name, "/"), nil
+	}
+	if r.opt.TestMode {
+		return r.opt.AssetPrefix + strings.TrimLeft(name, "/"), nil
+	}
+	entry, err := r.builtAsset(name)
+	if err != nil {
+		return "", err
+	}
+	return r.opt.AssetPrefix + strings.TrimLeft(entry.File, "/"), nil
+}
+
+// manifestFuncs expose asset resolution to templates.
+func (r *Render) manifestFuncs() template.FuncMap {
+	return template.FuncMap{
+		"asset": r.AssetURL,
+		"assetCSS": func(name string) ([]string, error) {
+			if r.devServer() || r.opt.TestMode {
+				// The dev server injects styles from the module graph itself,
+				// and tests must not depend on hashed file names.
+				return nil, nil
+			}
+			entry, err := r.builtAsset(name)
+			if err != nil {
+				return nil, err
+			}
+			urls := make([]string, len(entry.CSS))
+			for i, css := range entry.CSS {
+				urls[i] = r.opt.AssetPrefix + strings.TrimLeft(css, "/")
+			}
+			return urls, nil
+		},
+		"viteClient": func() string {
+			if !r.devServer() {
+				return ""
+			}
+			return strings.TrimRight(r.opt.AssetDevServer, "/") + "/@vite/client"
+		},
+	}
+}
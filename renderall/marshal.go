@@ -0,0 +1,174 @@
+package renderall
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// JSONMarshaler is implemented by types that control their own JSON wire
+// representation when rendered, independently of any json.Marshaler they
+// may implement for other uses.
+type JSONMarshaler interface {
+	RenderJSON() ([]byte, error)
+}
+
+// XMLMarshaler is implemented by types that control their own XML wire
+// representation when rendered at the top level of an XML response.
+type XMLMarshaler interface {
+	RenderXML() ([]byte, error)
+}
+
+// MarshalHook transforms a value before it is marshaled, e.g. to redact
+// fields or add computed ones. Returning an error aborts the render.
+type MarshalHook func(v interface{}) (interface{}, error)
+
+// renderedJSON adapts a JSONMarshaler to json.Marshaler so it is honoured by
+// both the buffered and streaming encoders, including indentation.
+type renderedJSON struct {
+	m JSONMarshaler
+}
+
+func (r renderedJSON) MarshalJSON() ([]byte, error) {
+	return r.m.RenderJSON()
+}
+
+func applyHook(hook MarshalHook, v interface{}) (interface{}, error) {
+	if hook == nil {
+		return v, nil
+	}
+	return hook(v)
+}
+
+// prepareJSON applies the hook and adapts JSONMarshalers. For an Envelope the
+// payload is prepared rather than the Envelope itself.
+func prepareJSON(hook MarshalHook, v interface{}) (interface{}, error) {
+	if env, ok := v.(Envelope); ok {
+		data, err := prepareJSON(hook, env.Data)
+		if err != nil {
+			return nil, err
+		}
+		env.Data = data
+		return env, nil
+	}
+
+	v, err := applyHook(hook, v)
+	if err != nil {
+		return nil, err
+	}
+	if m, ok := v.(JSONMarshaler); ok {
+		return renderedJSON{m}, nil
+	}
+	return v, nil
+}
+
+// jsonTree prepares v as for JSON and decodes its JSON form into ordered
+// objects, arrays, strings, json.Numbers, bools, and nils, for encoders of
+// formats with the JSON data model. json tags and marshalers apply as they
+// do to JSON responses.
+func jsonTree(hook MarshalHook, v interface{}) (interface{}, error) {
+	v, err := prepareJSON(hook, v)
+	if err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	return decodeOrdered(dec)
+}
+
+// jsonObject is a decoded JSON object that keeps its members in their
+// original order.
+type jsonObject struct {
+	keys []string
+	vals map[string]interface{}
+}
+
+// decodeOrdered decodes the next JSON value from dec, with objects as
+// *jsonObject and numbers as json.Number.
+func decodeOrdered(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		o := &jsonObject{vals: map[string]interface{}{}}
+		for dec.More() {
+			kt, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			k := kt.(string)
+			v, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			if _, dup := o.vals[k]; !dup {
+				o.keys = append(o.keys, k)
+			}
+			o.vals[k] = v
+		}
+		_, err := dec.Token()
+		return o, err
+	case json.Delim('['):
+		a := []interface{}{}
+		for dec.More() {
+			v, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, v)
+		}
+		_, err := dec.Token()
+		return a, err
+	}
+	return tok, nil
+}
+
+// jsonMembers writes a JSON object member by member, in the order given.
+// Members are encoded without HTML escaping, which the outer encoder applies,
+// if asked to, over the whole output.
+type jsonMembers struct {
+	buf bytes.Buffer
+	enc *json.Encoder
+	n   int
+}
+
+func newJSONMembers() *jsonMembers {
+	m := &jsonMembers{}
+	m.enc = json.NewEncoder(&m.buf)
+	m.enc.SetEscapeHTML(false)
+	m.buf.WriteByte('{')
+	return m
+}
+
+// add writes the member name with the value v.
+func (m *jsonMembers) add(name string, v interface{}) error {
+	if m.n > 0 {
+		m.buf.WriteByte(',')
+	}
+	m.n++
+	if err := m.encode(name); err != nil {
+		return err
+	}
+	m.buf.WriteByte(':')
+	return m.encode(v)
+}
+
+// encode writes v without the encoder's trailing newline.
+func (m *jsonMembers) encode(v interface{}) error {
+	if err := m.enc.Encode(v); err != nil {
+		return err
+	}
+	m.buf.Truncate(m.buf.Len() - 1)
+	return nil
+}
+
+// close ends the object and returns it.
+func (m *jsonMembers) close() []byte {
+	m.buf.WriteByte('}')
+	return m.buf.Bytes()
+}
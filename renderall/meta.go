@@ -0,0 +1,147 @@
+package renderall
+
+import (
+	"html/template"
+	"strings"
+)
+
+// Meta describes a page for search engines and link previews, rendered by
+// the metaTags template func. Site wide defaults go in Options.Meta; pages
+// override fields through HTMLOptions.Meta or a binding implementing
+// MetaProvider. Blank fields are left to the layer below.
+type Meta struct {
+	Title       string
+	Description string
+	// Canonical is the page's canonical URL.
+	Canonical string
+	// Image is the preview image URL, used when OpenGraph and Twitter set none.
+	Image string
+	// Robots is the robots meta value, e.g. "noindex".
+	Robots    string
+	OpenGraph OpenGraph
+	Twitter   TwitterCard
+}
+
+// OpenGraph holds og: properties. Title, Description, URL, and Image fall
+// back to the Meta fields.
+type OpenGraph struct {
+	Type        string
+	Title       string
+	Description string
+	URL         string
+	Image       string
+	SiteName    string
+	Locale      string
+}
+
+// TwitterCard holds twitter: card properties. Title, Description, and Image
+// fall back to the Meta fields.
+type TwitterCard struct {
+	// Card type, e.g. "summary_large_image".
+	Card        string
+	Site        string
+	Creator     string
+	Title       string
+	Description string
+	Image       string
+}
+
+// MetaProvider is implemented by bindings that carry their page's Meta.
+type MetaProvider interface {
+	PageMeta() Meta
+}
+
+// nonBlank returns a, or b if a is blank.
+func nonBlank(a, b string) string {
+	if a != "" {
+		return a
+	}
+	return b
+}
+
+// Merge returns m with the non-blank fields of o laid over it.
+func (m Meta) Merge(o Meta) Meta {
+	return Meta{
+		Title:       nonBlank(o.Title, m.Title),
+		Description: nonBlank(o.Description, m.Description),
+		Canonical:   nonBlank(o.Canonical, m.Canonical),
+		Image:       nonBlank(o.Image, m.Image),
+		Robots:      nonBlank(o.Robots, m.Robots),
+		OpenGraph: OpenGraph{
+			Type:        nonBlank(o.OpenGraph.Type, m.OpenGraph.Type),
+			Title:       nonBlank(o.OpenGraph.Title, m.OpenGraph.Title),
+			Description: nonBlank(o.OpenGraph.Description, m.OpenGraph.Description),
+			URL:         nonBlank(o.OpenGraph.URL, m.OpenGraph.URL),
+			Image:       nonBlank(o.OpenGraph.Image, m.OpenGraph.Image),
+			SiteName:    nonBlank(o.OpenGraph.SiteName, m.OpenGraph.SiteName),
+			Locale:      nonBlank(o.OpenGraph.Locale, m.OpenGraph.Locale),
+		},
+		Twitter: TwitterCard{
+			Card:        nonBlank(o.Twitter.Card, m.Twitter.Card),
+			Site:        nonBlank(o.Twitter.Site, m.Twitter.Site),
+			Creator:     nonBlank(o.Twitter.Creator, m.Twitter.Creator),
+			Title:       nonBlank(o.Twitter.Title, m.Twitter.Title),
+			Description: nonBlank(o.Twitter.Description, m.Twitter.Description),
+			Image:       nonBlank(o.Twitter.Image, m.Twitter.Image),
+		},
+	}
+}
+
+// Tags renders the title, description, canonical link, robots, Open Graph,
+// and Twitter card tags, skipping blank values.
+func (m Meta) Tags() template.HTML {
+	var b strings.Builder
+	esc := template.HTMLEscapeString
+	meta := func(attr, key, value string) {
+		if value != "" {
+			b.WriteString(`<meta ` + attr + `="` + key + `" content="` + esc(value) + `">` + "\n")
+		}
+	}
+
+	if m.Title != "" {
+		b.WriteString("<title>" + esc(m.Title) + "</title>\n")
+	}
+	meta("name", "description", m.Description)
+	if m.Canonical != "" {
+		b.WriteString(`<link rel="canonical" href="` + esc(m.Canonical) + `">` + "\n")
+	}
+	meta("name", "robots", m.Robots)
+
+	og := m.OpenGraph
+	meta("property", "og:type", og.Type)
+	meta("property", "og:title", nonBlank(og.Title, m.Title))
+	meta("property", "og:description", nonBlank(og.Description, m.Description))
+	meta("property", "og:url", nonBlank(og.URL, m.Canonical))
+	meta("property", "og:image", nonBlank(og.Image, m.Image))
+	meta("property", "og:site_name", og.SiteName)
+	meta("property", "og:locale", og.Locale)
+
+	tw := m.Twitter
+	meta("name", "twitter:card", tw.Card)
+	meta("name", "twitter:site", tw.Site)
+	meta("name", "twitter:creator", tw.Creator)
+	meta("name", "twitter:title", nonBlank(tw.Title, m.Title))
+	meta("name", "twitter:description", nonBlank(tw.Description, m.Description))
+	meta("name", "twitter:image", nonBlank(tw.Image, m.Image))
+	return template.HTML(b.String())
+}
+
+// metaFuncs expose the page's Meta: Options.Meta, then the binding's, then
+// HTMLOptions.Meta.
+func (r *Render) metaFuncs(opt HTMLOptions, binding interface{}) template.FuncMap {
+	m := r.opt.Meta
+	if p, ok := binding.(MetaProvider); ok {
+		m = m.Merge(p.PageMeta())
+	}
+	if opt.Meta != nil {
+		m = m.Merge(*opt.Meta)
+	}
+	return template.FuncMap{
+		"meta": func() Meta {
+			return m
+		},
+		"metaTags": func() template.HTML {
+			return m.Tags()
+		},
+	}
+}
@@ -0,0 +1,140 @@
+package renderall
+
+import (
+	"bytes"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// builtinMIMETypes covers extensions that the system mime tables often get
+// wrong or lack, so they resolve the same on every host.
+var builtinMIMETypes = map[string]string{
+	".avif":        "image/avif",
+	".css":         "text/css",
+	".csv":         ContentCSV,
+	".gz":          ContentGzip,
+	".html":        ContentHTML,
+	".ico":         "image/x-icon",
+	".jpeg":        ContentJPEG,
+	".jpg":         ContentJPEG,
+	".js":          "text/javascript",
+	".json":        ContentJSON,
+	".jsonld":      ContentJSONLD,
+	".map":         ContentJSON,
+	".md":          "text/markdown",
+	".mjs":         "text/javascript",
+	".pdf":         ContentPDF,
+	".png":         ContentPNG,
+	".svg":         ContentSVG,
+	".tar":         ContentTar,
+	".tsv":         ContentTSV,
+	".txt":         ContentText,
+	".vcf":         ContentVCard,
+	".wasm":        "application/wasm",
+	".webmanifest": ContentWebManifest,
+	".webp":        "image/webp",
+	".woff":        "font/woff",
+	".woff2":       "font/woff2",
+	".xlsx":        ContentXLSX,
+	".xml":         ContentXML,
+	".yaml":        "application/yaml",
+	".yml":         "application/yaml",
+	".zip":         ContentZip,
+}
+
+// MIMEType resolves the content type for a file name, looking at
+// Options.MIMETypes, then the built-in table, then the system mime tables.
+// Unknown extensions fall back to sniffing data, and to
+// application/octet-stream when there is none to sniff.
+func (r *Render) MIMEType(name string, data []byte) string {
+	ext := strings.ToLower(path.Ext(name))
+	if ext != "" {
+		if t, ok := r.opt.MIMETypes[ext]; ok {
+			return t
+		}
+		if t, ok := builtinMIMETypes[ext]; ok {
+			return t
+		}
+		if t := mime.TypeByExtension(ext); t != "" {
+			return t
+		}
+	}
+	if len(data) == 0 {
+		return ContentBinary
+	}
+	return sniffContentType(data)
+}
+
+// sniffContentType is http.DetectContentType plus the formats it does not
+// know about yet.
+func sniffContentType(data []byte) string {
+	t := http.DetectContentType(data)
+	if t != ContentBinary {
+		return t
+	}
+	// ISO base media files name their brand at offset 8, after the box size and "ftyp".
+	if len(data) >= 12 && bytes.Equal(data[4:8], []byte("ftyp")) {
+		switch string(data[8:12]) {
+		case "avif", "avis":
+			return "image/avif"
+		case "heic", "heix":
+			return "image/heic"
+		}
+	}
+	return t
+}
+
+// prepareMIMETypes normalises the Options.MIMETypes keys to lower case
+// extensions with a leading dot.
+func (r *Render) prepareMIMETypes() {
+	if len(r.opt.MIMETypes) == 0 {
+		return
+	}
+	types := make(map[string]string, len(r.opt.MIMETypes))
+	for ext, t := range r.opt.MIMETypes {
+		ext = strings.ToLower(ext)
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		types[ext] = t
+	}
+	r.opt.MIMETypes = types
+}
+
+// withCharset appends the charset to contentType if its media type takes
+// one, per Options.CharsetTypes and LegacyCharset, and it names none yet.
+func (r *Render) withCharset(contentType string) string {
+	if strings.Contains(contentType, "charset=") {
+		return contentType
+	}
+	media := contentType
+	if i := strings.IndexByte(media, ';'); i >= 0 {
+		media = media[:i]
+	}
+	media = strings.ToLower(strings.TrimSpace(media))
+
+	add, ok := r.opt.CharsetTypes[media]
+	if !ok {
+		add = r.opt.LegacyCharset || takesCharset(media)
+	}
+	if !add {
+		return contentType
+	}
+	return contentType + r.compiledCharset
+}
+
+// takesCharset reports whether media defines a charset parameter: text
+// types, XML, and JavaScript do; JSON (RFC 8259) and binary types do not.
+func takesCharset(media string) bool {
+	switch {
+	case strings.HasPrefix(media, "text/"):
+		return true
+	case media == "application/xml" || strings.HasSuffix(media, "+xml"):
+		return true
+	case media == ContentJSONP || media == "application/ecmascript":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,177 @@
+package renderall
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+)
+
+// ContentMsgPack header value for MessagePack data.
+const ContentMsgPack = "application/msgpack"
+
+// MsgPackOptions is a struct for overriding some rendering Options for specific MsgPack call.
+type MsgPackOptions struct {
+	// Request being served, for PreRender hooks and request IDs. Defaults to nil.
+	Request *http.Request
+}
+
+// MsgPack built-in renderer. It writes the value's JSON form as MessagePack,
+// so json tags and marshalers apply as they do to JSON, and []byte fields
+// are sent as base64 strings.
+type MsgPack struct {
+	Head
+	Hook MarshalHook
+}
+
+// Render a MessagePack response.
+func (m MsgPack) Render(w http.ResponseWriter, v interface{}) error {
+	return renderEncoded(w, m.Head, m, v)
+}
+
+// Encode writes v as MessagePack to w.
+func (m MsgPack) Encode(w io.Writer, v interface{}) (Encoded, error) {
+	return encodeBuffered(w, m, m.Head.ContentType, v)
+}
+
+// encodeBuffer writes v as MessagePack to buf.
+func (m MsgPack) encodeBuffer(buf *bytes.Buffer, v interface{}) error {
+	tree, err := jsonTree(m.Hook, v)
+	if err != nil {
+		return err
+	}
+	return writeMsgPack(buf, tree)
+}
+
+// writeMsgPack writes v in the smallest MessagePack form that holds it.
+func writeMsgPack(buf *bytes.Buffer, v interface{}) error {
+	switch t := v.(type) {
+	case nil:
+		buf.WriteByte(0xc0)
+	case bool:
+		if t {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+	case json.Number:
+		return writeMsgPackNumber(buf, t)
+	case string:
+		writeMsgPackHeader(buf, len(t), 0xa0, 32, 0xd9, 0xda, 0xdb)
+		buf.WriteString(t)
+	case []interface{}:
+		writeMsgPackHeader(buf, len(t), 0x90, 16, 0, 0xdc, 0xdd)
+		for _, item := range t {
+			if err := writeMsgPack(buf, item); err != nil {
+				return err
+			}
+		}
+	case *jsonObject:
+		writeMsgPackHeader(buf, len(t.keys), 0x80, 16, 0, 0xde, 0xdf)
+		for _, k := range t.keys {
+			writeMsgPack(buf, k)
+			if err := writeMsgPack(buf, t.vals[k]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("renderall: MsgPack cannot encode %T", v)
+	}
+	return nil
+}
+
+// writeMsgPackHeader writes the type and length of a string, array, or map:
+// the fix form below fixMax, then the 8 bit form if the type has one, then
+// the 16 and 32 bit forms.
+func writeMsgPackHeader(buf *bytes.Buffer, n int, fix byte, fixMax int, b8, b16, b32 byte) {
+	switch {
+	case n < fixMax:
+		buf.WriteByte(fix | byte(n))
+	case b8 != 0 && n <= math.MaxUint8:
+		buf.Write([]byte{b8, byte(n)})
+	case n <= math.MaxUint16:
+		buf.WriteByte(b16)
+		buf.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+	default:
+		buf.WriteByte(b32)
+		buf.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	}
+}
+
+// writeMsgPackNumber writes integers as the smallest int or uint that holds
+// them and everything else as a float 64.
+func writeMsgPackNumber(buf *bytes.Buffer, n json.Number) error {
+	if i, err := strconv.ParseInt(string(n), 10, 64); err == nil {
+		switch {
+		case i >= 0:
+			writeMsgPackUint(buf, uint64(i))
+		case i >= -32:
+			buf.WriteByte(byte(int8(i)))
+		case i >= math.MinInt8:
+			buf.Write([]byte{0xd0, byte(int8(i))})
+		case i >= math.MinInt16:
+			buf.WriteByte(0xd1)
+			buf.Write(binary.BigEndian.AppendUint16(nil, uint16(int16(i))))
+		case i >= math.MinInt32:
+			buf.WriteByte(0xd2)
+			buf.Write(binary.BigEndian.AppendUint32(nil, uint32(int32(i))))
+		default:
+			buf.WriteByte(0xd3)
+			buf.Write(binary.BigEndian.AppendUint64(nil, uint64(i)))
+		}
+		return nil
+	}
+	if u, err := strconv.ParseUint(string(n), 10, 64); err == nil {
+		writeMsgPackUint(buf, u)
+		return nil
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return err
+	}
+	buf.WriteByte(0xcb)
+	buf.Write(binary.BigEndian.AppendUint64(nil, math.Float64bits(f)))
+	return nil
+}
+
+// writeMsgPackUint writes u as a positive fixint or the smallest uint.
+func writeMsgPackUint(buf *bytes.Buffer, u uint64) {
+	switch {
+	case u <= 0x7f:
+		buf.WriteByte(byte(u))
+	case u <= math.MaxUint8:
+		buf.Write([]byte{0xcc, byte(u)})
+	case u <= math.MaxUint16:
+		buf.WriteByte(0xcd)
+		buf.Write(binary.BigEndian.AppendUint16(nil, uint16(u)))
+	case u <= math.MaxUint32:
+		buf.WriteByte(0xce)
+		buf.Write(binary.BigEndian.AppendUint32(nil, uint32(u)))
+	default:
+		buf.WriteByte(0xcf)
+		buf.Write(binary.BigEndian.AppendUint64(nil, u))
+	}
+}
+
+// MsgPack marshals the given interface object and writes the MessagePack response.
+func (r *Render) MsgPack(w http.ResponseWriter, status int, v interface{}, msgpackOpt ...MsgPackOptions) error {
+	opt := MsgPackOptions{}
+	if len(msgpackOpt) > 0 {
+		opt = msgpackOpt[0]
+	}
+
+	head := Head{
+		ContentType: r.withCharset(ContentMsgPack),
+		Status:      status,
+	}
+
+	m := MsgPack{
+		Head: head,
+		Hook: r.marshalHook(),
+	}
+	return r.render(w, opt.Request, m, v)
+}
@@ -0,0 +1,128 @@
+package renderall
+
+import (
+	"html/template"
+	"net/http"
+	"strings"
+)
+
+// Nav is a tree of navigation items for the nav and breadcrumbs template
+// funcs. Build it before serving; it is read concurrently by renders.
+type Nav struct {
+	items []*NavItem
+}
+
+// NavItem is one entry of a Nav.
+type NavItem struct {
+	Label    string
+	Path     string
+	children []*NavItem
+}
+
+// NavLink is a NavItem as seen by one render.
+type NavLink struct {
+	Label string
+	Path  string
+	// Current is set on the item for the request path.
+	Current bool
+	// Active is set on the current item and its ancestors.
+	Active   bool
+	Children []NavLink
+}
+
+// NewNav creates an empty Nav.
+func NewNav() *Nav {
+	return &Nav{}
+}
+
+// Add appends a top level item and returns it for adding children.
+func (n *Nav) Add(path, label string) *NavItem {
+	item := &NavItem{Label: label, Path: path}
+	n.items = append(n.items, item)
+	return item
+}
+
+// Add appends a child item and returns it for adding children.
+func (i *NavItem) Add(path, label string) *NavItem {
+	item := &NavItem{Label: label, Path: path}
+	i.children = append(i.children, item)
+	return item
+}
+
+// Trail returns the items from the top level down to the one for path: an
+// exact match, or failing that the deepest item whose path is a parent of it.
+func (n *Nav) Trail(path string) []*NavItem {
+	var best []*NavItem
+	bestLen := -1
+	var walk func(items []*NavItem, trail []*NavItem)
+	walk = func(items []*NavItem, trail []*NavItem) {
+		for _, item := range items {
+			t := append(trail[:len(trail):len(trail)], item)
+			if item.Path == path {
+				best, bestLen = t, len(path)+1
+				return
+			}
+			if navPrefix(item.Path, path) && len(item.Path) > bestLen {
+				best, bestLen = t, len(item.Path)
+			}
+			walk(item.children, t)
+			if bestLen > len(path) {
+				return
+			}
+		}
+	}
+	walk(n.items, nil)
+	return best
+}
+
+// navPrefix reports whether prefix is a parent path of path. The root only
+// matches itself, or it would be active everywhere.
+func navPrefix(prefix, path string) bool {
+	if prefix == "" || prefix == "/" {
+		return false
+	}
+	prefix = strings.TrimSuffix(prefix, "/")
+	return strings.HasPrefix(path, prefix+"/")
+}
+
+// navLinks converts items for a render, marking those on trail.
+func navLinks(items []*NavItem, trail []*NavItem) []NavLink {
+	links := make([]NavLink, 0, len(items))
+	for _, item := range items {
+		link := NavLink{Label: item.Label, Path: item.Path}
+		for i, t := range trail {
+			if t == item {
+				link.Active = true
+				link.Current = i == len(trail)-1
+			}
+		}
+		if len(item.children) > 0 {
+			link.Children = navLinks(item.children, trail)
+		}
+		links = append(links, link)
+	}
+	return links
+}
+
+// navFuncs expose Options.Nav to templates, marked for the request path.
+func (r *Render) navFuncs(req *http.Request) template.FuncMap {
+	var trail []*NavItem
+	if r.opt.Nav != nil && req != nil {
+		trail = r.opt.Nav.Trail(req.URL.Path)
+	}
+	return template.FuncMap{
+		"nav": func() []NavLink {
+			if r.opt.Nav == nil {
+				return nil
+			}
+			return navLinks(r.opt.Nav.items, trail)
+		},
+		"breadcrumbs": func() []NavLink {
+			crumbs := make([]NavLink, len(trail))
+			for i, item := range trail {
+				crumbs[i] = NavLink{Label: item.Label, Path: item.Path, Active: true, Current: i == len(trail)-1}
+			}
+			return crumbs
+		},
+	}
+}
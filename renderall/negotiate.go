@@ -0,0 +1,111 @@
+package renderall
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// mediaRange is one entry of an Accept header.
+type mediaRange struct {
+	typ, sub string
+	q        float64
+}
+
+// parseAccept parses an Accept header. Malformed entries are skipped.
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		mt, params, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+		slash := strings.IndexByte(mt, '/')
+		if slash < 0 {
+			if mt != "*" {
+				continue
+			}
+			mt, slash = "*/*", 1
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		ranges = append(ranges, mediaRange{typ: mt[:slash], sub: mt[slash+1:], q: q})
+	}
+	return ranges
+}
+
+// quality returns the q value the ranges give contentType, using the most
+// specific matching range, and the specificity of that match (0 for no match).
+func quality(ranges []mediaRange, contentType string) (float64, int) {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return 0, 0
+	}
+	slash := strings.IndexByte(mt, '/')
+	if slash < 0 {
+		return 0, 0
+	}
+	typ, sub := mt[:slash], mt[slash+1:]
+
+	best, specificity := 0.0, 0
+	for _, r := range ranges {
+		s := 0
+		switch {
+		case r.typ == typ && r.sub == sub:
+			s = 3
+		case r.typ == typ && r.sub == "*":
+			s = 2
+		case r.typ == "*" && r.sub == "*":
+			s = 1
+		}
+		if s > specificity {
+			best, specificity = r.q, s
+		}
+	}
+	return best, specificity
+}
+
+// Negotiate returns the offer the Accept header prefers, or blank if none is
+// acceptable. Ties go to the earlier offer, and a missing Accept header
+// accepts the first offer.
+func Negotiate(accept string, offers ...string) string {
+	if len(offers) == 0 {
+		return ""
+	}
+	if strings.TrimSpace(accept) == "" {
+		return offers[0]
+	}
+
+	ranges := parseAccept(accept)
+	best, bestQ, bestS := "", 0.0, 0
+	for _, offer := range offers {
+		q, s := quality(ranges, offer)
+		if q <= 0 {
+			continue
+		}
+		if q > bestQ || (q == bestQ && s > bestS) {
+			best, bestQ, bestS = offer, q, s
+		}
+	}
+	return best
+}
+
+// prefersHTML reports whether req explicitly asks for HTML over JSON, as
+// browsers do. Wildcard-only clients such as curl do not count.
+func prefersHTML(req *http.Request) bool {
+	if req == nil {
+		return false
+	}
+	ranges := parseAccept(req.Header.Get("Accept"))
+	q, s := quality(ranges, ContentHTML)
+	return s == 3 && q > 0 && Negotiate(req.Header.Get("Accept"), ContentHTML, ContentJSON) == ContentHTML
+}
@@ -0,0 +1,181 @@
+package renderall
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// NormalizeJSON rewrites a JSON document for comparison in tests: object
+// keys sorted, two space indentation, no HTML escaping, and the values at
+// the volatile JSONPath expressions, such as IDs and timestamps, replaced
+// with RedactedValue. Paths use a subset of JSONPath: $ for the root,
+// .name or ['name'] for members, [n] for elements (negative counts from the
+// end), * for every member or element, and ..name to match at any depth,
+// e.g. "$.data.id", "$.items[*].created_at", or "$..updated_at". Paths that
+// match nothing are ignored.
+func NormalizeJSON(b []byte, volatile ...string) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("renderall: trailing data after JSON value")
+	}
+
+	for _, p := range volatile {
+		steps, err := parseJSONPath(p)
+		if err != nil {
+			return nil, err
+		}
+		v = redactPath(v, steps, RedactedValue)
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// pathStep is one step of a parsed JSONPath.
+type pathStep struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+	// recursive matches the step at any depth below the current node.
+	recursive bool
+}
+
+// parseJSONPath parses the JSONPath subset NormalizeJSON supports.
+func parseJSONPath(p string) ([]pathStep, error) {
+	invalid := func() ([]pathStep, error) {
+		return nil, fmt.Errorf("renderall: invalid JSONPath %q", p)
+	}
+	if !strings.HasPrefix(p, "$") {
+		return invalid()
+	}
+	s := p[1:]
+	var steps []pathStep
+	for s != "" {
+		var step pathStep
+		switch {
+		case strings.HasPrefix(s, ".."):
+			step.recursive = true
+			s = s[2:]
+			if strings.HasPrefix(s, "[") {
+				break
+			}
+			fallthrough
+		case s[0] == '.':
+			s = strings.TrimPrefix(s, ".")
+			end := strings.IndexAny(s, ".[")
+			if end == -1 {
+				end = len(s)
+			}
+			if end == 0 {
+				return invalid()
+			}
+			step.key, s = s[:end], s[end:]
+			step.wildcard = step.key == "*"
+			steps = append(steps, step)
+			continue
+		case s[0] != '[':
+			return invalid()
+		}
+
+		end := strings.IndexByte(s, ']')
+		if end == -1 {
+			return invalid()
+		}
+		sel := s[1:end]
+		s = s[end+1:]
+		switch {
+		case sel == "*":
+			step.wildcard = true
+		case len(sel) >= 2 && (sel[0] == '\'' || sel[0] == '"') && sel[len(sel)-1] == sel[0]:
+			step.key = sel[1 : len(sel)-1]
+		default:
+			n, err := strconv.Atoi(sel)
+			if err != nil {
+				return invalid()
+			}
+			step.index, step.isIndex = n, true
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// redactPath returns v with the values steps match replaced by mask. Maps,
+// objects, and slices are modified in place.
+func redactPath(v interface{}, steps []pathStep, mask interface{}) interface{} {
+	if len(steps) == 0 {
+		return mask
+	}
+	step, rest := steps[0], steps[1:]
+
+	if step.recursive {
+		// Match below this node first, so a match here cannot hide one deeper.
+		switch t := v.(type) {
+		case map[string]interface{}:
+			for k, c := range t {
+				t[k] = redactPath(c, steps, mask)
+			}
+		case *jsonObject:
+			for k, c := range t.vals {
+				t.vals[k] = redactPath(c, steps, mask)
+			}
+		case []interface{}:
+			for i, c := range t {
+				t[i] = redactPath(c, steps, mask)
+			}
+		}
+	}
+
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if step.isIndex {
+			return v
+		}
+		for k, c := range t {
+			if step.wildcard || k == step.key {
+				t[k] = redactPath(c, rest, mask)
+			}
+		}
+	case *jsonObject:
+		if step.isIndex {
+			return v
+		}
+		for k, c := range t.vals {
+			if step.wildcard || k == step.key {
+				t.vals[k] = redactPath(c, rest, mask)
+			}
+		}
+	case []interface{}:
+		switch {
+		case step.wildcard:
+			for i, c := range t {
+				t[i] = redactPath(c, rest, mask)
+			}
+		case step.isIndex:
+			i := step.index
+			if i < 0 {
+				i += len(t)
+			}
+			if i >= 0 && i < len(t) {
+				t[i] = redactPath(t[i], rest, mask)
+			}
+		}
+	}
+	return v
+}
@@ -0,0 +1,47 @@
+package renderall
+
+import "testing"
+
+func TestNormalizeJSON(t *testing.T) {
+	tests := []struct {
+		in       string
+		volatile []string
+		want     string
+	}{
+		{`{"b":1,"a":"<x>"}`, nil, "{\n  \"a\": \"<x>\",\n  \"b\": 1\n}\n"},
+		{`[1.50, 2]`, nil, "[\n  1.50,\n  2\n]\n"},
+		{`{"id":7,"data":{"id":8}}`, []string{"$.id"}, "{\n  \"data\": {\n    \"id\": 8\n  },\n  \"id\": \"[REDACTED]\"\n}\n"},
+		{`{"id":7,"data":{"id":8}}`, []string{"$..id"}, "{\n  \"data\": {\n    \"id\": \"[REDACTED]\"\n  },\n  \"id\": \"[REDACTED]\"\n}\n"},
+		{`{"items":[{"t":1},{"t":2}]}`, []string{"$.items[*].t"}, "{\n  \"items\": [\n    {\n      \"t\": \"[REDACTED]\"\n    },\n    {\n      \"t\": \"[REDACTED]\"\n    }\n  ]\n}\n"},
+		{`{"items":[1,2,3]}`, []string{"$.items[-1]"}, "{\n  \"items\": [\n    1,\n    2,\n    \"[REDACTED]\"\n  ]\n}\n"},
+		{`{"a b":1}`, []string{"$['a b']"}, "{\n  \"a b\": \"[REDACTED]\"\n}\n"},
+		{`{"a":1}`, []string{"$.missing", "$[3]"}, "{\n  \"a\": 1\n}\n"},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeJSON([]byte(tt.in), tt.volatile...)
+		if err != nil {
+			t.Errorf("NormalizeJSON(%s, %q): %v", tt.in, tt.volatile, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("NormalizeJSON(%s, %q) =\n%s\nwant\n%s", tt.in, tt.volatile, got, tt.want)
+		}
+	}
+
+	errors := []struct {
+		in       string
+		volatile []string
+	}{
+		{`{"a":1} {}`, nil},
+		{`{"a":`, nil},
+		{`{}`, []string{"a"}},
+		{`{}`, []string{"$."}},
+		{`{}`, []string{"$[x]"}},
+		{`{}`, []string{"$[1"}},
+	}
+	for _, tt := range errors {
+		if _, err := NormalizeJSON([]byte(tt.in), tt.volatile...); err == nil {
+			t.Errorf("NormalizeJSON(%s, %q) succeeded", tt.in, tt.volatile)
+		}
+	}
+}
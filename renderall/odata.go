@@ -0,0 +1,131 @@
+package renderall
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ContentOData header value for OData JSON with minimal metadata.
+	ContentOData = "application/json;odata.metadata=minimal"
+	// ODataVersionHeader header constant.
+	ODataVersionHeader = "OData-Version"
+)
+
+// ODataOptions is a struct for overriding some rendering Options for specific OData call.
+type ODataOptions struct {
+	// Context URL sent as @odata.context, e.g. "https://api.example.com/odata/$metadata#Products". Omitted if blank.
+	Context string
+	// Sends @odata.count even when the request did not ask for it with $count=true. Default is false.
+	Count bool
+	// DeltaLink sent as @odata.deltaLink, for change tracking. Omitted if blank.
+	DeltaLink string
+}
+
+// ODataCollection is an OData collection response body.
+type ODataCollection struct {
+	Context string
+	// Count is the total number of matches across all pages, if known.
+	Count     *int
+	Value     interface{}
+	NextLink  string
+	DeltaLink string
+}
+
+// MarshalJSON writes the annotations around the value array: context and
+// count ahead of it, as streaming consumers expect, and the links after.
+func (c ODataCollection) MarshalJSON() ([]byte, error) {
+	value := c.Value
+	if value == nil {
+		value = []interface{}{}
+	}
+	members := []struct {
+		name string
+		v    interface{}
+		skip bool
+	}{
+		{"@odata.context", c.Context, c.Context == ""},
+		{"@odata.count", c.Count, c.Count == nil},
+		{"value", value, false},
+		{"@odata.nextLink", c.NextLink, c.NextLink == ""},
+		{"@odata.deltaLink", c.DeltaLink, c.DeltaLink == ""},
+	}
+	obj := newJSONMembers()
+	for _, m := range members {
+		if m.skip {
+			continue
+		}
+		if err := obj.add(m.name, m.v); err != nil {
+			return nil, err
+		}
+	}
+	return obj.close(), nil
+}
+
+// ODataPage builds the OData collection for page p of the collection req
+// queried. The count is included when req asks for it with $count=true.
+// The next link pages on with $skiptoken set to p.NextCursor for cursor
+// pages, and with $skip and $top otherwise, while items remain: before
+// Total, or, when it is unknown, while pages come back full.
+func ODataPage(req *http.Request, p Page, odataOpt ...ODataOptions) ODataCollection {
+	opt := ODataOptions{}
+	if len(odataOpt) > 0 {
+		opt = odataOpt[0]
+	}
+
+	c := ODataCollection{Context: opt.Context, Value: p.Items, DeltaLink: opt.DeltaLink}
+	if p.Total >= 0 && (opt.Count || (req != nil && strings.EqualFold(req.URL.Query().Get("$count"), "true"))) {
+		total := p.Total
+		c.Count = &total
+	}
+	if req == nil {
+		return c
+	}
+	if p.cursored() {
+		if p.NextCursor != "" {
+			set := url.Values{"$skiptoken": {p.NextCursor}}
+			if p.Limit > 0 {
+				set["$top"] = []string{strconv.Itoa(p.Limit)}
+			}
+			c.NextLink = pageURL(req, set, "$skip")
+		}
+		return c
+	}
+	if p.Limit <= 0 {
+		return c
+	}
+	more := p.Offset+p.Limit < p.Total
+	if p.Total < 0 {
+		n, ok := p.count()
+		more = !ok || n >= p.Limit
+	}
+	if more {
+		c.NextLink = pageURL(req, url.Values{
+			"$skip": {strconv.Itoa(p.Offset + p.Limit)},
+			"$top":  {strconv.Itoa(p.Limit)},
+		}, "$skiptoken")
+	}
+	return c
+}
+
+// OData built-in renderer. It is JSON with the OData-Version header, for
+// ODataCollection and single entity bodies alike.
+type OData struct {
+	JSON
+}
+
+// Render an OData response.
+func (o OData) Render(w http.ResponseWriter, v interface{}) error {
+	w.Header().Set(ODataVersionHeader, "4.0")
+	return o.JSON.Render(w, v)
+}
+
+// OData writes page p of the collection req queried as an OData response,
+// see ODataPage.
+func (r *Render) OData(w http.ResponseWriter, req *http.Request, status int, p Page, odataOpt ...ODataOptions) error {
+	j := r.protocolJSON(status, req)
+	j.Head.ContentType = r.withCharset(ContentOData)
+	return r.render(w, req, OData{JSON: j}, ODataPage(req, p, odataOpt...))
+}
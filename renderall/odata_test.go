@@ -0,0 +1,47 @@
+package renderall
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestODataPage(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		p      Page
+		next   string
+		count  int
+	}{
+		{"offset", "/Products?$top=2&$count=true", Page{Items: []int{1, 2}, Total: 5, Limit: 2}, "http://example.com/Products?%24count=true&%24skip=2&%24top=2", 5},
+		{"last offset page", "/Products?$skip=4&$top=2", Page{Items: []int{5}, Total: 5, Limit: 2, Offset: 4}, "", -1},
+		{"unknown total, full page", "/Products", Page{Items: []int{1, 2}, Total: -1, Limit: 2}, "http://example.com/Products?%24skip=2&%24top=2", -1},
+		{"unknown total, short page", "/Products", Page{Items: []int{1}, Total: -1, Limit: 2}, "", -1},
+		{"cursor", "/Products?$skip=9&$skiptoken=a", Page{Items: []int{1, 2}, Total: -1, Limit: 2, Cursor: "a", NextCursor: "b"}, "http://example.com/Products?%24skiptoken=b&%24top=2", -1},
+		{"last cursor page", "/Products?$skiptoken=b", Page{Items: []int{1, 2}, Total: -1, Limit: 2, Cursor: "b", PrevCursor: "a"}, "", -1},
+	}
+	for _, tt := range tests {
+		c := ODataPage(httptest.NewRequest("GET", tt.target, nil), tt.p)
+		if c.NextLink != tt.next {
+			t.Errorf("%s: nextLink %q, want %q", tt.name, c.NextLink, tt.next)
+		}
+		if (c.Count == nil) != (tt.count < 0) || c.Count != nil && *c.Count != tt.count {
+			t.Errorf("%s: count %v, want %d", tt.name, c.Count, tt.count)
+		}
+	}
+}
+
+func TestODataPrefix(t *testing.T) {
+	r := New(Options{PrefixJSON: []byte(")]}',\n")})
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/Products?$count=true", nil)
+	if err := r.OData(w, req, 200, Page{Items: []int{1}, Total: 1, Limit: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := w.Body.String(), `{"@odata.count":1,"value":[1]}`; got != want {
+		t.Errorf("body = %s, want %s", got, want)
+	}
+	if v := w.Header().Get(ODataVersionHeader); v != "4.0" {
+		t.Errorf("%s = %q", ODataVersionHeader, v)
+	}
+}
@@ -0,0 +1,77 @@
+package renderall
+
+import (
+	"net/http"
+	"strings"
+)
+
+// AllowHeader header constant.
+const AllowHeader = "Allow"
+
+// MethodDescription describes one of a resource's methods in the JSON body
+// of DescribeOptions.
+type MethodDescription struct {
+	Method      string `json:"method"`
+	Description string `json:"description,omitempty"`
+	// Schema of the request body, e.g. a JSON Schema document. Defaults to nil.
+	Schema interface{} `json:"schema,omitempty"`
+}
+
+// allowValue joins methods for the Allow header, upper cased and without
+// duplicates. HEAD follows GET and OPTIONS is always last, as both are
+// implied by any resource that answers OPTIONS with a GET.
+func allowValue(methods []string) string {
+	seen := map[string]bool{http.MethodOptions: true}
+	allow := make([]string, 0, len(methods)+2)
+	add := func(m string) {
+		if !seen[m] {
+			seen[m] = true
+			allow = append(allow, m)
+		}
+	}
+	for _, m := range methods {
+		m = strings.ToUpper(strings.TrimSpace(m))
+		if m == "" {
+			continue
+		}
+		add(m)
+		if m == http.MethodGet {
+			add(http.MethodHead)
+		}
+	}
+	return strings.Join(append(allow, http.MethodOptions), ", ")
+}
+
+// Options answers an OPTIONS request with 204 No Content and an Allow
+// header listing allowedMethods, HEAD with GET, and OPTIONS.
+func (r *Render) Options(w http.ResponseWriter, allowedMethods ...string) error {
+	w.Header().Set(AllowHeader, allowValue(allowedMethods))
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// DescribeOptions answers an OPTIONS request like Options, but clients that
+// ask for JSON explicitly get a 200 describing the methods as
+// {"methods": [...]}.
+func (r *Render) DescribeOptions(w http.ResponseWriter, req *http.Request, methods ...MethodDescription) error {
+	names := make([]string, len(methods))
+	for i, m := range methods {
+		names[i] = m.Method
+	}
+	if req == nil {
+		return r.Options(w, names...)
+	}
+	accept := req.Header.Get("Accept")
+	if _, s := quality(parseAccept(accept), ContentJSON); s != 3 || Negotiate(accept, ContentJSON, ContentHTML) != ContentJSON {
+		return r.Options(w, names...)
+	}
+
+	w.Header().Set(AllowHeader, allowValue(names))
+	if methods == nil {
+		methods = []MethodDescription{}
+	}
+	body := struct {
+		Methods []MethodDescription `json:"methods"`
+	}{methods}
+	return r.JSON(w, http.StatusOK, body, JSONOptions{Request: req})
+}
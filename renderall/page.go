@@ -0,0 +1,151 @@
+package renderall
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Page describes one page of a paginated collection. Offset pagination is used
+// unless NextCursor or PrevCursor is set.
+type Page struct {
+	// Items on this page.
+	Items interface{}
+	// Total number of items in the collection. Negative if unknown.
+	Total int
+	// Limit is the page size.
+	Limit int
+	// Offset of the first item on this page.
+	Offset int
+	// Cursor identifying this page, for cursor pagination.
+	Cursor string
+	// NextCursor identifies the following page. Blank if this is the last page.
+	NextCursor string
+	// PrevCursor identifies the preceding page. Blank if this is the first page.
+	PrevCursor string
+}
+
+// PageMeta is the pagination block placed in the envelope meta.
+type PageMeta struct {
+	Total      *int   `json:"total,omitempty"`
+	Limit      int    `json:"limit"`
+	Offset     *int   `json:"offset,omitempty"`
+	Cursor     string `json:"cursor,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+func (p Page) cursored() bool {
+	return p.NextCursor != "" || p.PrevCursor != ""
+}
+
+// count returns the number of Items, if they are a slice or array.
+func (p Page) count() (int, bool) {
+	v := reflect.ValueOf(p.Items)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return 0, false
+	}
+	return v.Len(), true
+}
+
+// Meta returns the pagination metadata for the page.
+func (p Page) Meta() PageMeta {
+	m := PageMeta{Limit: p.Limit}
+	if p.Total >= 0 {
+		total := p.Total
+		m.Total = &total
+	}
+	if p.cursored() {
+		m.Cursor = p.Cursor
+		m.NextCursor = p.NextCursor
+		m.PrevCursor = p.PrevCursor
+	} else {
+		offset := p.Offset
+		m.Offset = &offset
+	}
+	return m
+}
+
+// Links returns the RFC 8288 link relations for the page, relative to the request URL.
+func (p Page) Links(req *http.Request) map[string]string {
+	links := map[string]string{}
+	if p.cursored() {
+		if p.NextCursor != "" {
+			links["next"] = pageURL(req, url.Values{"cursor": {p.NextCursor}, "limit": {strconv.Itoa(p.Limit)}}, "offset")
+		}
+		if p.PrevCursor != "" {
+			links["prev"] = pageURL(req, url.Values{"cursor": {p.PrevCursor}, "limit": {strconv.Itoa(p.Limit)}}, "offset")
+		}
+		return links
+	}
+	if p.Limit <= 0 {
+		return links
+	}
+
+	offsetURL := func(offset int) string {
+		return pageURL(req, url.Values{"offset": {strconv.Itoa(offset)}, "limit": {strconv.Itoa(p.Limit)}}, "cursor")
+	}
+	if p.Total < 0 || p.Offset+p.Limit < p.Total {
+		links["next"] = offsetURL(p.Offset + p.Limit)
+	}
+	if p.Offset > 0 {
+		prev := p.Offset - p.Limit
+		if prev < 0 {
+			prev = 0
+		}
+		links["prev"] = offsetURL(prev)
+		links["first"] = offsetURL(0)
+	}
+	if p.Total > 0 && p.Offset+p.Limit < p.Total {
+		links["last"] = offsetURL((p.Total - 1) / p.Limit * p.Limit)
+	}
+	return links
+}
+
+// pageURL rebuilds the absolute request URL with the given query values set and drop removed.
+func pageURL(req *http.Request, set url.Values, drop string) string {
+	u := *req.URL
+	if u.Host == "" {
+		u.Host = req.Host
+	}
+	if u.Scheme == "" {
+		u.Scheme = "http"
+		if req.TLS != nil {
+			u.Scheme = "https"
+		}
+	}
+	q := u.Query()
+	q.Del(drop)
+	for k, v := range set {
+		q[k] = v
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// linkHeader formats link relations as an RFC 8288 Link header value.
+func linkHeader(links map[string]string) string {
+	var parts []string
+	for _, rel := range []string{"first", "prev", "next", "last"} {
+		if href, ok := links[rel]; ok {
+			parts = append(parts, "<"+href+`>; rel="`+rel+`"`)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Paginate writes the page items as an enveloped JSON response, with the
+// pagination metadata in the envelope meta and next/prev Link headers.
+func (r *Render) Paginate(w http.ResponseWriter, req *http.Request, status int, p Page) error {
+	if link := linkHeader(p.Links(req)); link != "" {
+		w.Header().Add("Link", link)
+	}
+
+	return r.JSON(w, status, p.Items, JSONOptions{
+		Envelope: true,
+		Request:  req,
+		Meta:     map[string]interface{}{"pagination": p.Meta()},
+	})
+}
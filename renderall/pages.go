@@ -0,0 +1,150 @@
+package renderall
+
+import (
+	"html/template"
+	"net/http"
+	"strings"
+)
+
+// Names of the built-in error page templates. Each is compiled into every
+// template set below the user's own templates, so a file with the same name,
+// e.g. templates/errors/404.tmpl, replaces it.
+const (
+	NotFoundTemplate      = "errors/404"
+	InternalErrorTemplate = "errors/500"
+	MaintenanceTemplate   = "errors/maintenance"
+)
+
+const errorPageTemplate = `<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{ .Status }} {{ .Title }}</title></head>
+<body>
+<h1>{{ .Title }}</h1>
+{{ if .Message }}<p>{{ .Message }}</p>{{ end }}
+{{ if .Stack }}<pre>{{ .Stack }}</pre>{{ end }}
+{{ if .RequestID }}<p><small>Request ID: {{ .RequestID }}</small></p>{{ end }}
+</body>
+</html>
+`
+
+var builtinTemplates = map[string]string{
+	NotFoundTemplate:      errorPageTemplate,
+	InternalErrorTemplate: errorPageTemplate,
+	MaintenanceTemplate:   errorPageTemplate,
+}
+
+// ErrorPage is the binding the built-in error page templates render.
+type ErrorPage struct {
+	Status  int
+	Title   string
+	Message string
+	// Stack is the stack trace of a recovered panic, set in development mode.
+	Stack string
+	// RequestID correlates the page with logs, see Options.RequestIDHeader.
+	RequestID string
+	// Data is the binding a caller passed to Status, for the built-in pages.
+	Data interface{}
+}
+
+// errorPage returns the ErrorPage binding for status, with req's request ID.
+func (r *Render) errorPage(req *http.Request, status int) ErrorPage {
+	return ErrorPage{Status: status, Title: http.StatusText(status), RequestID: r.RequestID(req)}
+}
+
+// addBuiltinTemplates adds the built-in templates to set, written with the
+// configured delimiters.
+func (r *Render) addBuiltinTemplates(set *template.Template) error {
+	left, right := r.opt.Delims.Left, r.opt.Delims.Right
+	if left == "" {
+		left = "{{"
+	}
+	if right == "" {
+		right = "}}"
+	}
+	delims := strings.NewReplacer("{{", left, "}}", right)
+
+	for name, text := range builtinTemplates {
+		if err := r.parseTemplate(set, name, []byte(delims.Replace(text))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ErrorPage renders the named error page template, without a layout, for
+// status. The Title is the status text.
+func (r *Render) ErrorPage(w http.ResponseWriter, req *http.Request, status int, name string) error {
+	page := r.errorPage(req, status)
+	return r.HTML(w, status, name, page, HTMLOptions{NoLayout: true, Request: req})
+}
+
+// htmlFail reports a failed HTML render with the internal error page,
+// falling back to a plain-text error if that fails too.
+func (r *Render) htmlFail(w http.ResponseWriter, req *http.Request, err error) error {
+	if err == nil || r.opt.DisableHTTPErrorRendering || headerWritten(w) {
+		return err
+	}
+
+	tmpl, cerr := r.cloneTemplates(r.theme(HTMLOptions{Request: req}))
+	if cerr == nil {
+		page := r.errorPage(req, http.StatusInternalServerError)
+		buf, cerr := execute(tmpl, InternalErrorTemplate, page)
+		if cerr == nil {
+			w.Header().Set(ContentType, r.withCharset(r.opt.HTMLContentType))
+			w.WriteHeader(http.StatusInternalServerError)
+			buf.WriteTo(w)
+			return err
+		}
+	}
+	return r.fail(w, err)
+}
+
+// builtinErrorTemplates maps statuses to the built-in error pages. Other
+// statuses use InternalErrorTemplate, which titles itself by status.
+var builtinErrorTemplates = map[int]string{
+	http.StatusNotFound:            NotFoundTemplate,
+	http.StatusInternalServerError: InternalErrorTemplate,
+	http.StatusServiceUnavailable:  MaintenanceTemplate,
+}
+
+// Status renders an error response for status. Requests preferring HTML get
+// the template Options.ErrorTemplates maps status to rendered with data, or
+// a built-in error page without a layout, given an ErrorPage holding data.
+// Everything else gets the standard JSON error body, with data as its
+// details.
+func (r *Render) Status(w http.ResponseWriter, req *http.Request, status int, data interface{}) error {
+	if !prefersHTML(req) {
+		var details []interface{}
+		if data != nil {
+			details = []interface{}{data}
+		}
+		return r.errorBody(w, req, newAPIError(status, "", http.StatusText(status), details))
+	}
+	return r.statusHTML(w, req, status, data)
+}
+
+// StatusPage renders the HTML error page for status, whatever the request
+// prefers: the template Options.ErrorTemplates maps status to, or a built-in
+// error page without a layout, given an ErrorPage titled with the status's
+// reason phrase. It spares handlers building the binding themselves.
+func (r *Render) StatusPage(w http.ResponseWriter, req *http.Request, status int) error {
+	return r.statusHTML(w, req, status, r.errorPage(req, status))
+}
+
+// statusHTML is the HTML half of Status.
+func (r *Render) statusHTML(w http.ResponseWriter, req *http.Request, status int, data interface{}) error {
+	if name, ok := r.opt.ErrorTemplates[status]; ok {
+		return r.HTML(w, status, name, data, HTMLOptions{Request: req})
+	}
+	name, ok := builtinErrorTemplates[status]
+	if !ok {
+		name = InternalErrorTemplate
+	}
+	// The built-in pages need an ErrorPage; other bindings ride along in it.
+	page, ok := data.(ErrorPage)
+	if !ok {
+		page = r.errorPage(req, status)
+		page.Data = data
+	}
+	return r.HTML(w, status, name, page, HTMLOptions{NoLayout: true, Request: req})
+}
@@ -0,0 +1,137 @@
+package renderall
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"strconv"
+)
+
+// ContentPDF header value for PDF documents.
+const ContentPDF = "application/pdf"
+
+// PDFConverter turns rendered HTML into a PDF, e.g. by driving headless
+// Chrome through chromedp. See WKHTMLToPDF for a converter using the
+// wkhtmltopdf binary.
+type PDFConverter interface {
+	ConvertPDF(ctx context.Context, html []byte, w io.Writer) error
+}
+
+// PDFConverterFunc adapts an ordinary function to a PDFConverter.
+type PDFConverterFunc func(ctx context.Context, html []byte, w io.Writer) error
+
+// ConvertPDF calls f(ctx, html, w).
+func (f PDFConverterFunc) ConvertPDF(ctx context.Context, html []byte, w io.Writer) error {
+	return f(ctx, html, w)
+}
+
+// WKHTMLToPDF returns a PDFConverter that pipes the HTML through the
+// wkhtmltopdf binary at path, with any extra args such as "--page-size A4".
+// Relative asset URLs in the HTML can't be resolved, so use absolute ones.
+func WKHTMLToPDF(path string, args ...string) PDFConverter {
+	return PDFConverterFunc(func(ctx context.Context, html []byte, w io.Writer) error {
+		var stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, path, append(append([]string{"--quiet"}, args...), "-", "-")...)
+		cmd.Stdin = bytes.NewReader(html)
+		cmd.Stdout = w
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			if msg := bytes.TrimSpace(stderr.Bytes()); len(msg) > 0 {
+				return fmt.Errorf("renderall: wkhtmltopdf: %v: %s", err, msg)
+			}
+			return fmt.Errorf("renderall: wkhtmltopdf: %v", err)
+		}
+		return nil
+	})
+}
+
+// PDFOptions is a struct for overriding some rendering Options for specific PDF call.
+type PDFOptions struct {
+	// Layout template name. Overrides Options.Layout when not blank.
+	Layout string
+	// Renders without any layout, even if Options.Layout is set.
+	NoLayout bool
+	// Request being served, used by request-scoped template funcs and to cancel conversion. Defaults to nil.
+	Request *http.Request
+	// Download file name for the Content-Disposition header. Defaults to "document.pdf".
+	Filename string
+}
+
+// PDF built-in renderer. It executes an HTML template and converts the
+// result, buffering the PDF so it is sent with a Content-Length.
+type PDF struct {
+	Head
+	Page      HTML
+	Converter PDFConverter
+	Context   context.Context
+	// Filename, if set, offers the PDF as a download once it has converted.
+	Filename string
+}
+
+// Render a PDF response.
+func (p PDF) Render(w http.ResponseWriter, binding interface{}) error {
+	page := bufPool.Get()
+	defer bufPool.Put(page)
+	if err := p.Page.Templates.ExecuteTemplate(page, p.Page.Name, binding); err != nil {
+		return err
+	}
+
+	ctx := p.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	out := bufPool.Get()
+	defer bufPool.Put(out)
+	if err := p.Converter.ConvertPDF(ctx, page.Bytes(), out); err != nil {
+		return err
+	}
+
+	setAttachment(w.Header(), p.Filename)
+	w.Header().Set(ContentLength, strconv.Itoa(out.Len()))
+	p.Head.Write(w)
+	out.WriteTo(w)
+	return nil
+}
+
+// PDF renders the named template with the same layouts and funcs as pages
+// and sends it as a PDF download, converted by Options.PDFConverter.
+func (r *Render) PDF(w http.ResponseWriter, status int, name string, binding interface{}, pdfOpt ...PDFOptions) error {
+	opt := PDFOptions{}
+	if len(pdfOpt) > 0 {
+		opt = pdfOpt[0]
+	}
+	if opt.Filename == "" {
+		opt.Filename = "document.pdf"
+	}
+	if r.opt.PDFConverter == nil {
+		return r.fail(w, fmt.Errorf("renderall: PDF requires Options.PDFConverter"))
+	}
+
+	htmlOpt := HTMLOptions{Layout: opt.Layout, NoLayout: opt.NoLayout, Request: opt.Request}
+	page, binding, _, err := r.prepareHTML(w, status, name, binding, nil, []HTMLOptions{htmlOpt})
+	if err != nil {
+		return r.fail(w, err)
+	}
+	// The converter has no use for the live reload script.
+	page.Inject = nil
+
+	head := Head{
+		ContentType: ContentPDF,
+		Status:      status,
+	}
+
+	p := PDF{
+		Head:      head,
+		Page:      page,
+		Converter: r.opt.PDFConverter,
+		Filename:  opt.Filename,
+	}
+	if opt.Request != nil {
+		p.Context = opt.Request.Context()
+	}
+
+	return r.render(w, opt.Request, p, binding)
+}
@@ -0,0 +1,220 @@
+package renderall
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// PIIDetector finds sensitive text in s, returning the start and end of each
+// match like regexp's FindAllStringIndex.
+type PIIDetector func(s string) [][]int
+
+// RegexpDetector is a PIIDetector matching re.
+func RegexpDetector(re *regexp.Regexp) PIIDetector {
+	return func(s string) [][]int {
+		return re.FindAllStringIndex(s, -1)
+	}
+}
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`)
+	cardPattern  = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+)
+
+// EmailDetector matches email addresses.
+var EmailDetector = RegexpDetector(emailPattern)
+
+// CardDetector matches payment card numbers: 13 to 19 digits, optionally
+// grouped by spaces or dashes, that pass the Luhn check.
+func CardDetector(s string) [][]int {
+	var out [][]int
+	for _, loc := range cardPattern.FindAllStringIndex(s, -1) {
+		if luhn(s[loc[0]:loc[1]]) {
+			out = append(out, loc)
+		}
+	}
+	return out
+}
+
+// luhn reports whether the digits in s have a valid Luhn check digit.
+func luhn(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] < '0' || s[i] > '9' {
+			continue
+		}
+		d := int(s[i] - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// PIIMask configures MaskPII.
+type PIIMask struct {
+	// Detectors find sensitive text in JSON strings and text bodies. Defaults
+	// to EmailDetector and CardDetector; an empty non-nil slice disables them.
+	Detectors []PIIDetector
+	// JSONPath expressions, in the subset NormalizeJSON supports, whose values are masked whole in JSON bodies, e.g. "$..ssn" or "$.users[*].phone". Defaults to [].
+	Paths []string
+	// Replaces each match. Defaults to RedactedValue.
+	Mask string
+}
+
+// MaskPII returns a PostRender hook masking personal data in JSON, text,
+// and XML responses, for Render instances whose output leaves the trust
+// boundary, e.g. the one behind support tooling:
+//
+//	support := renderall.New(renderall.Options{
+//		PostRender: []func(*renderall.RenderContext) error{
+//			renderall.MaskPII(renderall.PIIMask{Paths: []string{"$..ssn"}}),
+//		},
+//	})
+//
+// JSON is decoded and re-encoded, keeping its key order and indentation
+// style; a number a detector matches becomes the mask string. Text and XML
+// are masked in place. Other bodies pass through untouched.
+func MaskPII(m PIIMask) func(*RenderContext) error {
+	if m.Detectors == nil {
+		m.Detectors = []PIIDetector{EmailDetector, CardDetector}
+	}
+	if m.Mask == "" {
+		m.Mask = RedactedValue
+	}
+	paths := make([][]pathStep, 0, len(m.Paths))
+	for _, p := range m.Paths {
+		steps, err := parseJSONPath(p)
+		if err != nil {
+			return func(*RenderContext) error { return err }
+		}
+		paths = append(paths, steps)
+	}
+
+	return func(ctx *RenderContext) error {
+		media := ctx.Header.Get(ContentType)
+		if i := strings.IndexByte(media, ';'); i >= 0 {
+			media = media[:i]
+		}
+		media = strings.ToLower(strings.TrimSpace(media))
+
+		switch {
+		case media == ContentJSON || strings.HasSuffix(media, "+json"):
+			body, err := m.maskJSON(ctx, paths)
+			if err != nil {
+				return err
+			}
+			ctx.Body = body
+		case strings.HasPrefix(media, "text/") || media == "application/xml" || strings.HasSuffix(media, "+xml"):
+			ctx.Body = []byte(m.maskText(string(ctx.Body)))
+		}
+		return nil
+	}
+}
+
+// maskText replaces every detector match in s.
+func (m PIIMask) maskText(s string) string {
+	for _, detect := range m.Detectors {
+		locs := detect(s)
+		if len(locs) == 0 {
+			continue
+		}
+		var b strings.Builder
+		last := 0
+		for _, loc := range locs {
+			b.WriteString(s[last:loc[0]])
+			b.WriteString(m.Mask)
+			last = loc[1]
+		}
+		b.WriteString(s[last:])
+		s = b.String()
+	}
+	return s
+}
+
+// maskJSON masks the JSON body of ctx, after any prefix its JSON engine wrote.
+func (m PIIMask) maskJSON(ctx *RenderContext, paths [][]pathStep) ([]byte, error) {
+	body := ctx.Body
+	var prefix []byte
+	escapeHTML := true
+	if j, ok := ctx.Engine.(JSON); ok {
+		if bytes.HasPrefix(body, j.Prefix) {
+			prefix, body = j.Prefix, body[len(j.Prefix):]
+		}
+		escapeHTML = !j.UnEscapeHTML
+	}
+	// Nothing to mask, e.g. the body of a HEAD or an empty 200.
+	if len(bytes.TrimSpace(body)) == 0 {
+		return ctx.Body, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	v, err := decodeOrdered(dec)
+	if err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("renderall: trailing data after JSON value")
+	}
+
+	v = m.maskValue(v)
+	for _, steps := range paths {
+		v = redactPath(v, steps, m.Mask)
+	}
+
+	out := bytes.NewBuffer(append([]byte(nil), prefix...))
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(escapeHTML)
+	trimmed := bytes.TrimLeft(body, " \t\r\n")
+	if len(trimmed) > 1 && (trimmed[0] == '{' || trimmed[0] == '[') && trimmed[1] == '\n' {
+		enc.SetIndent("", "  ")
+	}
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	// Encode always ends with a newline; keep the original's ending.
+	if !bytes.HasSuffix(body, []byte{'\n'}) {
+		out.Truncate(out.Len() - 1)
+	}
+	return out.Bytes(), nil
+}
+
+// maskValue runs the detectors over the strings and numbers in v.
+func (m PIIMask) maskValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case string:
+		return m.maskText(t)
+	case json.Number:
+		if s := m.maskText(t.String()); s != t.String() {
+			return m.Mask
+		}
+	case *jsonObject:
+		for k, c := range t.vals {
+			t.vals[k] = m.maskValue(c)
+		}
+	case []interface{}:
+		for i, c := range t {
+			t[i] = m.maskValue(c)
+		}
+	}
+	return v
+}
+
+// MarshalJSON writes the members in their original order.
+func (o *jsonObject) MarshalJSON() ([]byte, error) {
+	m := newJSONMembers()
+	for _, k := range o.keys {
+		if err := m.add(k, o.vals[k]); err != nil {
+			return nil, err
+		}
+	}
+	return m.close(), nil
+}
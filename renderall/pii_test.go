@@ -0,0 +1,103 @@
+package renderall
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+func TestCardDetector(t *testing.T) {
+	tests := []struct {
+		s    string
+		want int
+	}{
+		{"4111111111111111", 1},
+		{"card 4111 1111 1111 1111 on file", 1},
+		{"5500-0000-0000-0004", 1},
+		{"4111111111111112", 0}, // fails the Luhn check
+		{"order 123456789012", 0},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		if got := len(CardDetector(tt.s)); got != tt.want {
+			t.Errorf("CardDetector(%q) found %d, want %d", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestMaskPII(t *testing.T) {
+	tests := []struct {
+		name   string
+		mask   PIIMask
+		render func(r *Render, w http.ResponseWriter) error
+		want   string
+	}{
+		{
+			name: "json strings and numbers",
+			render: func(r *Render, w http.ResponseWriter) error {
+				return r.JSON(w, 200, map[string]interface{}{
+					"email": "Contact ann@example.com",
+					"card":  4111111111111111,
+					"name":  "Ann",
+				})
+			},
+			want: `{"card":"[REDACTED]","email":"Contact [REDACTED]","name":"Ann"}`,
+		},
+		{
+			name: "json paths keep order",
+			mask: PIIMask{Paths: []string{"$..ssn"}, Mask: "***"},
+			render: func(r *Render, w http.ResponseWriter) error {
+				return r.JSON(w, 200, struct {
+					Z   string `json:"z"`
+					SSN string `json:"ssn"`
+					A   []struct {
+						SSN int `json:"ssn"`
+					} `json:"a"`
+				}{Z: "z", SSN: "078-05-1120", A: []struct {
+					SSN int `json:"ssn"`
+				}{{SSN: 1}}})
+			},
+			want: `{"z":"z","ssn":"***","a":[{"ssn":"***"}]}`,
+		},
+		{
+			name: "text",
+			mask: PIIMask{Detectors: []PIIDetector{RegexpDetector(regexp.MustCompile(`\d{3}-\d{2}-\d{4}`))}},
+			render: func(r *Render, w http.ResponseWriter) error {
+				return r.DataWithType(w, 200, ContentText, []byte("ssn 078-05-1120, mail ann@example.com"))
+			},
+			want: "ssn [REDACTED], mail ann@example.com",
+		},
+		{
+			name: "empty json untouched",
+			render: func(r *Render, w http.ResponseWriter) error {
+				return r.DataWithType(w, 200, ContentJSON, []byte(" \n"))
+			},
+			want: " \n",
+		},
+		{
+			name: "binary untouched",
+			render: func(r *Render, w http.ResponseWriter) error {
+				return r.Data(w, 200, []byte("ann@example.com"))
+			},
+			want: "ann@example.com",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRender(Options{PostRender: []func(*RenderContext) error{MaskPII(tt.mask)}})
+			rec := httptest.NewRecorder()
+			if err := tt.render(r, rec); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Body.String() != tt.want {
+				t.Errorf("body %s, want %s", rec.Body.String(), tt.want)
+			}
+		})
+	}
+
+	hook := MaskPII(PIIMask{Paths: []string{"ssn"}})
+	if err := hook(&RenderContext{}); err == nil {
+		t.Error("MaskPII with an invalid path succeeded")
+	}
+}
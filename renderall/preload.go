@@ -0,0 +1,84 @@
+package renderall
+
+import (
+	"fmt"
+	"html/template"
+	"sort"
+	"text/template/parse"
+)
+
+// Preload compiles every template set and checks that the layout exists and
+// that every {{ template }} call resolves, so misconfigured template
+// directories fail at boot instead of on the first request.
+func (r *Render) Preload() error {
+	if err := r.compileTemplates(); err != nil {
+		return err
+	}
+
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	if err := r.checkSet("", r.templates); err != nil {
+		return err
+	}
+	for theme, tmpl := range r.themes {
+		if err := r.checkSet(theme, tmpl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkSet validates one compiled set. theme is only used in errors.
+func (r *Render) checkSet(theme string, set *template.Template) error {
+	where := ""
+	if theme != "" {
+		where = fmt.Sprintf(" in theme %q", theme)
+	}
+	if r.opt.Layout != "" && set.Lookup(r.opt.Layout) == nil {
+		return fmt.Errorf("renderall: layout %q not found%s", r.opt.Layout, where)
+	}
+
+	tmpls := set.Templates()
+	sort.Slice(tmpls, func(i, j int) bool { return tmpls[i].Name() < tmpls[j].Name() })
+	for _, t := range tmpls {
+		if t.Tree == nil {
+			continue
+		}
+		for _, name := range templateCalls(t.Tree.Root) {
+			if set.Lookup(name) == nil {
+				return fmt.Errorf("renderall: template %q%s calls undefined template %q", t.Name(), where, name)
+			}
+		}
+	}
+	return nil
+}
+
+// templateCalls returns the names of the templates invoked under node.
+func templateCalls(node parse.Node) []string {
+	var names []string
+	var walk func(parse.Node)
+	walk = func(node parse.Node) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, c := range n.Nodes {
+				walk(c)
+			}
+		case *parse.TemplateNode:
+			names = append(names, n.Name)
+		case *parse.IfNode:
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.RangeNode:
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.WithNode:
+			walk(n.List)
+			walk(n.ElseList)
+		}
+	}
+	walk(node)
+	return names
+}
@@ -0,0 +1,146 @@
+package renderall
+
+import (
+	"fmt"
+	"io/fs"
+	"net/http"
+)
+
+const (
+	// ContentWebManifest header value for web app manifests.
+	ContentWebManifest = "application/manifest+json"
+	// ServiceWorkerAllowedHeader widens the scope a service worker may control.
+	ServiceWorkerAllowedHeader = "Service-Worker-Allowed"
+)
+
+// WebManifest is a web app manifest, as served from manifest.webmanifest
+// to make a site installable. Blank fields are left out.
+type WebManifest struct {
+	ID              string                `json:"id,omitempty"`
+	Name            string                `json:"name,omitempty"`
+	ShortName       string                `json:"short_name,omitempty"`
+	Description     string                `json:"description,omitempty"`
+	StartURL        string                `json:"start_url,omitempty"`
+	Scope           string                `json:"scope,omitempty"`
+	Display         string                `json:"display,omitempty"`
+	Orientation     string                `json:"orientation,omitempty"`
+	BackgroundColor string                `json:"background_color,omitempty"`
+	ThemeColor      string                `json:"theme_color,omitempty"`
+	Lang            string                `json:"lang,omitempty"`
+	Dir             string                `json:"dir,omitempty"`
+	Categories      []string              `json:"categories,omitempty"`
+	Icons           []WebManifestIcon     `json:"icons,omitempty"`
+	Shortcuts       []WebManifestShortcut `json:"shortcuts,omitempty"`
+}
+
+// WebManifestIcon is an app icon. Src may name a source asset, which
+// WebManifest resolves to its fingerprinted URL.
+type WebManifestIcon struct {
+	Src   string `json:"src"`
+	Sizes string `json:"sizes,omitempty"`
+	Type  string `json:"type,omitempty"`
+	// Purpose, e.g. "maskable" or "any maskable".
+	Purpose string `json:"purpose,omitempty"`
+}
+
+// WebManifestShortcut is an app shortcut shown from the installed icon.
+type WebManifestShortcut struct {
+	Name        string            `json:"name"`
+	ShortName   string            `json:"short_name,omitempty"`
+	Description string            `json:"description,omitempty"`
+	URL         string            `json:"url"`
+	Icons       []WebManifestIcon `json:"icons,omitempty"`
+}
+
+// ServiceWorkerOptions is a struct for overriding some rendering Options for specific ServiceWorker call.
+type ServiceWorkerOptions struct {
+	// Scope sent as Service-Worker-Allowed, e.g. "/" for a worker served from /assets/. Default is blank for none.
+	Scope string
+}
+
+// WebManifest renders m as application/manifest+json. Icon sources that
+// are in the asset manifest are rewritten to their built URLs.
+func (r *Render) WebManifest(w http.ResponseWriter, status int, m WebManifest) error {
+	icons := func(in []WebManifestIcon) ([]WebManifestIcon, error) {
+		manifest, err := r.manifest()
+		if err != nil || manifest == nil {
+			return in, err
+		}
+		out := make([]WebManifestIcon, len(in))
+		for i, icon := range in {
+			if _, ok := manifest[icon.Src]; ok {
+				if icon.Src, err = r.AssetURL(icon.Src); err != nil {
+					return nil, err
+				}
+			}
+			out[i] = icon
+		}
+		return out, nil
+	}
+	var err error
+	if m.Icons, err = icons(m.Icons); err != nil {
+		return r.fail(w, err)
+	}
+	shortcuts := make([]WebManifestShortcut, len(m.Shortcuts))
+	for i, s := range m.Shortcuts {
+		if s.Icons, err = icons(s.Icons); err != nil {
+			return r.fail(w, err)
+		}
+		shortcuts[i] = s
+	}
+	if m.Shortcuts != nil {
+		m.Shortcuts = shortcuts
+	}
+
+	head := Head{
+		ContentType: ContentWebManifest,
+		Status:      status,
+	}
+
+	j := JSON{
+		Head:         head,
+		Indent:       r.opt.IndentJSON,
+		UnEscapeHTML: r.opt.UnEscapeHTML,
+		Hook:         r.marshalHook(),
+	}
+
+	return r.Render(w, j, m)
+}
+
+// ServiceWorker serves the named script from Options.Assets, resolved
+// through the asset manifest, with the Service-Worker-Allowed scope. It is
+// sent with Cache-Control: no-cache so browsers pick up new workers
+// promptly; the worker must live at a stable, unfingerprinted URL.
+func (r *Render) ServiceWorker(w http.ResponseWriter, status int, name string, swOpt ...ServiceWorkerOptions) error {
+	opt := ServiceWorkerOptions{}
+	if len(swOpt) > 0 {
+		opt = swOpt[0]
+	}
+	if r.opt.Assets == nil {
+		return r.fail(w, fmt.Errorf("renderall: cannot serve service worker %q, Options.Assets is nil", name))
+	}
+	entry, err := r.builtAsset(name)
+	if err != nil {
+		return r.fail(w, err)
+	}
+	data, err := fs.ReadFile(r.opt.Assets, entry.File)
+	if err != nil {
+		return r.fail(w, err)
+	}
+
+	if opt.Scope != "" {
+		w.Header().Set(ServiceWorkerAllowedHeader, opt.Scope)
+	}
+	w.Header().Set("Cache-Control", "no-cache")
+
+	head := Head{
+		ContentType: r.withCharset("text/javascript"),
+		Status:      status,
+	}
+
+	d := Data{
+		Head: head,
+	}
+
+	return r.Render(w, d, data)
+}
@@ -0,0 +1,86 @@
+package renderall
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// RetryAfter header constant.
+	RetryAfter = "Retry-After"
+	// RateLimitLimit header constant.
+	RateLimitLimit = "RateLimit-Limit"
+	// RateLimitRemaining header constant.
+	RateLimitRemaining = "RateLimit-Remaining"
+	// RateLimitReset header constant.
+	RateLimitReset = "RateLimit-Reset"
+	// RateLimitPolicy header constant.
+	RateLimitPolicy = "RateLimit-Policy"
+)
+
+// RateLimit is a client's quota, sent as RateLimit-* headers.
+type RateLimit struct {
+	// Limit is the number of requests allowed in the window.
+	Limit int
+	// Remaining is the number of requests left in the window.
+	Remaining int
+	// Reset is the time until the window resets.
+	Reset time.Duration
+	// Policy describes the quota, e.g. "100;w=60". Blank sends no RateLimit-Policy.
+	Policy string
+}
+
+// SetRateLimit sets the RateLimit-* headers for rl on h, e.g. on successful
+// responses so clients can pace themselves.
+func SetRateLimit(h http.Header, rl RateLimit) {
+	h.Set(RateLimitLimit, strconv.Itoa(rl.Limit))
+	h.Set(RateLimitRemaining, strconv.Itoa(rl.Remaining))
+	h.Set(RateLimitReset, strconv.Itoa(ceilSeconds(rl.Reset)))
+	if rl.Policy != "" {
+		h.Set(RateLimitPolicy, rl.Policy)
+	}
+}
+
+// ceilSeconds rounds d up to whole seconds, as Retry-After and RateLimit-Reset
+// count them.
+func ceilSeconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	return int((d + time.Second - 1) / time.Second)
+}
+
+// TooManyRequests writes a 429 response asking the client to retry after
+// retryAfter, with the RateLimit-* headers for rl if given.
+func (r *Render) TooManyRequests(w http.ResponseWriter, req *http.Request, retryAfter time.Duration, rl ...RateLimit) error {
+	if len(rl) > 0 {
+		SetRateLimit(w.Header(), rl[0])
+	}
+	return r.throttled(w, req, http.StatusTooManyRequests, retryAfter)
+}
+
+// Unavailable writes a 503 response asking the client to retry after
+// retryAfter, e.g. while shedding load.
+func (r *Render) Unavailable(w http.ResponseWriter, req *http.Request, retryAfter time.Duration) error {
+	return r.throttled(w, req, http.StatusServiceUnavailable, retryAfter)
+}
+
+// throttled sets Retry-After and writes the body: problem details for
+// clients that ask for them, otherwise the usual Status response.
+func (r *Render) throttled(w http.ResponseWriter, req *http.Request, status int, retryAfter time.Duration) error {
+	secs := ceilSeconds(retryAfter)
+	w.Header().Set(RetryAfter, strconv.Itoa(secs))
+
+	if req != nil {
+		accept := req.Header.Get("Accept")
+		if _, s := quality(parseAccept(accept), ContentProblemJSON); s == 3 &&
+			Negotiate(accept, ContentProblemJSON, ContentJSON, ContentHTML) == ContentProblemJSON {
+			return r.Problem(w, status, Problem{
+				Detail:     "Retry after " + strconv.Itoa(secs) + " seconds.",
+				RetryAfter: secs,
+			})
+		}
+	}
+	return r.Status(w, req, status, nil)
+}
@@ -0,0 +1,195 @@
+package renderall
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// recordMaxBytes caps the body and binding snapshot kept per recording.
+const recordMaxBytes = 64 << 10
+
+// RecordedResponse is a render kept by Options.RecordResponses.
+type RecordedResponse struct {
+	Time time.Time `json:"time"`
+	// Method and URL of the request, when the render was given one.
+	Method string `json:"method,omitempty"`
+	URL    string `json:"url,omitempty"`
+	// Engine type, e.g. "renderall.HTML".
+	Engine string `json:"engine"`
+	// Template rendered by HTML, text, and PDF engines.
+	Template string      `json:"template,omitempty"`
+	Status   int         `json:"status"`
+	Header   http.Header `json:"header"`
+	// Binding is a JSON snapshot of the data taken before rendering, redacted
+	// as JSON output is, or its %+v form if it can't be marshalled.
+	Binding string `json:"binding"`
+	Body    string `json:"body"`
+	// Truncated is set when Body or Binding were cut at 64KB.
+	Truncated bool          `json:"truncated,omitempty"`
+	Duration  time.Duration `json:"duration"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// recorder is a ring buffer of the last renders.
+type recorder struct {
+	mu    sync.Mutex
+	items []RecordedResponse
+	next  int
+}
+
+func (rec *recorder) add(size int, item RecordedResponse) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if len(rec.items) < size {
+		rec.items = append(rec.items, item)
+		return
+	}
+	rec.items[rec.next] = item
+	rec.next = (rec.next + 1) % size
+}
+
+// Recorded returns the renders kept by Options.RecordResponses, newest first.
+func (r *Render) Recorded() []RecordedResponse {
+	r.recorder.mu.Lock()
+	defer r.recorder.mu.Unlock()
+	items := r.recorder.items
+	out := make([]RecordedResponse, 0, len(items))
+	for i := 0; i < len(items); i++ {
+		// next is the oldest once the buffer has wrapped.
+		out = append(out, items[(r.recorder.next+len(items)-1-i)%len(items)])
+	}
+	return out
+}
+
+// RecordedHandler serves the recorded renders as JSON, newest first. Mount
+// it on an internal route only: bodies and bindings may hold personal data.
+// It writes directly rather than rendering, so it doesn't record itself.
+func (r *Render) RecordedHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		b, err := json.MarshalIndent(r.Recorded(), "", "  ")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set(ContentType, r.withCharset(ContentJSON))
+		w.Header().Set("Cache-Control", "no-store")
+		w.Write(b)
+	})
+}
+
+// recordWriter keeps the status and the start of the body of a render.
+type recordWriter struct {
+	http.ResponseWriter
+	status    int
+	body      []byte
+	truncated bool
+}
+
+func (rw *recordWriter) WriteHeader(status int) {
+	if rw.status == 0 {
+		rw.status = status
+	}
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *recordWriter) Write(b []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	if !rw.truncated {
+		rw.body, rw.truncated = appendCapped(rw.body, b)
+	}
+	return rw.ResponseWriter.Write(b)
+}
+
+// appendCapped appends b to body up to recordMaxBytes, reporting whether it
+// had to cut b short. The cut backs off to the start of a UTF-8 sequence,
+// which may have begun in an earlier write.
+func appendCapped(body, b []byte) ([]byte, bool) {
+	room := recordMaxBytes - len(body)
+	if room >= len(b) {
+		return append(body, b...), false
+	}
+	body = append(body, b[:room]...)
+	for i := len(body) - 1; i >= 0 && i >= len(body)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(body[i]) {
+			if !utf8.FullRune(body[i:]) {
+				body = body[:i]
+			}
+			break
+		}
+	}
+	return body, true
+}
+
+// Flush sends any buffered data to the client.
+func (rw *recordWriter) Flush() {
+	http.NewResponseController(rw.ResponseWriter).Flush()
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (rw *recordWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// recordedTemplate names the template an engine renders, if any.
+func recordedTemplate(e Engine) string {
+	switch e := e.(type) {
+	case HTML:
+		return e.Name
+	case TextTemplate:
+		return e.Name
+	case PDF:
+		return e.Page.Name
+	}
+	return ""
+}
+
+// snapshot captures data as it would be marshalled, before the engine runs.
+func (r *Render) snapshot(data interface{}) (string, bool) {
+	v := data
+	if !r.opt.DisableRedaction {
+		v = Redact(v)
+	}
+	var s string
+	if b, err := json.Marshal(v); err == nil {
+		s = string(b)
+	} else {
+		s = fmt.Sprintf("%+v", v)
+	}
+	if len(s) > recordMaxBytes {
+		b, _ := appendCapped(nil, []byte(s))
+		return string(b), true
+	}
+	return s, false
+}
+
+// record runs render against a recordWriter and keeps the result.
+func (r *Render) record(w http.ResponseWriter, ctx *RenderContext, render func(http.ResponseWriter) error) error {
+	item := RecordedResponse{
+		Time:     r.now(),
+		Engine:   fmt.Sprintf("%T", ctx.Engine),
+		Template: recordedTemplate(ctx.Engine),
+	}
+	if ctx.Request != nil {
+		item.Method, item.URL = ctx.Request.Method, ctx.Request.URL.String()
+	}
+	item.Binding, item.Truncated = r.snapshot(ctx.Data)
+
+	rw := &recordWriter{ResponseWriter: w}
+	err := render(rw)
+	item.Duration = r.now().Sub(item.Time)
+	item.Status = rw.status
+	item.Header = w.Header().Clone()
+	item.Body = string(rw.body)
+	item.Truncated = item.Truncated || rw.truncated
+	if err != nil {
+		item.Error = err.Error()
+	}
+	r.recorder.add(r.opt.RecordResponses, item)
+	return err
+}
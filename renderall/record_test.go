@@ -0,0 +1,29 @@
+package renderall
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestRecordTruncation(t *testing.T) {
+	// A two byte rune straddles the cap.
+	body := strings.Repeat("a", recordMaxBytes-1) + "é" + "tail"
+
+	r := New(Options{RecordResponses: 1})
+	if err := r.Data(httptest.NewRecorder(), 200, []byte(body)); err != nil {
+		t.Fatal(err)
+	}
+	rec := r.Recorded()[0]
+	if !rec.Truncated || len(rec.Body) != recordMaxBytes-1 || !utf8.ValidString(rec.Body) {
+		t.Errorf("kept %d bytes, truncated %v, valid %v", len(rec.Body), rec.Truncated, utf8.ValidString(rec.Body))
+	}
+
+	rw := &recordWriter{ResponseWriter: httptest.NewRecorder()}
+	rw.Write([]byte(body[:recordMaxBytes]))
+	rw.Write([]byte("more"))
+	if len(rw.body) != recordMaxBytes-1 || !rw.truncated {
+		t.Errorf("kept %d bytes over two writes, truncated %v", len(rw.body), rw.truncated)
+	}
+}
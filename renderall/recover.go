@@ -0,0 +1,66 @@
+package renderall
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"strings"
+)
+
+// RecoverHandler recovers panics in next and renders a 500 through Status,
+// so the error template mapping and the JSON error body apply. Development
+// mode adds the panic value and stack trace to the page or error details.
+// Panics are passed to Options.OnPanic. A panic after the response has
+// started aborts it instead, since an error page can no longer be sent.
+func (r *Render) RecoverHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		rw := NewResponseWriter(w)
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+			stack := debug.Stack()
+			if r.opt.OnPanic != nil {
+				r.opt.OnPanic(req, v, stack)
+			} else {
+				log.Printf("renderall: panic serving %s: %v\n%s", req.URL.Path, v, stack)
+			}
+			if rw.HeaderWritten() {
+				panic(http.ErrAbortHandler)
+			}
+			r.renderPanic(w, req, v, stack)
+		}()
+		next.ServeHTTP(rw, req)
+	})
+}
+
+// renderPanic renders the 500 for a recovered panic, dropping headers the
+// handler set for the response it never finished.
+func (r *Render) renderPanic(w http.ResponseWriter, req *http.Request, v interface{}, stack []byte) {
+	h := w.Header()
+	for _, k := range []string{ContentLength, ContentEncoding, ContentDisposition, ETagHeader, LastModifiedHeader} {
+		h.Del(k)
+	}
+
+	status := http.StatusInternalServerError
+	page := r.errorPage(req, status)
+	var details interface{}
+	if r.opt.IsDevelopment {
+		page.Message = fmt.Sprint(v)
+		page.Stack = string(stack)
+		details = map[string]interface{}{
+			"panic": page.Message,
+			"stack": strings.Split(strings.TrimSpace(page.Stack), "\n"),
+		}
+	}
+	if prefersHTML(req) {
+		r.Status(w, req, status, page)
+		return
+	}
+	r.Status(w, req, status, details)
+}
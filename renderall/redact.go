@@ -0,0 +1,300 @@
+package renderall
+
+import (
+	"reflect"
+	"sync"
+)
+
+// RedactedValue replaces string fields tagged `render:"redact"`.
+const RedactedValue = "[REDACTED]"
+
+// redactCache records whether a type can contain tagged fields.
+var redactCache sync.Map
+
+// Redact returns a copy of v with fields tagged `render:"redact"` masked and
+// fields tagged `render:"omit"` zeroed. Redacted strings become RedactedValue,
+// other redacted types become their zero value. Pair "omit" with the
+// encoder's omitempty to drop the field from the output entirely. v itself is
+// never modified.
+func Redact(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	c, changed := redactValue(reflect.ValueOf(v), map[redactKey]redactCopy{})
+	if !changed {
+		return v
+	}
+	return c.Interface()
+}
+
+// mayRedact reports whether values of type t could hold tagged fields.
+func mayRedact(t reflect.Type) bool {
+	if cached, ok := redactCache.Load(t); ok {
+		return cached.(bool)
+	}
+	seen := map[reflect.Type]bool{}
+	result := mayRedactType(t, seen)
+	// Had any type walked held tagged fields, so would t; none does.
+	if !result {
+		for t := range seen {
+			redactCache.Store(t, false)
+		}
+	}
+	return result
+}
+
+// mayRedactType walks t for mayRedact. Only positive answers are cached as
+// they are found; a negative one may hinge on a type seen earlier in the
+// walk whose answer is not known yet.
+func mayRedactType(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if cached, ok := redactCache.Load(t); ok {
+		return cached.(bool)
+	}
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+
+	result := false
+	switch t.Kind() {
+	case reflect.Interface:
+		// Decided by the dynamic value.
+		result = true
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		result = mayRedactType(t.Elem(), seen)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			if tag := f.Tag.Get("render"); tag == "redact" || tag == "omit" || mayRedactType(f.Type, seen) {
+				result = true
+				break
+			}
+		}
+	}
+	if result {
+		redactCache.Store(t, true)
+	}
+	return result
+}
+
+// redactKey identifies a pointer, map, or slice being walked, so that cyclic
+// values are copied with the same shape instead of recursing forever.
+type redactKey struct {
+	ptr uintptr
+	typ reflect.Type
+	len int
+}
+
+// redactCopy is the copy of a reference reached again through a cycle while
+// still being walked. It is made regardless of what the walk finds, as the
+// cycle has to point at the copy should anything under it change.
+type redactCopy struct {
+	v       reflect.Value
+	changed bool
+}
+
+// redactChange is an element of a map, slice, array, or struct that differs
+// in the copy.
+type redactChange struct {
+	key reflect.Value
+	i   int
+	v   reflect.Value
+}
+
+// redactValue walks v and, if it reaches a tagged field, returns a copy with
+// tagged fields masked and reports true. Untouched parts of v are shared
+// with the copy, and v is returned as is if nothing is tagged. seen holds
+// the references on the path to v.
+func redactValue(v reflect.Value, seen map[redactKey]redactCopy) (reflect.Value, bool) {
+	if !mayRedact(v.Type()) {
+		return v, false
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v, false
+		}
+		key := redactKey{v.Pointer(), v.Type(), 0}
+		if _, ok := seen[key]; ok {
+			return revisit(seen, key, func() reflect.Value { return reflect.New(v.Type().Elem()) })
+		}
+		seen[key] = redactCopy{}
+		e, changed := redactValue(v.Elem(), seen)
+		s := seen[key]
+		delete(seen, key)
+		if !s.changed {
+			if !changed {
+				return v, false
+			}
+			s.v, s.changed = reflect.New(v.Type().Elem()), true
+		}
+		s.v.Elem().Set(e)
+		return s.v, true
+	case reflect.Interface:
+		if v.IsNil() {
+			return v, false
+		}
+		e, changed := redactValue(v.Elem(), seen)
+		if !changed {
+			return v, false
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(e)
+		return c, true
+	case reflect.Struct:
+		var changes []redactChange
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			switch f.Tag.Get("render") {
+			case "redact":
+				changes = append(changes, redactChange{i: i, v: mask(v.Field(i))})
+			case "omit":
+				changes = append(changes, redactChange{i: i, v: reflect.Zero(f.Type)})
+			default:
+				if e, changed := redactValue(v.Field(i), seen); changed {
+					changes = append(changes, redactChange{i: i, v: e})
+				}
+			}
+		}
+		if len(changes) == 0 {
+			return v, false
+		}
+		c := reflect.New(t).Elem()
+		c.Set(v)
+		for _, ch := range changes {
+			c.Field(ch.i).Set(ch.v)
+		}
+		return c, true
+	case reflect.Slice:
+		if v.IsNil() {
+			return v, false
+		}
+		key := redactKey{v.Pointer(), v.Type(), v.Len()}
+		if _, ok := seen[key]; ok {
+			return revisit(seen, key, func() reflect.Value { return reflect.MakeSlice(v.Type(), v.Len(), v.Len()) })
+		}
+		seen[key] = redactCopy{}
+		changes := redactElems(v, seen)
+		s := seen[key]
+		delete(seen, key)
+		if !s.changed {
+			if len(changes) == 0 {
+				return v, false
+			}
+			s.v, s.changed = reflect.MakeSlice(v.Type(), v.Len(), v.Len()), true
+		}
+		reflect.Copy(s.v, v)
+		for _, ch := range changes {
+			s.v.Index(ch.i).Set(ch.v)
+		}
+		return s.v, true
+	case reflect.Array:
+		changes := redactElems(v, seen)
+		if len(changes) == 0 {
+			return v, false
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		for _, ch := range changes {
+			c.Index(ch.i).Set(ch.v)
+		}
+		return c, true
+	case reflect.Map:
+		if v.IsNil() {
+			return v, false
+		}
+		key := redactKey{v.Pointer(), v.Type(), 0}
+		if _, ok := seen[key]; ok {
+			return revisit(seen, key, func() reflect.Value { return reflect.MakeMapWithSize(v.Type(), v.Len()) })
+		}
+		seen[key] = redactCopy{}
+		var changes []redactChange
+		// One scratch value serves every entry; the walk copies what it keeps.
+		e := reflect.New(v.Type().Elem()).Elem()
+		var iter reflect.MapIter
+		iter.Reset(v)
+		for iter.Next() {
+			e.SetIterValue(&iter)
+			if c, changed := redactValue(e, seen); changed {
+				changes = append(changes, redactChange{key: iter.Key(), v: c})
+			}
+		}
+		s := seen[key]
+		delete(seen, key)
+		if !s.changed {
+			if len(changes) == 0 {
+				return v, false
+			}
+			s.v, s.changed = reflect.MakeMapWithSize(v.Type(), v.Len()), true
+		}
+		iter.Reset(v)
+		for iter.Next() {
+			s.v.SetMapIndex(iter.Key(), iter.Value())
+		}
+		for _, ch := range changes {
+			s.v.SetMapIndex(ch.key, ch.v)
+		}
+		return s.v, true
+	}
+	return v, false
+}
+
+// revisit answers for the reference key reached again, copying it with
+// alloc the first time.
+func revisit(seen map[redactKey]redactCopy, key redactKey, alloc func() reflect.Value) (reflect.Value, bool) {
+	s := seen[key]
+	if !s.changed {
+		s = redactCopy{alloc(), true}
+		seen[key] = s
+	}
+	return s.v, true
+}
+
+// redactElems walks the elements of a slice or array and returns those that
+// differ in the copy.
+func redactElems(v reflect.Value, seen map[redactKey]redactCopy) []redactChange {
+	var changes []redactChange
+	for i := 0; i < v.Len(); i++ {
+		if c, changed := redactValue(v.Index(i), seen); changed {
+			changes = append(changes, redactChange{i: i, v: c})
+		}
+	}
+	return changes
+}
+
+// mask returns the value replacing a redacted field: RedactedValue where
+// the type allows it, otherwise the zero value.
+func mask(f reflect.Value) reflect.Value {
+	switch {
+	case f.Kind() == reflect.String:
+		return reflect.ValueOf(RedactedValue).Convert(f.Type())
+	case f.Kind() == reflect.Ptr && f.Type().Elem().Kind() == reflect.String && !f.IsNil():
+		s := reflect.New(f.Type().Elem())
+		s.Elem().SetString(RedactedValue)
+		return s
+	}
+	return reflect.Zero(f.Type())
+}
+
+// marshalHook combines the user MarshalHook with tag redaction, which runs
+// last so values produced by the hook are redacted too.
+func (r *Render) marshalHook() MarshalHook {
+	if r.opt.DisableRedaction {
+		return r.opt.MarshalHook
+	}
+	hook := r.opt.MarshalHook
+	return func(v interface{}) (interface{}, error) {
+		v, err := applyHook(hook, v)
+		if err != nil {
+			return nil, err
+		}
+		return Redact(v), nil
+	}
+}
@@ -0,0 +1,44 @@
+package renderall
+
+import (
+	"reflect"
+	"testing"
+)
+
+type redactUser struct {
+	Name     string
+	Password string  `render:"redact"`
+	Token    *string `render:"redact"`
+	Internal int     `render:"omit"`
+	Friend   *redactUser
+}
+
+func TestRedact(t *testing.T) {
+	token := "t"
+	u := &redactUser{Name: "a", Password: "p", Token: &token, Internal: 1}
+	u.Friend = u
+	rows := []map[string]interface{}{
+		{"id": 1, "user": u},
+		{"id": 2, "name": "plain"},
+	}
+
+	got := Redact(rows).([]map[string]interface{})
+	c := got[0]["user"].(*redactUser)
+	if c == u || c.Password != RedactedValue || *c.Token != RedactedValue || c.Internal != 0 || c.Name != "a" {
+		t.Fatalf("redacted user = %+v", c)
+	}
+	if c.Friend != c {
+		t.Errorf("cycle not kept in copy: Friend = %p, want %p", c.Friend, c)
+	}
+	if u.Password != "p" || token != "t" || u.Internal != 1 {
+		t.Errorf("original modified: %+v", u)
+	}
+	if reflect.ValueOf(got[1]).Pointer() != reflect.ValueOf(rows[1]).Pointer() {
+		t.Error("untagged row copied")
+	}
+
+	plain := []map[string]interface{}{{"id": 1, "tags": []interface{}{"a", map[string]interface{}{"b": 2}}}}
+	if reflect.ValueOf(Redact(plain)).Pointer() != reflect.ValueOf(plain).Pointer() {
+		t.Error("payload without tagged fields copied")
+	}
+}
@@ -3,8 +3,22 @@ package renderall
 import (
 	"bytes"
 	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
 	"html/template"
+	"io"
+	"io/fs"
+	"io/ioutil"
 	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+	"time"
 )
 
 const (
@@ -38,20 +52,56 @@ type Options struct {
 	Asset func(name string) ([]byte, error)
 	// AssetNames function to use in place of directory. Defaults to nil.
 	AssetNames func() []string
+	// Loader to source templates from in place of Directory, Asset, and AssetNames. Defaults to nil.
+	Loader TemplateLoader
+	// Watches Loader and recompiles the templates whenever it reports a change. Default is false.
+	ReloadTemplates bool
 	// Layout template name. Will not render a layout if blank (""). Defaults to blank ("").
 	Layout string
+	// Themes maps a theme name to its template roots, highest precedence first, e.g. {"acme": {"themes/acme", "themes/brand", "templates"}}. Defaults to nil.
+	Themes map[string][]string
+	// ThemeResolver picks the theme for a request. Blank or unknown themes use Directory. Defaults to nil.
+	ThemeResolver func(*http.Request) string
 	// Extensions to parse template files from. Defaults to [".tmpl"].
 	Extensions []string
 	// Funcs is a slice of FuncMaps to apply to the template upon compilation. This is useful for helper functions. Defaults to [].
 	Funcs []template.FuncMap
 	// Delims sets the action delimiters to the specified strings in the Delims struct.
-	//Delims Delims
-	// Appends the given character set to the Content-Type header. Default is "UTF-8".
+	Delims Delims
+	// Appends the given character set to the Content-Type header of textual types, see CharsetTypes. Default is "UTF-8".
 	Charset string
+	// Overrides, by media type, whether Charset is appended, e.g. {"application/json": true}. Defaults to nil, which appends it to text, XML, and JavaScript types only, as JSON (RFC 8259) and binary types define no charset parameter.
+	CharsetTypes map[string]bool
+	// Appends Charset to every rendered Content-Type, JSON and binary types included, as before CharsetTypes. Default is false.
+	LegacyCharset bool
 	// Outputs human readable JSON.
 	IndentJSON bool
 	// Outputs human readable XML. Default is false.
 	IndentXML bool
+	// Writes the <?xml version="1.0" encoding="..."?> prolog before XML documents. Default is false.
+	XMLProlog bool
+	// Default namespace set on the root element of XML documents. Default is blank ("").
+	XMLNamespace string
+	// Spaces per level of human readable XML. Default is 2.
+	XMLIndentWidth int
+	// Field delimiter of CSV responses, e.g. ';' for locales where Excel expects it. Default is ','.
+	CSVComma rune
+	// Starts CSV and TSV responses with a UTF-8 byte order mark so Excel reads non-ASCII text correctly. Default is false.
+	CSVBOM bool
+	// Starts CSV and TSV responses with an Excel "sep=" line naming the delimiter. Default is false.
+	CSVSepHint bool
+	// Creates the SheetWriter XLSX builds workbooks with, e.g. one wrapping excelize. Defaults to NewXLSXWriter.
+	XLSXWriter func(io.Writer) SheetWriter
+	// Query parameter that turns on human readable JSON and XML for a request, e.g. "pretty" for ?pretty=1. Default is blank, off.
+	PrettyParam string
+	// Strips comments and the whitespace between tags from SVG responses. Default is false.
+	MinifySVG bool
+	// Detects the type of Data responses with MIMEType when the handler has not set one, in place of application/octet-stream. Default is false.
+	SniffData bool
+	// Honors Range headers on 200 Data responses given a Request through DataOptions, including multipart/byteranges. Default is false.
+	DataRanges bool
+	// Content types by file extension, e.g. {".glb": "model/gltf-binary"}, overriding the built-in table used by MIMEType. Defaults to nil.
+	MIMETypes map[string]string
 	// Prefixes the JSON output with the given bytes. Default is false.
 	PrefixJSON []byte
 	// Prefixes the XML output with the given bytes.
@@ -64,16 +114,293 @@ type Options struct {
 	UnEscapeHTML bool
 	// Streams JSON responses instead of marshalling prior to sending. Default is false.
 	StreamingJSON bool
+	// Buffers JSON responses up to this many bytes, sending them with a Content-Length, and streams larger ones. Overrides StreamingJSON. Default is 0, off.
+	StreamingJSONThreshold int
 	// Require that all blocks executed in the layout are implemented in all templates using the layout. Default is false.
 	RequireBlocks bool
 	// Disables automatic rendering of http.StatusInternalServerError when an error occurs. Default is false.
 	DisableHTTPErrorRendering bool
+	// Aborts renders whose body would exceed this many bytes with a ResponseTooLargeError and a 507. Default is 0, no limit.
+	MaxResponseBytes int64
+	// Template rendered in place of a requested template that does not exist. Defaults to blank (""), which reports an error.
+	FallbackTemplate string
+	// ErrorTemplates maps statuses to the templates Status renders for them, e.g. {404: "errors/not-found"}. Defaults to nil, which uses the built-in error pages.
+	ErrorTemplates map[int]string
+	// Wraps JSON responses in an Envelope. Default is false.
+	Envelope bool
+	// MetaFuncs populate the meta of every enveloped response. Defaults to [].
+	MetaFuncs []MetaFunc
+	// Errors maps Go errors to status codes and error codes for RenderError. Defaults to nil.
+	Errors *ErrorRegistry
+	// Schemas validates JSON renders against registered contracts when IsDevelopment is set. Defaults to nil.
+	Schemas *SchemaRegistry
+	// OnSchemaError receives schema violations, e.g. to log them, instead of failing the render. Defaults to nil.
+	OnSchemaError func(req *http.Request, err error)
+	// OnPanic receives panics recovered by RecoverHandler, e.g. to report them. Defaults to logging them like net/http.
+	OnPanic func(req *http.Request, v interface{}, stack []byte)
+	// OnWarning receives problems renders recover from, such as a *StatusError for a body dropped from a 204. The request may be nil. Defaults to logging them.
+	OnWarning func(req *http.Request, err error)
+	// Counts renders and execution times per template for TemplateUsage, to find dead templates. Default is false.
+	TemplateStats bool
+	// Keeps the last N renders, with their bindings, bodies, and timings, for Recorded and RecordedHandler. Only for debugging, as it copies every response. Default is 0 for none.
+	RecordResponses int
+	// Receives an AuditRecord of every render's route, status, content type, size, and body hash, e.g. NewAuditLog(f). Defaults to nil.
+	Audit AuditSink
+	// Reports whether a render is audited, e.g. only for admin routes. Renders without a request always are. Defaults to nil, auditing every render.
+	AuditRequest func(*http.Request) bool
+	// Fraction of audited renders, from 0 to 1, that keep up to 64KB of the body in their record, drawn from Random, so every one in TestMode. Default is 0, none.
+	AuditBodySample float64
+	// Retry-After sent with maintenance mode responses, see SetMaintenance. Defaults to 5 minutes.
+	MaintenanceRetryAfter time.Duration
+	// MaintenanceBypass lets requests it returns true for, e.g. from admins or health checks, render normally during maintenance. Defaults to nil.
+	MaintenanceBypass func(*http.Request) bool
+	// MarshalHook transforms values before the JSON, JSONP, and XML engines marshal them. Defaults to nil.
+	MarshalHook MarshalHook
+	// Skips masking of fields tagged `render:"redact"` or `render:"omit"`, e.g. for internal renderers. Default is false.
+	DisableRedaction bool
+	// Outputs canonical JSON (RFC 8785) suitable for hashing and signing. Overrides IndentJSON and StreamingJSON. Default is false.
+	CanonicalJSON bool
+	// Computes Content-Digest headers over the body sent and Repr-Digest headers over the whole representation with these algorithms ("sha-256", "sha-512"). Responses are buffered while set. Defaults to [].
+	DigestAlgorithms []string
+	// Sets a strong ETag computed over the rendered body. Responses are buffered while set. Default is false.
+	ETag bool
+	// Gzips rendered bodies of 1KB and up for requests that accept it, sending Vary: Accept-Encoding and per-encoding ETags. Only renders given a Request are compressed. Responses are buffered while set. Default is false.
+	Compress bool
+	// Signs rendered responses with HTTP Message Signatures (RFC 9421). Responses are buffered while set. Defaults to nil.
+	Signature *SignatureOptions
+	// Adds a Server-Timing metric for template execution ("tmpl") or marshalling ("marshal"), plus "total" for requests through Timed. Default is false.
+	ServerTiming bool
+	// Headers attached to every render, see DefaultSecurityHeaders. Defaults to nil.
+	SecurityHeaders *SecurityHeaders
+	// Header carrying request IDs, e.g. "X-Request-ID". When set, renders echo the ID on the response, templates get the requestID func, and error pages and bodies show it. Default is blank.
+	RequestIDHeader string
+	// Generates IDs for requests arriving without one. Defaults to 16 bytes from Random in hex.
+	RequestIDGenerator func() string
+	// Freezes the clock at TestTime, makes CSP nonces and generated request IDs constant, and leaves asset URLs unfingerprinted and sri blank, so golden file tests of rendered output are stable. Default is false.
+	TestMode bool
+	// Returns the current time for signatures, Server-Timing, archive entries, and recorded responses. Defaults to time.Now, or TestTime in TestMode.
+	Clock func() time.Time
+	// Source of CSP nonces, generated request IDs, and AuditBodySample draws. Defaults to crypto/rand.Reader, or zeros in TestMode.
+	Random io.Reader
+	// Cross-origin policy applied to renders that know their request, and to Preflight. Defaults to nil.
+	CORS *CORS
+	// Content-Security-Policy sent with HTML renders. "{nonce}" is replaced with the response's CSP nonce. Default is blank.
+	ContentSecurityPolicy string
+	// Static assets read by the asset template funcs such as sri. Defaults to nil.
+	Assets fs.FS
+	// Precomputed SRI values by asset path, see ComputeIntegrity. Missing entries are hashed from Assets. Defaults to nil.
+	Integrity map[string]string
+	// Path of a Vite or esbuild manifest within Assets mapping source assets to fingerprinted files for the asset template func. Default is blank.
+	AssetManifest string
+	// Largest asset in bytes the inlineCSS, inlineJS, and dataURI template funcs embed. Default is 16384.
+	InlineMaxBytes int
+	// URL prefix of built asset files. Default is "/".
+	AssetPrefix string
+	// Front-end dev server, e.g. "http://localhost:5173", that the asset template func points at when IsDevelopment is set. Default is blank.
+	AssetDevServer string
+	// Sanitizer used by the sanitize and safeHTML template funcs. Defaults to EscapeSanitizer.
+	Sanitizer Sanitizer
+	// Named sanitizer policies used by the sanitizeWith template func. Defaults to nil.
+	Sanitizers map[string]Sanitizer
+	// Returns the CSRF token for a request for the csrfToken and csrfField template funcs, e.g. csrf.Token or nosurf.Token. Defaults to nil.
+	CSRFToken func(*http.Request) string
+	// Form field name emitted by csrfField. Default is "csrf_token".
+	CSRFFieldName string
+	// Store backing Flash and the flashes template func. Defaults to an unsigned CookieFlashStore.
+	FlashStore FlashStore
+	// Class added to form fields with validation errors by the form template funcs. Default is "error".
+	FormErrorClass string
+	// Named cell formatters for the table template func, selected by a field's format tag. Defaults to nil.
+	TableFormats map[string]func(interface{}) string
+	// Query parameter used for sortable columns by the table template func. Default is "sort".
+	TableSortParam string
+	// Class attribute of tables rendered by the table template func. Default is blank.
+	TableClass string
+	// Renders JSON as a browsable HTML page for requests preferring text/html. Requires JSONOptions.Request. Default is false.
+	BrowsableAPI bool
+	// Path of the live reload endpoint served by LiveReloadHandler. Its script is injected into HTML renders when IsDevelopment is set. Default is "/_renderall/livereload".
+	LiveReloadPath string
+	// Disables the live reload script injected in development mode. Default is false.
+	DisableLiveReload bool
+	// Deadline for each write of held open responses, live reload events and long poll heartbeats, so stalled clients are dropped. Default is 0, none.
+	StreamWriteTimeout time.Duration
+	// Hooks run before every render, in order. Defaults to [].
+	PreRender []func(*RenderContext) error
+	// Hooks run on the rendered body before it is sent, in order. Responses are buffered while set. Defaults to [].
+	PostRender []func(*RenderContext) error
+	// Data every HTML render sees, e.g. the current user or nav. Map results are merged into map bindings, nil bindings are replaced, and templates can always call global. The request is nil for renders not given one. Defaults to nil.
+	GlobalData func(req *http.Request) interface{}
+	// Evaluates feature flags for the flag template func, e.g. {{ if flag "new-nav" }}. Flags are off while nil. Defaults to nil.
+	FlagProvider FlagProvider
+	// Builds URLs of named routes for the url template func, e.g. {{ url "user.show" .ID }}. See Routes. Defaults to nil.
+	URLBuilder URLBuilder
+	// Converts rendered HTML to PDF for PDF responses, e.g. WKHTMLToPDF("wkhtmltopdf"). Defaults to nil.
+	PDFConverter PDFConverter
+	// Layout template name for the HTML part of emails. Will not render a layout if blank (""). Defaults to blank ("").
+	EmailLayout string
+	// Inlines the stylesheets of rendered email HTML into style attributes, e.g. by wrapping a premailer library. Defaults to nil.
+	EmailCSSInliner func(html string) (string, error)
+	// Alternate template variants pages may have, e.g. ["amp"] for article.amp next to article, linked by the alternateLinks template func. Defaults to [].
+	Variants []string
+	// VariantResolver picks the variant for a request. Blank, or a variant the page lacks, renders the page itself. Defaults to nil.
+	VariantResolver func(*http.Request) string
+	// Builds the URL of a variant of the requested page, or of the page itself for a blank variant. Defaults to setting the "variant" query parameter.
+	VariantURL func(req *http.Request, variant string) string
+	// Scheme and host of the absolute URLs the alternateLinks template func renders, e.g. "https://example.com". Defaults to blank, which renders root-relative URLs unless TrustHost is set.
+	BaseURL string
+	// Builds absolute URLs from the request's Host header when BaseURL is blank. Only set it when a proxy in front validates Host, as the URLs end up in cached pages. Default is false.
+	TrustHost bool
+	// Site wide page meta for the metaTags template func, which pages override through HTMLOptions.Meta or MetaProvider bindings. Defaults to empty.
+	Meta Meta
+	// Navigation tree for the nav and breadcrumbs template funcs, marked active for the request path. Defaults to nil.
+	Nav *Nav
+	// Order in which Auto consults the request for a format. Default is [FormatQuery, FormatExtension, FormatAccept].
+	FormatPrecedence []FormatSource
+	// Query parameter Auto reads the format from. Default is "format".
+	FormatParam string
+	// Format Auto uses when the request expresses no usable preference. Default is "json".
+	DefaultFormat string
+}
+
+// Delims represents a set of Left and Right delimiters for HTML template rendering.
+type Delims struct {
+	// Left delimiter, defaults to {{.
+	Left string
+	// Right delimiter, defaults to }}.
+	Right string
+}
+
+// Included helper functions for use when rendering HTML. Except for the
+// layout funcs these are parse-time stand-ins, and Options.Funcs of the same
+// name replace them.
+var helperFuncs = template.FuncMap{
+	"yield": func() (string, error) {
+		return "", fmt.Errorf("yield called with no layout defined")
+	},
+	"partial": func() (string, error) {
+		return "", fmt.Errorf("partial called with no layout defined")
+	},
+	"current": func() (string, error) {
+		return "", nil
+	},
+	"errors": func() ValidationErrors {
+		return nil
+	},
+	"hasError": func(field string) bool {
+		return false
+	},
+	"fieldErrors": func(field string) []string {
+		return nil
+	},
+	"cspNonce": func() string {
+		return ""
+	},
+	"csrfToken": func() string {
+		return ""
+	},
+	"csrfField": func() template.HTML {
+		return ""
+	},
+	"flashes": func() []Flash {
+		return nil
+	},
+	"form": func(v interface{}) template.HTML {
+		return ""
+	},
+	"formField": func(v interface{}, name string) template.HTML {
+		return ""
+	},
+	"formFields": func(v interface{}) []FormField {
+		return nil
+	},
+	"table": func(rows interface{}) template.HTML {
+		return ""
+	},
+	"global": func() interface{} {
+		return nil
+	},
+	"flag": func(name string) bool {
+		return false
+	},
+	"nav": func() []NavLink {
+		return nil
+	},
+	"breadcrumbs": func() []NavLink {
+		return nil
+	},
+	"meta": func() Meta {
+		return Meta{}
+	},
+	"metaTags": func() template.HTML {
+		return ""
+	},
+	"variant": func() string {
+		return ""
+	},
+	"alternateLinks": func() template.HTML {
+		return ""
+	},
+	"requestID": func() string {
+		return ""
+	},
+}
+
+// layoutHelpers are the helperFuncs layouts replace at render time, which
+// keep precedence over Options.Funcs.
+var layoutHelpers = template.FuncMap{
+	"yield":   helperFuncs["yield"],
+	"partial": helperFuncs["partial"],
+	"current": helperFuncs["current"],
 }
 
 // HTMLOptions is a struct for overriding some rendering Options for specific HTML call.
 type HTMLOptions struct {
-	// Layout template name. Overrides Options.Layout.
+	// Layout template name. Overrides Options.Layout when not blank.
 	Layout string
+	// Renders without any layout, even if Options.Layout is set.
+	NoLayout bool
+	// Request being served, used by request-scoped template funcs. Defaults to nil.
+	Request *http.Request
+	// Theme to render with. Overrides Options.ThemeResolver when not blank.
+	Theme string
+	// Page meta laid over Options.Meta and the binding's for the metaTags template func. Defaults to nil.
+	Meta *Meta
+	// Alternate variant to render, e.g. "amp" for article.amp in place of article. Overrides Options.VariantResolver when not blank.
+	Variant string
+}
+
+// XMLOptions is a struct for overriding some rendering Options for specific XML call.
+type XMLOptions struct {
+	// Request being served, used for PrettyParam. Defaults to nil.
+	Request *http.Request
+	// Root element name, replacing the one encoding/xml would pick. Defaults to blank ("").
+	Root string
+	// Default namespace of the root element. Overrides Options.XMLNamespace when not blank.
+	Namespace string
+	// Writes the <?xml?> prolog even if Options.XMLProlog is false.
+	Prolog bool
+}
+
+// DataOptions is a struct for overriding some rendering Options for specific Data call.
+type DataOptions struct {
+	// Request being served, whose Range header is honoured when Options.DataRanges is set. Defaults to nil.
+	Request *http.Request
+	// File name whose extension picks the content type through MIMEType when the handler has not set one, e.g. "app.wasm". Defaults to blank ("").
+	Name string
+}
+
+// JSONOptions is a struct for overriding some rendering Options for specific JSON call.
+type JSONOptions struct {
+	// Wraps the response in an Envelope. Overrides Options.Envelope.
+	Envelope bool
+	// Disables the Envelope for this call. Takes precedence over Envelope.
+	Raw bool
+	// Request handed to the MetaFuncs. Defaults to nil.
+	Request *http.Request
+	// Meta is merged into the envelope meta after the MetaFuncs have run.
+	Meta map[string]interface{}
+	// Errors populates the envelope errors.
+	Errors []interface{}
 }
 
 // New constructs a new Render instance with the supplied options.
@@ -85,19 +412,84 @@ func New(options ...Options) *Render {
 		o = options[0]
 	}
 
+	// Break out if parsing fails. We don't want any silent server starts.
+	return Must(newRender(o, (*Render).compileTemplates))
+}
+
+// NewE is like New but validates the options and returns configuration and
+// template errors instead of panicking.
+func NewE(o Options) (*Render, error) {
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+	return newRender(o, (*Render).Preload)
+}
+
+// Must panics if err is not nil and returns r otherwise, for wiring such as
+// renderall.Must(renderall.NewE(opts)) in main.
+func Must(r *Render, err error) *Render {
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+func newRender(o Options, compile func(*Render) error) (*Render, error) {
 	r := Render{
 		opt: o,
 	}
 	r.opt.Charset = defaultCharset
 	r.prepareOptions()
-	//r.compileTemplates()
+
+	if err := compile(&r); err != nil {
+		return nil, err
+	}
+	if r.opt.Loader != nil && r.opt.ReloadTemplates {
+		go r.watchTemplates()
+	}
 
 	// Create a new buffer pool for writing templates into.
 	if bufPool == nil {
 		bufPool = NewBufferPool(64)
 	}
 
-	return &r
+	return &r, nil
+}
+
+// validate reports options New would silently accept but cannot work.
+func (o Options) validate() error {
+	if (o.Asset == nil) != (o.AssetNames == nil) {
+		return fmt.Errorf("renderall: Options.Asset and Options.AssetNames must be set together")
+	}
+	if o.Extensions != nil && len(o.Extensions) == 0 {
+		return fmt.Errorf("renderall: Options.Extensions is empty")
+	}
+	for _, ext := range o.Extensions {
+		if !strings.HasPrefix(ext, ".") {
+			return fmt.Errorf("renderall: extension %q must start with a dot", ext)
+		}
+	}
+	if o.Loader != nil || o.Asset != nil {
+		return nil
+	}
+
+	dirs := []string{o.Directory}
+	if o.Directory == "" {
+		dirs[0] = "templates"
+	}
+	for _, roots := range o.Themes {
+		dirs = append(dirs, roots...)
+	}
+	for _, dir := range dirs {
+		info, err := os.Stat(dir)
+		if err != nil {
+			return fmt.Errorf("renderall: template directory: %w", err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("renderall: template directory %q is not a directory", dir)
+		}
+	}
+	return nil
 }
 
 func (r *Render) prepareOptions() {
@@ -116,6 +508,327 @@ func (r *Render) prepareOptions() {
 	if len(r.opt.HTMLContentType) == 0 {
 		r.opt.HTMLContentType = ContentHTML
 	}
+	if len(r.opt.CSRFFieldName) == 0 {
+		r.opt.CSRFFieldName = defaultCSRFField
+	}
+	if len(r.opt.FormErrorClass) == 0 {
+		r.opt.FormErrorClass = "error"
+	}
+	if len(r.opt.TableSortParam) == 0 {
+		r.opt.TableSortParam = "sort"
+	}
+	if len(r.opt.AssetPrefix) == 0 {
+		r.opt.AssetPrefix = "/"
+	}
+	if len(r.opt.LiveReloadPath) == 0 {
+		r.opt.LiveReloadPath = defaultLiveReloadPath
+	}
+	if len(r.opt.FormatParam) == 0 {
+		r.opt.FormatParam = "format"
+	}
+	if len(r.opt.DefaultFormat) == 0 {
+		r.opt.DefaultFormat = "json"
+	}
+	r.formats = r.builtinFormats()
+	r.prepareMIMETypes()
+}
+
+func (r *Render) compileTemplates() error {
+	tmpl, text, err := r.compileDefault()
+	if err != nil {
+		return err
+	}
+	themes := make(map[string]*template.Template, len(r.opt.Themes))
+	for name, roots := range r.opt.Themes {
+		if themes[name], _, err = r.compileSet(roots); err != nil {
+			return err
+		}
+	}
+
+	r.lock.Lock()
+	r.templates = tmpl
+	r.textTemplates = text
+	r.themes = themes
+	r.lock.Unlock()
+	return nil
+}
+
+// compileDefault compiles the default sets from the swapped in templates, if
+// any, then Options.Loader, then Directory.
+func (r *Render) compileDefault() (*template.Template, *texttemplate.Template, error) {
+	name, walk := r.walkDefault()
+	return r.compile(name, walk)
+}
+
+// walkDefault returns the name and walk of the default set's sources.
+func (r *Render) walkDefault() (string, func(parseFunc) error) {
+	r.lock.RLock()
+	l := r.loader
+	r.lock.RUnlock()
+	if l == nil {
+		l = r.opt.Loader
+	}
+	if l != nil {
+		return ".", r.walkLoader(l)
+	}
+	return r.opt.Directory, r.walkSet([]string{r.opt.Directory})
+}
+
+// parseFunc adds the template file name with contents buf to a set.
+type parseFunc func(name string, buf []byte) error
+
+// compile builds an HTML set and a text set from the files walk passes to
+// its parseFunc.
+func (r *Render) compile(name string, walk func(parseFunc) error) (*template.Template, *texttemplate.Template, error) {
+	tmpl, err := r.newSet(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	text := r.newTextSet(name)
+	err = walk(func(name string, buf []byte) error {
+		if err := r.parseTemplate(tmpl, name, buf); err != nil {
+			return err
+		}
+		return r.parseText(text, name, buf)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return tmpl, text, nil
+}
+
+// compileSet compiles the template roots into one set. Roots are listed
+// highest precedence first, so templates in earlier roots replace those of
+// the same name in later ones.
+func (r *Render) compileSet(roots []string) (*template.Template, *texttemplate.Template, error) {
+	return r.compile(roots[0], r.walkSet(roots))
+}
+
+// walkSet returns a walk over the template roots, lowest precedence first.
+func (r *Render) walkSet(roots []string) func(parseFunc) error {
+	return func(parse parseFunc) error {
+		for i := len(roots) - 1; i >= 0; i-- {
+			var err error
+			if r.opt.Asset == nil || r.opt.AssetNames == nil {
+				err = r.compileTemplatesFromDir(roots[i], parse)
+			} else {
+				err = r.compileTemplatesFromAsset(roots[i], parse)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// newSet returns an empty set holding only the built-in templates.
+func (r *Render) newSet(name string) (*template.Template, error) {
+	tmpl := template.New(name)
+	tmpl.Delims(r.opt.Delims.Left, r.opt.Delims.Right)
+	return tmpl, r.addBuiltinTemplates(tmpl)
+}
+
+func (r *Render) compileTemplatesFromDir(dir string, parse parseFunc) error {
+	// Walk the supplied directory and compile any files that match our extension list.
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		// Directories are never templates, even if they are named like one ("users.tmpl").
+		if info == nil || info.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		ext := ""
+		if strings.Index(rel, ".") != -1 {
+			ext = filepath.Ext(rel)
+		}
+
+		for _, extension := range r.opt.Extensions {
+			if ext == extension {
+				buf, err := ioutil.ReadFile(path)
+				if err != nil {
+					return err
+				}
+
+				name := (rel[0 : len(rel)-len(ext)])
+				return parse(filepath.ToSlash(name), buf)
+			}
+		}
+		return nil
+	})
+}
+
+func (r *Render) compileTemplatesFromAsset(dir string, parse parseFunc) error {
+	for _, path := range r.opt.AssetNames() {
+		if !strings.HasPrefix(path, dir) {
+			continue
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		ext := ""
+		if strings.Index(rel, ".") != -1 {
+			ext = "." + strings.Join(strings.Split(rel, ".")[1:], ".")
+		}
+
+		for _, extension := range r.opt.Extensions {
+			if ext == extension {
+				buf, err := r.opt.Asset(path)
+				if err != nil {
+					return err
+				}
+
+				name := (rel[0 : len(rel)-len(ext)])
+				if err := parse(filepath.ToSlash(name), buf); err != nil {
+					return err
+				}
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// parseTemplate adds a named template to the set with our funcmaps applied.
+func (r *Render) parseTemplate(set *template.Template, name string, buf []byte) error {
+	tmpl := set.New(name)
+	tmpl.Funcs(helperFuncs)
+	tmpl.Funcs(r.builtinFuncs())
+
+	// Add our funcmaps, which win over built-ins of the same name.
+	for _, funcs := range r.opt.Funcs {
+		tmpl.Funcs(funcs)
+	}
+
+	_, err := tmpl.Funcs(layoutHelpers).Parse(string(buf))
+	return err
+}
+
+// builtin drops the funcs of m that Options.Funcs defines, so user funcs win
+// over built-ins of the same name at render time as they do at parse time.
+func (r *Render) builtin(m template.FuncMap) template.FuncMap {
+	for _, funcs := range r.opt.Funcs {
+		for name := range funcs {
+			delete(m, name)
+		}
+	}
+	return m
+}
+
+// SwapTemplates compiles the templates in fsys and, if they all parse,
+// atomically replaces the default template set, and its text twin, with
+// them. On failure the current set stays in place. Themes are left untouched.
+func (r *Render) SwapTemplates(fsys fs.FS) error {
+	l := FSLoader(fsys)
+	tmpl, text, err := r.compileLoader(l)
+	if err != nil {
+		return err
+	}
+
+	r.lock.Lock()
+	r.templates = tmpl
+	r.textTemplates = text
+	r.loader = l
+	r.lock.Unlock()
+	return nil
+}
+
+// TemplateLookup is a wrapper around template.Lookup and returns
+// the template with the given name that is associated with t, or nil
+// if there is no such template.
+func (r *Render) TemplateLookup(t string) *template.Template {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.templates.Lookup(t)
+}
+
+// cloneTemplates returns a private copy of the compiled template set for
+// theme, or of the default set if theme is blank or unknown. The shared sets
+// are never executed themselves so per-render funcs can be attached to the
+// copy without racing other renders.
+func (r *Render) cloneTemplates(theme string) (*template.Template, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	if tmpl, ok := r.themes[theme]; ok {
+		return tmpl.Clone()
+	}
+	return r.templates.Clone()
+}
+
+// pretty reports whether req asks for indented output through PrettyParam.
+// A bare ?pretty counts as true.
+func (r *Render) pretty(req *http.Request) bool {
+	if r.opt.PrettyParam == "" || req == nil {
+		return false
+	}
+	q := req.URL.Query()
+	if _, ok := q[r.opt.PrettyParam]; !ok {
+		return false
+	}
+	v := q.Get(r.opt.PrettyParam)
+	on, err := strconv.ParseBool(v)
+	return v == "" || (err == nil && on)
+}
+
+// theme resolves the theme for an HTML render.
+func (r *Render) theme(opt HTMLOptions) string {
+	if opt.Theme != "" {
+		return opt.Theme
+	}
+	if r.opt.ThemeResolver != nil && opt.Request != nil {
+		return r.opt.ThemeResolver(opt.Request)
+	}
+	return ""
+}
+
+func execute(tmpl *template.Template, name string, binding interface{}) (*bytes.Buffer, error) {
+	buf := new(bytes.Buffer)
+	return buf, tmpl.ExecuteTemplate(buf, name, binding)
+}
+
+func (r *Render) layoutFuncs(tmpl *template.Template, name string, binding interface{}) template.FuncMap {
+	return template.FuncMap{
+		"yield": func() (template.HTML, error) {
+			defer r.trackTemplate(name, time.Now())
+			buf, err := execute(tmpl, name, binding)
+			// Return safe HTML here since we are rendering our own template.
+			return template.HTML(buf.String()), err
+		},
+		"current": func() (string, error) {
+			return name, nil
+		},
+		"partial": func(partialName string) (template.HTML, error) {
+			fullPartialName := fmt.Sprintf("%s-%s", partialName, name)
+			if r.opt.RequireBlocks || tmpl.Lookup(fullPartialName) != nil {
+				defer r.trackTemplate(fullPartialName, time.Now())
+				buf, err := execute(tmpl, fullPartialName, binding)
+				// Return safe HTML here since we are rendering our own template.
+				return template.HTML(buf.String()), err
+			}
+			return "", nil
+		},
+	}
+}
+
+func (r *Render) prepareHTMLOptions(htmlOpt []HTMLOptions) HTMLOptions {
+	opt := HTMLOptions{}
+	if len(htmlOpt) > 0 {
+		opt = htmlOpt[0]
+	}
+	if len(opt.Layout) == 0 {
+		opt.Layout = r.opt.Layout
+	}
+	if opt.NoLayout {
+		opt.Layout = ""
+	}
+	return opt
 }
 
 // Render is a service that provides functions for easily writing JSON, XML,
@@ -123,6 +836,20 @@ func (r *Render) prepareOptions() {
 type Render struct {
 	// Customize Secure with an Options struct.
 	opt             Options
+	templates       *template.Template
+	textTemplates   *texttemplate.Template
+	themes          map[string]*template.Template
+	loader          TemplateLoader
+	lock            sync.RWMutex
+	sriCache        sync.Map
+	inlineCache     sync.Map
+	assetManifest   assetManifest
+	formats         []*format
+	imageFormats    map[string]imageFormat
+	reload          liveReload
+	maintenance     *maintenanceMode
+	recorder        recorder
+	usage           templateUsage
 	compiledCharset string
 }
 
@@ -134,6 +861,10 @@ type Head struct {
 // Data built-in renderer.
 type Data struct {
 	Head
+	// Sniff detects the content type from the data when the handler has not set one.
+	Sniff bool
+	// Ranges, if set, is the request whose Range header a 200 response honours.
+	Ranges *http.Request
 }
 
 // Engine is the generic interface for all responses.
@@ -146,6 +877,10 @@ type HTML struct {
 	Head
 	Name      string
 	Templates *template.Template
+	// Inject is inserted before the closing body tag, e.g. dev tooling scripts.
+	Inject []byte
+	// Nonce is the CSP nonce the templates were given, see RenderContext.Nonce.
+	Nonce string
 }
 
 // JSON built-in renderer.
@@ -155,6 +890,10 @@ type JSON struct {
 	UnEscapeHTML  bool
 	Prefix        []byte
 	StreamingJSON bool
+	// StreamThreshold, when positive, buffers up to that many bytes and streams the rest.
+	StreamThreshold int
+	Canonical       bool
+	Hook            MarshalHook
 }
 
 // JSONP built-in renderer.
@@ -162,6 +901,22 @@ type JSONP struct {
 	Head
 	Indent   bool
 	Callback string
+	Hook     MarshalHook
+}
+
+// XML built-in renderer.
+type XML struct {
+	Head
+	Indent bool
+	Prefix []byte
+	Hook   MarshalHook
+	// Root renames the root element and Namespace sets its default namespace.
+	Root      string
+	Namespace string
+	// Prolog is written before the document, after Prefix.
+	Prolog string
+	// IndentWidth is the number of spaces per level when Indent is set, 2 if zero.
+	IndentWidth int
 }
 
 // Write outputs the header content.
@@ -172,180 +927,590 @@ func (h Head) Write(w http.ResponseWriter) {
 
 // Render a data response.
 func (d Data) Render(w http.ResponseWriter, v interface{}) error {
-	c := w.Header().Get(ContentType)
-	if c != "" {
+	b := v.([]byte)
+	if c := w.Header().Get(ContentType); c != "" {
 		d.Head.ContentType = c
+	} else {
+		d.Head.ContentType = d.contentType(b)
+	}
+
+	if d.Ranges != nil && d.Head.Status == http.StatusOK {
+		// ServeContent handles single and multipart/byteranges responses,
+		// If-Range, and 416s, and treats a missing Range as a plain 200.
+		w.Header().Set(ContentType, d.Head.ContentType)
+		http.ServeContent(w, d.Ranges, "", time.Time{}, bytes.NewReader(b))
+		return nil
 	}
 
 	d.Head.Write(w)
-	w.Write(v.([]byte))
+	w.Write(b)
 	return nil
 }
 
+// Encode writes the raw bytes to w.
+func (d Data) Encode(w io.Writer, v interface{}) (Encoded, error) {
+	b := v.([]byte)
+	n, err := w.Write(b)
+	return Encoded{ContentType: d.contentType(b), Length: int64(n)}, err
+}
+
+// contentType is the configured type, or b's sniffed one with Sniff set.
+func (d Data) contentType(b []byte) string {
+	if d.Sniff {
+		return sniffContentType(b)
+	}
+	return d.Head.ContentType
+}
+
 // Render a HTML response.
 func (h HTML) Render(w http.ResponseWriter, binding interface{}) error {
-	// Retrieve a buffer from the pool to write to.
-	out := bufPool.Get()
-	err := h.Templates.ExecuteTemplate(out, h.Name, binding)
-	if err != nil {
-		return err
-	}
+	return renderEncoded(w, h.Head, h, binding)
+}
 
-	h.Head.Write(w)
-	out.WriteTo(w)
+// Encode executes the template into w.
+func (h HTML) Encode(w io.Writer, binding interface{}) (Encoded, error) {
+	return encodeBuffered(w, h, h.Head.ContentType, binding)
+}
 
-	// Return the buffer to the pool.
-	bufPool.Put(out)
+// encodeBuffer executes the template into out.
+func (h HTML) encodeBuffer(out *bytes.Buffer, binding interface{}) error {
+	if err := h.Templates.ExecuteTemplate(out, h.Name, binding); err != nil {
+		return err
+	}
+	if len(h.Inject) > 0 {
+		injectBeforeBodyEnd(out, h.Inject)
+	}
 	return nil
 }
 
 // Render a JSON response.
 func (j JSON) Render(w http.ResponseWriter, v interface{}) error {
-	if j.StreamingJSON {
-		return j.renderStreamingJSON(w, v)
+	v, err := prepareJSON(j.Hook, v)
+	if err != nil {
+		return err
 	}
 
-	var result []byte
-	var err error
-
-	if j.Indent {
-		result, err = json.MarshalIndent(v, "", "  ")
-		result = append(result, '\n')
-	} else {
-		result, err = json.Marshal(v)
+	if j.StreamThreshold > 0 && !j.Canonical {
+		return j.renderHybridJSON(w, v)
 	}
-	if err != nil {
-		return err
+	if j.StreamingJSON && !j.Canonical {
+		return j.renderStreamingJSON(w, v)
 	}
 
-	// Unescape HTML if needed.
-	if j.UnEscapeHTML {
-		result = bytes.Replace(result, []byte("\\u003c"), []byte("<"), -1)
-		result = bytes.Replace(result, []byte("\\u003e"), []byte(">"), -1)
-		result = bytes.Replace(result, []byte("\\u0026"), []byte("&"), -1)
+	s := getJSONState()
+	defer putJSONState(s)
+	if err := j.marshal(s, v); err != nil {
+		return err
 	}
 
 	// JSON marshaled fine, write out the result.
 	j.Head.Write(w)
-	if len(j.Prefix) > 0 {
-		w.Write(j.Prefix)
+	w.Write(s.buf.Bytes())
+	return nil
+}
+
+// Encode writes the JSON document to w. Streaming, with or without a
+// threshold, encodes straight into w, as there is no header to hold back.
+func (j JSON) Encode(w io.Writer, v interface{}) (Encoded, error) {
+	v, err := prepareJSON(j.Hook, v)
+	if err != nil {
+		return Encoded{}, err
+	}
+
+	enc := Encoded{ContentType: j.Head.ContentType}
+	if (j.StreamingJSON || j.StreamThreshold > 0) && !j.Canonical {
+		cw := &countWriter{Writer: w}
+		err := j.stream(cw, v)
+		enc.Length = cw.n
+		return enc, err
+	}
+
+	s := getJSONState()
+	defer putJSONState(s)
+	if err := j.marshal(s, v); err != nil {
+		return Encoded{}, err
+	}
+	n, err := w.Write(s.buf.Bytes())
+	enc.Length = int64(n)
+	return enc, err
+}
+
+// marshal encodes the prefix and body into s's buffer so they go out in a
+// single write.
+func (j JSON) marshal(s *jsonState, v interface{}) error {
+	s.buf.Write(j.Prefix)
+	if j.Canonical {
+		result, err := json.Marshal(v)
+		if err == nil {
+			result, err = Canonicalize(result)
+		}
+		if err != nil {
+			return err
+		}
+		s.buf.Write(result)
+		return nil
+	}
+
+	s.enc.SetEscapeHTML(!j.UnEscapeHTML)
+	if j.Indent {
+		s.enc.SetIndent("", "  ")
+	} else {
+		s.enc.SetIndent("", "")
+	}
+	if err := s.enc.Encode(v); err != nil {
+		return err
+	}
+	// Encode always ends with a newline; compact output never had one.
+	if !j.Indent {
+		s.buf.Truncate(s.buf.Len() - 1)
 	}
-	w.Write(result)
 	return nil
 }
 
 func (j JSON) renderStreamingJSON(w http.ResponseWriter, v interface{}) error {
 	j.Head.Write(w)
+	return j.stream(w, v)
+}
+
+// stream encodes the prefix and body straight into w.
+func (j JSON) stream(w io.Writer, v interface{}) error {
 	if len(j.Prefix) > 0 {
 		w.Write(j.Prefix)
 	}
 
-	return json.NewEncoder(w).Encode(v)
+	// Match the buffered output's formatting, which has no trailing newline
+	// when compact.
+	if !j.Indent {
+		w = trimNewlineWriter{w}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(!j.UnEscapeHTML)
+	if j.Indent {
+		enc.SetIndent("", "  ")
+	}
+	return enc.Encode(v)
+}
+
+// trimNewlineWriter drops the newline ending each write, for json.Encoder,
+// which writes a whole value and its newline at once.
+type trimNewlineWriter struct {
+	io.Writer
+}
+
+func (t trimNewlineWriter) Write(b []byte) (int, error) {
+	if _, err := t.Writer.Write(bytes.TrimSuffix(b, []byte{'\n'})); err != nil {
+		return 0, err
+	}
+	return len(b), nil
 }
 
 // Render a JSONP response.
 func (j JSONP) Render(w http.ResponseWriter, v interface{}) error {
-	var result []byte
-	var err error
+	s := getJSONState()
+	defer putJSONState(s)
+	if err := j.marshal(s, v); err != nil {
+		return err
+	}
 
-	if j.Indent {
-		result, err = json.MarshalIndent(v, "", "  ")
-	} else {
-		result, err = json.Marshal(v)
+	// JSON marshaled fine, write out the result.
+	w.Header().Set(ContentLength, strconv.Itoa(s.buf.Len()))
+	j.Head.Write(w)
+	w.Write(s.buf.Bytes())
+	return nil
+}
+
+// Encode writes the callback wrapped JSON to w.
+func (j JSONP) Encode(w io.Writer, v interface{}) (Encoded, error) {
+	s := getJSONState()
+	defer putJSONState(s)
+	if err := j.marshal(s, v); err != nil {
+		return Encoded{}, err
 	}
+	n, err := w.Write(s.buf.Bytes())
+	return Encoded{ContentType: j.Head.ContentType, Length: int64(n)}, err
+}
+
+// marshal assembles the callback, payload, and terminator in s's buffer.
+func (j JSONP) marshal(s *jsonState, v interface{}) error {
+	v, err := prepareJSON(j.Hook, v)
 	if err != nil {
 		return err
 	}
 
-	// JSON marshaled fine, write out the result.
-	j.Head.Write(w)
-	w.Write([]byte(j.Callback + "("))
-	w.Write(result)
-	w.Write([]byte(");"))
+	s.buf.WriteString(j.Callback)
+	s.buf.WriteByte('(')
+	s.enc.SetEscapeHTML(true)
+	if j.Indent {
+		s.enc.SetIndent("", "  ")
+	} else {
+		s.enc.SetIndent("", "")
+	}
+	if err := s.enc.Encode(v); err != nil {
+		return err
+	}
+	// Replace the newline Encode ends with by the terminator.
+	s.buf.Truncate(s.buf.Len() - 1)
+	s.buf.WriteString(");")
 
 	// If indenting, append a new line.
 	if j.Indent {
-		w.Write([]byte("\n"))
+		s.buf.WriteByte('\n')
 	}
 	return nil
 }
 
+// Render an XML response.
+func (x XML) Render(w http.ResponseWriter, v interface{}) error {
+	result, err := x.document(v)
+	if err != nil {
+		return err
+	}
+
+	// XML marshaled fine, write out the result.
+	x.Head.Write(w)
+	x.write(w, result)
+	return nil
+}
+
+// Encode writes the XML document to w.
+func (x XML) Encode(w io.Writer, v interface{}) (Encoded, error) {
+	result, err := x.document(v)
+	if err != nil {
+		return Encoded{}, err
+	}
+	cw := &countWriter{Writer: w}
+	err = x.write(cw, result)
+	return Encoded{ContentType: x.Head.ContentType, Length: cw.n}, err
+}
+
+// document marshals v, through its XMLMarshaler if it has one.
+func (x XML) document(v interface{}) ([]byte, error) {
+	v, err := applyHook(x.Hook, v)
+	if err != nil {
+		return nil, err
+	}
+	if m, ok := v.(XMLMarshaler); ok {
+		return m.RenderXML()
+	}
+	return x.marshal(v)
+}
+
+// write sends the prefix and prolog ahead of the marshaled result.
+func (x XML) write(w io.Writer, result []byte) error {
+	if len(x.Prefix) > 0 {
+		if _, err := w.Write(x.Prefix); err != nil {
+			return err
+		}
+	}
+	if len(x.Prolog) > 0 {
+		if _, err := io.WriteString(w, x.Prolog); err != nil {
+			return err
+		}
+	}
+	_, err := w.Write(result)
+	return err
+}
+
+// marshal encodes v with the configured root, namespace, and indent.
+func (x XML) marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := xml.NewEncoder(&buf)
+	if x.Indent {
+		width := x.IndentWidth
+		if width == 0 {
+			width = 2
+		}
+		enc.Indent("", strings.Repeat(" ", width))
+	}
+
+	var err error
+	if x.Root != "" || x.Namespace != "" {
+		name := xml.Name{Space: x.Namespace, Local: x.Root}
+		if name.Local == "" {
+			name.Local = xmlRootName(v)
+		}
+		err = enc.EncodeElement(v, xml.StartElement{Name: name})
+	} else {
+		err = enc.Encode(v)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if x.Indent {
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+// xmlRootName is the root element name encoding/xml would give v: its
+// XMLName tag, or failing that its type name.
+func xmlRootName(v interface{}) string {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil {
+		return "xml"
+	}
+	if t.Kind() == reflect.Struct {
+		if f, ok := t.FieldByName("XMLName"); ok && f.Type == reflect.TypeOf(xml.Name{}) {
+			tag := strings.Split(f.Tag.Get("xml"), ",")[0]
+			if i := strings.LastIndex(tag, " "); i >= 0 {
+				tag = tag[i+1:]
+			}
+			if tag != "" {
+				return tag
+			}
+		}
+	}
+	if t.Name() != "" {
+		return t.Name()
+	}
+	return "xml"
+}
+
 //engine
 // Render is the generic function called by XML, JSON, Data, HTML, and can be called by custom implementations.
 func (r *Render) Render(w http.ResponseWriter, e Engine, data interface{}) error {
-	err := e.Render(w, data)
-	if err != nil && !r.opt.DisableHTTPErrorRendering {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	return r.render(w, nil, e, data)
+}
+
+// render is Render for a known request, which may still be nil.
+func (r *Render) render(w http.ResponseWriter, req *http.Request, e Engine, data interface{}) error {
+	rw := NewResponseWriter(w)
+	return r.fail(rw, r.renderEngine(rw, req, e, data))
+}
+
+// renderEngine is render without the error response.
+func (r *Render) renderEngine(w http.ResponseWriter, req *http.Request, e Engine, data interface{}) error {
+	r.applySecurityHeaders(w.Header(), e)
+	if r.opt.CORS != nil && req != nil {
+		r.opt.CORS.Apply(w.Header(), req)
+	}
+	if id := r.RequestID(req); id != "" {
+		w.Header().Set(r.opt.RequestIDHeader, id)
+	}
+	if m := r.inMaintenance(req); m != nil {
+		return r.renderMaintenance(w, req, m)
+	}
+
+	ctx := &RenderContext{Request: req, Header: w.Header(), Engine: e, Data: data, Nonce: Nonce(req)}
+	if h, ok := e.(HTML); ok && h.Nonce != "" {
+		ctx.Nonce = h.Nonce
+	}
+	if err := r.preRender(ctx); err != nil {
+		return err
+	}
+
+	w = r.withTiming(w, req, e)
+	sw := &statusWriter{ResponseWriter: w}
+	w = sw
+	send := func(w http.ResponseWriter) error {
+		if r.buffered() {
+			return r.renderBuffered(w, ctx)
+		}
+		return ctx.Engine.Render(w, ctx.Data)
+	}
+	if r.audited(req) {
+		render := send
+		send = func(w http.ResponseWriter) error {
+			return r.audit(w, ctx, render)
+		}
+	}
+	var err error
+	if r.opt.RecordResponses > 0 {
+		err = r.record(w, ctx, send)
+	} else {
+		err = send(w)
+	}
+	if sw.dropped > 0 {
+		r.warn(req, &StatusError{Status: sw.status, Dropped: sw.dropped})
+	}
+	if err == nil {
+		err = sw.err
+	}
+	return err
+}
+
+// fail renders http.StatusInternalServerError, or http.StatusInsufficientStorage
+// for a ResponseTooLargeError, for a non-nil err unless disabled or the
+// response is already under way, and returns err.
+func (r *Render) fail(w http.ResponseWriter, err error) error {
+	if err != nil && !r.opt.DisableHTTPErrorRendering && !headerWritten(w) {
+		status := http.StatusInternalServerError
+		var tooLarge *ResponseTooLargeError
+		if errors.As(err, &tooLarge) {
+			status = http.StatusInsufficientStorage
+		}
+		http.Error(w, err.Error(), status)
 	}
 	return err
 }
 
 // Data writes out the raw bytes as binary data.
-func (r *Render) Data(w http.ResponseWriter, status int, v []byte) error {
+func (r *Render) Data(w http.ResponseWriter, status int, v []byte, dataOpt ...DataOptions) error {
+	opt := DataOptions{}
+	if len(dataOpt) > 0 {
+		opt = dataOpt[0]
+	}
+
 	head := Head{
 		ContentType: ContentBinary,
 		Status:      status,
 	}
+	if w.Header().Get(ContentType) == "" && (opt.Name != "" || r.opt.SniffData) {
+		head.ContentType = r.MIMEType(opt.Name, v)
+	}
 
 	d := Data{
 		Head: head,
 	}
+	if r.opt.DataRanges && opt.Request != nil && (opt.Request.Method == http.MethodGet || opt.Request.Method == http.MethodHead) {
+		d.Ranges = opt.Request
+		// If-Range needs the validator before the body is written.
+		if r.opt.ETag && status == http.StatusOK && w.Header().Get(ETagHeader) == "" {
+			w.Header().Set(ETagHeader, bodyETag(v))
+		}
+	}
 
-	return r.Render(w, d, v)
+	return r.render(w, opt.Request, d, v)
+}
+
+// DataWithType writes out the raw bytes as contentType, taking precedence
+// over a Content-Type the handler already set.
+func (r *Render) DataWithType(w http.ResponseWriter, status int, contentType string, v []byte, dataOpt ...DataOptions) error {
+	w.Header().Set(ContentType, contentType)
+	return r.Data(w, status, v, dataOpt...)
 }
 
 // HTML builds up the response from the specified template and bindings.
-// TODO: make work ;)
-/*
 func (r *Render) HTML(w http.ResponseWriter, status int, name string, binding interface{}, htmlOpt ...HTMLOptions) error {
+	return r.html(w, status, name, binding, nil, htmlOpt)
+}
+
+// html renders the named template with funcs added on top of the layout funcs.
+func (r *Render) html(w http.ResponseWriter, status int, name string, binding interface{}, funcs template.FuncMap, htmlOpt []HTMLOptions) error {
+	h, binding, opt, err := r.prepareHTML(w, status, name, binding, funcs, htmlOpt)
+	if err != nil {
+		return r.fail(w, err)
+	}
+	defer r.trackTemplate(h.Name, time.Now())
+	rw := NewResponseWriter(w)
+	return r.htmlFail(rw, opt.Request, r.renderEngine(rw, opt.Request, h, binding))
+}
+
+// prepareHTML builds the HTML engine for the named template, returning it
+// with the binding to execute it with and the resolved options.
+func (r *Render) prepareHTML(w http.ResponseWriter, status int, name string, binding interface{}, funcs template.FuncMap, htmlOpt []HTMLOptions) (HTML, interface{}, HTMLOptions, error) {
 	// If we are in development mode, recompile the templates on every HTML request.
 	if r.opt.IsDevelopment {
-		r.compileTemplates()
+		if err := r.compileTemplates(); err != nil {
+			return HTML{}, nil, HTMLOptions{}, err
+		}
 	}
 
 	opt := r.prepareHTMLOptions(htmlOpt)
+	tmpl, err := r.cloneTemplates(r.theme(opt))
+	if err != nil {
+		return HTML{}, nil, opt, err
+	}
+
+	if r.opt.FallbackTemplate != "" && tmpl.Lookup(name) == nil {
+		name = r.opt.FallbackTemplate
+	}
+	page := name
+	variant := r.variant(opt)
+	if variant != "" && tmpl.Lookup(name+"."+variant) != nil {
+		name += "." + variant
+	} else {
+		variant = ""
+	}
+	tmpl.Funcs(r.builtin(r.variantFuncs(tmpl, page, variant, opt.Request)))
+
+	global := r.globalData(opt.Request)
+	binding = mergeGlobal(binding, global)
+	tmpl.Funcs(r.builtin(globalFuncs(global)))
+	tmpl.Funcs(r.builtin(r.metaFuncs(opt, binding)))
+	tmpl.Funcs(r.layoutFuncs(tmpl, name, binding))
+	nonce, err := r.cspNonce(opt.Request)
+	if err != nil {
+		return HTML{}, nil, opt, err
+	}
+	tmpl.Funcs(r.builtin(r.requestFuncs(w, opt, nonce)))
+	if funcs != nil {
+		tmpl.Funcs(r.builtin(funcs))
+	}
+
 	// Assign a layout if there is one.
 	if len(opt.Layout) > 0 {
-		r.addLayoutFuncs(name, binding)
 		name = opt.Layout
 	}
 
 	head := Head{
-		ContentType: r.opt.HTMLContentType + r.compiledCharset,
+		ContentType: r.withCharset(r.opt.HTMLContentType),
 		Status:      status,
 	}
 
 	h := HTML{
 		Head:      head,
 		Name:      name,
-		Templates: r.templates,
+		Templates: tmpl,
+		Inject:    r.liveReloadScript(nonce),
+		Nonce:     nonce,
 	}
-
-	return r.Render(w, h, binding)
-} */
+	return h, binding, opt, nil
+}
 
 // JSON marshals the given interface object and writes the JSON response.
-func (r *Render) JSON(w http.ResponseWriter, status int, v interface{}) error {
+func (r *Render) JSON(w http.ResponseWriter, status int, v interface{}, jsonOpt ...JSONOptions) error {
+	opt := r.prepareJSONOptions(jsonOpt)
+	if err := r.validateSchema(opt.Request, v); err != nil {
+		return r.fail(w, err)
+	}
+	if opt.Envelope {
+		v = r.envelope(v, opt)
+	}
+	if r.browsable(opt.Request) {
+		return r.browsableJSON(w, status, opt.Request, v)
+	}
+	if r.opt.BrowsableAPI {
+		addVary(w.Header(), "Accept")
+	}
+
+	return r.render(w, opt.Request, r.jsonEngine(status, opt.Request), v)
+}
+
+// jsonEngine builds the JSON engine for a request, which may be nil.
+func (r *Render) jsonEngine(status int, req *http.Request) JSON {
 	head := Head{
-		ContentType: ContentJSON + r.compiledCharset,
+		ContentType: r.withCharset(ContentJSON),
 		Status:      status,
 	}
 
-	j := JSON{
-		Head:          head,
-		Indent:        r.opt.IndentJSON,
-		Prefix:        r.opt.PrefixJSON,
-		UnEscapeHTML:  r.opt.UnEscapeHTML,
-		StreamingJSON: r.opt.StreamingJSON,
+	return JSON{
+		Head:            head,
+		Indent:          r.opt.IndentJSON || r.pretty(req),
+		Prefix:          r.opt.PrefixJSON,
+		UnEscapeHTML:    r.opt.UnEscapeHTML,
+		StreamingJSON:   r.opt.StreamingJSON,
+		StreamThreshold: r.opt.StreamingJSONThreshold,
+		Canonical:       r.opt.CanonicalJSON,
+		Hook:            r.marshalHook(),
 	}
+}
 
-	return r.Render(w, j, v)
+// protocolJSON is jsonEngine for bodies whose format a protocol specifies,
+// which clients could not parse behind Options.PrefixJSON.
+func (r *Render) protocolJSON(status int, req *http.Request) JSON {
+	j := r.jsonEngine(status, req)
+	j.Prefix = nil
+	return j
 }
 
 // JSONP marshals the given interface object and writes the JSON response.
 func (r *Render) JSONP(w http.ResponseWriter, status int, callback string, v interface{}) error {
 	head := Head{
-		ContentType: ContentJSONP + r.compiledCharset,
+		ContentType: r.withCharset(ContentJSONP),
 		Status:      status,
 	}
 
@@ -353,6 +1518,41 @@ func (r *Render) JSONP(w http.ResponseWriter, status int, callback string, v int
 		Head:     head,
 		Indent:   r.opt.IndentJSON,
 		Callback: callback,
+		Hook:     r.marshalHook(),
 	}
 	return r.Render(w, j, v)
 }
+
+// XML marshals the given interface object and writes the XML response.
+func (r *Render) XML(w http.ResponseWriter, status int, v interface{}, xmlOpt ...XMLOptions) error {
+	opt := XMLOptions{}
+	if len(xmlOpt) > 0 {
+		opt = xmlOpt[0]
+	}
+	return r.render(w, opt.Request, r.xmlEngine(status, opt), v)
+}
+
+// xmlEngine builds the XML engine for the resolved options.
+func (r *Render) xmlEngine(status int, opt XMLOptions) XML {
+	head := Head{
+		ContentType: r.withCharset(ContentXML),
+		Status:      status,
+	}
+
+	x := XML{
+		Head:        head,
+		Indent:      r.opt.IndentXML || r.pretty(opt.Request),
+		Prefix:      r.opt.PrefixXML,
+		Hook:        r.marshalHook(),
+		Root:        opt.Root,
+		Namespace:   r.opt.XMLNamespace,
+		IndentWidth: r.opt.XMLIndentWidth,
+	}
+	if opt.Namespace != "" {
+		x.Namespace = opt.Namespace
+	}
+	if r.opt.XMLProlog || opt.Prolog {
+		x.Prolog = `<?xml version="1.0" encoding="` + r.opt.Charset + `"?>` + "\n"
+	}
+	return x
+}
@@ -0,0 +1,138 @@
+package renderall
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// testTemplates are the templates newTestRender renders from.
+var testTemplates = fstest.MapFS{
+	"hello.tmpl":  {Data: []byte(`<p>Hello {{ . }}</p>`)},
+	"funcs.tmpl":  {Data: []byte(`{{ flashes }} {{ requestID }}`)},
+	"layout.tmpl": {Data: []byte(`<html><body>{{ yield }}</body></html>`)},
+	"users.tmpl":  {Data: []byte(`<ul>{{ range . }}<li id="{{ .ID }}">{{ .Name }} &lt;{{ .Email }}&gt;{{ range .Tags }} {{ . }}{{ end }}</li>{{ end }}</ul>`)},
+}
+
+// newTestRender builds a Render over testTemplates unless o names its own
+// templates.
+func newTestRender(o Options) *Render {
+	if o.Loader == nil && o.Directory == "" && o.Asset == nil {
+		o.Loader = FSLoader(testTemplates)
+	}
+	return New(o)
+}
+
+func TestJSONModesMatch(t *testing.T) {
+	values := map[string]interface{}{
+		"slice":  []int{1, 2, 3},
+		"empty":  []string{},
+		"object": map[string]string{"a": "<b>"},
+		"string": "x",
+		"nil":    nil,
+	}
+	for _, indent := range []bool{false, true} {
+		modes := map[string]Options{
+			"buffered":  {IndentJSON: indent},
+			"streaming": {IndentJSON: indent, StreamingJSON: true},
+			"spilled":   {IndentJSON: indent, StreamingJSONThreshold: 1},
+			"held":      {IndentJSON: indent, StreamingJSONThreshold: 1 << 20},
+		}
+		for name, v := range values {
+			var want string
+			for mode, o := range modes {
+				rec := httptest.NewRecorder()
+				if err := newTestRender(o).JSON(rec, http.StatusOK, v); err != nil {
+					t.Fatal(err)
+				}
+				got := rec.Body.String()
+				if want == "" {
+					want = got
+				} else if got != want {
+					t.Errorf("indent=%v %s: %s output %q differs from %q", indent, name, mode, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestDataContentType(t *testing.T) {
+	r := newTestRender(Options{SniffData: true, MIMETypes: map[string]string{".glb": "model/gltf-binary"}})
+	tests := []struct {
+		name, set, want string
+	}{
+		{"", "", "text/html; charset=utf-8"},
+		{"app.wasm", "", "application/wasm"},
+		{"scene.glb", "", "model/gltf-binary"},
+		{"app.wasm", "text/plain", "text/plain"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		if tt.set != "" {
+			rec.Header().Set(ContentType, tt.set)
+		}
+		if err := r.Data(rec, http.StatusOK, []byte("<html>"), DataOptions{Name: tt.name}); err != nil {
+			t.Fatal(err)
+		}
+		if got := rec.Header().Get(ContentType); got != tt.want {
+			t.Errorf("Data(%q) Content-Type %q, want %q", tt.name, got, tt.want)
+		}
+		if got := rec.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+			t.Errorf("Data(%q) X-Content-Type-Options %q, want nosniff", tt.name, got)
+		}
+	}
+}
+
+func TestUserFuncsOverrideBuiltins(t *testing.T) {
+	r := newTestRender(Options{Funcs: []template.FuncMap{{
+		"flashes":   func() string { return "mine" },
+		"requestID": func() string { return "id" },
+	}}})
+	rec := httptest.NewRecorder()
+	if err := r.HTML(rec, http.StatusOK, "funcs", nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.Body.String(); got != "mine id" {
+		t.Errorf("got %q, want %q", got, "mine id")
+	}
+}
+
+func TestDataRanges(t *testing.T) {
+	body := []byte("0123456789")
+	tests := []struct {
+		rangeHeader string
+		code        int
+		body        string
+		contentType string
+	}{
+		{"", http.StatusOK, "0123456789", ContentBinary},
+		{"bytes=2-4", http.StatusPartialContent, "234", ContentBinary},
+		{"bytes=-3", http.StatusPartialContent, "789", ContentBinary},
+		{"bytes=8-", http.StatusPartialContent, "89", ContentBinary},
+		{"bytes=0-0,5-5", http.StatusPartialContent, "", "multipart/byteranges"},
+		{"bytes=20-30", http.StatusRequestedRangeNotSatisfiable, "", ""},
+	}
+	r := newTestRender(Options{DataRanges: true})
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/file", nil)
+		if tt.rangeHeader != "" {
+			req.Header.Set("Range", tt.rangeHeader)
+		}
+		rec := httptest.NewRecorder()
+		if err := r.Data(rec, http.StatusOK, body, DataOptions{Request: req}); err != nil {
+			t.Fatalf("Range %q: %v", tt.rangeHeader, err)
+		}
+		if rec.Code != tt.code {
+			t.Errorf("Range %q: status %d, want %d", tt.rangeHeader, rec.Code, tt.code)
+		}
+		if tt.body != "" && rec.Body.String() != tt.body {
+			t.Errorf("Range %q: body %q, want %q", tt.rangeHeader, rec.Body.String(), tt.body)
+		}
+		if got := rec.Header().Get(ContentType); !strings.HasPrefix(got, tt.contentType) {
+			t.Errorf("Range %q: Content-Type %q, want %q", tt.rangeHeader, got, tt.contentType)
+		}
+	}
+}
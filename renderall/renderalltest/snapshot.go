@@ -0,0 +1,107 @@
+// Package renderalltest provides helpers for testing handlers that render
+// with renderall, in the spirit of net/http/httptest.
+package renderalltest
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pandemicsyn/electrostatic/renderall"
+)
+
+// SnapshotDir is where snapshots are kept, relative to the test's package.
+var SnapshotDir = filepath.Join("testdata", "snapshots")
+
+// update is set by running the tests with -update. Tests using this package
+// must not define a flag of the same name.
+var update = flag.Bool("update", false, "rewrite renderalltest snapshots with the current output")
+
+// JSON compares a JSON response body to the snapshot called name, after
+// normalizing both with renderall.NormalizeJSON and the volatile JSONPath
+// expressions, e.g. "$.meta.request_id". Run the tests with -update to
+// create or replace the snapshot instead:
+//
+//	rec := httptest.NewRecorder()
+//	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/users/1", nil))
+//	renderalltest.JSON(t, "users/show", rec.Body.Bytes(), "$.data.created_at")
+func JSON(t testing.TB, name string, body []byte, volatile ...string) {
+	t.Helper()
+	got, err := renderall.NormalizeJSON(body, volatile...)
+	if err != nil {
+		t.Fatalf("renderalltest: snapshot %s: %v", name, err)
+	}
+	path := filepath.Join(SnapshotDir, filepath.FromSlash(name)+".json")
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("renderalltest: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("renderalltest: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		t.Fatalf("renderalltest: snapshot %s does not exist, run the tests with -update to create it", path)
+	}
+	if err != nil {
+		t.Fatalf("renderalltest: %v", err)
+	}
+	// Volatile paths may have been added since the snapshot was taken.
+	if want, err = renderall.NormalizeJSON(want, volatile...); err != nil {
+		t.Fatalf("renderalltest: snapshot %s: %v", path, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("renderalltest: response does not match snapshot %s, run the tests with -update to accept it:\n%s", path, diff(want, got))
+	}
+}
+
+// diff returns a line diff of a and b, with - for lines only in a and +
+// for lines only in b.
+func diff(a, b []byte) string {
+	x := strings.Split(strings.TrimSuffix(string(a), "\n"), "\n")
+	y := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+
+	// lcs[i][j] is the length of the longest common subsequence of x[i:] and y[j:].
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			switch {
+			case x[i] == y[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var out strings.Builder
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			fmt.Fprintf(&out, "  %s\n", x[i])
+			i++
+			j++
+		case i < len(x) && (j == len(y) || lcs[i+1][j] >= lcs[i][j+1]):
+			fmt.Fprintf(&out, "- %s\n", x[i])
+			i++
+		default:
+			fmt.Fprintf(&out, "+ %s\n", y[j])
+			j++
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,100 @@
+package renderalltest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// recorder is a testing.TB that records failures instead of reporting them.
+type recorder struct {
+	testing.TB
+	failures []string
+	fatal    bool
+}
+
+// stop unwinds a Fatalf out of the helper under test.
+type stop struct{}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.Errorf(format, args...)
+	r.fatal = true
+	panic(stop{})
+}
+
+// snapshot runs JSON against a recorder and returns its failures.
+func snapshot(t *testing.T, name, body string, volatile ...string) *recorder {
+	t.Helper()
+	rec := &recorder{TB: t}
+	func() {
+		defer func() {
+			if p := recover(); p != nil {
+				if _, ok := p.(stop); !ok {
+					panic(p)
+				}
+			}
+		}()
+		JSON(rec, name, []byte(body), volatile...)
+	}()
+	return rec
+}
+
+func TestJSON(t *testing.T) {
+	dir, up := SnapshotDir, *update
+	t.Cleanup(func() { SnapshotDir, *update = dir, up })
+	SnapshotDir = t.TempDir()
+
+	*update = false
+	if rec := snapshot(t, "users/show", `{"id":1}`); !rec.fatal || !strings.Contains(rec.failures[0], "-update") {
+		t.Errorf("missing snapshot: failures %q, want a fatal pointing at -update", rec.failures)
+	}
+
+	*update = true
+	if rec := snapshot(t, "users/show", `{"name":"Ada","id":1,"at":"2024-01-01"}`, "$.at"); len(rec.failures) > 0 {
+		t.Fatalf("-update: failures %q", rec.failures)
+	}
+	b, err := os.ReadFile(filepath.Join(SnapshotDir, "users", "show.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\n  \"at\": \"[REDACTED]\",\n  \"id\": 1,\n  \"name\": \"Ada\"\n}\n"; string(b) != want {
+		t.Errorf("snapshot file\n%s\nwant\n%s", b, want)
+	}
+
+	*update = false
+	tests := []struct {
+		body     string
+		volatile []string
+		fail     string
+	}{
+		// Key order, spacing, and volatile values do not matter.
+		{`{"id":1, "at":"2025-06-30","name":"Ada"}`, []string{"$.at"}, ""},
+		{`{"id":2,"at":"x","name":"Ada"}`, []string{"$.at"}, "-   \"id\": 1,"},
+		{`{"id":1,"at":"x","name":"Ada"}`, nil, "+   \"at\": \"x\","},
+		{`{"id":`, nil, "unexpected EOF"},
+	}
+	for _, tt := range tests {
+		rec := snapshot(t, "users/show", tt.body, tt.volatile...)
+		switch {
+		case tt.fail == "" && len(rec.failures) > 0:
+			t.Errorf("JSON(%s): failures %q", tt.body, rec.failures)
+		case tt.fail != "" && (len(rec.failures) == 0 || !strings.Contains(rec.failures[0], tt.fail)):
+			t.Errorf("JSON(%s): failures %q, want one containing %q", tt.body, rec.failures, tt.fail)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	got := diff([]byte("a\nb\nc\n"), []byte("a\nc\nd\n"))
+	if want := "  a\n- b\n  c\n+ d\n"; got != want {
+		t.Errorf("diff =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,49 @@
+package renderall
+
+import (
+	"encoding/hex"
+	"html/template"
+	"io"
+	"net/http"
+)
+
+// newRequestID returns 16 bytes from Options.Random in hex.
+func (r *Render) newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := io.ReadFull(r.random(), b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// RequestID returns the ID of req from the Options.RequestIDHeader request
+// header, generating one with Options.RequestIDGenerator when the client
+// sent none. A generated ID is set on the request header, so later renders
+// and handlers of the same request see it too. It is blank when the option
+// is unset or req is nil.
+func (r *Render) RequestID(req *http.Request) string {
+	if r.opt.RequestIDHeader == "" || req == nil {
+		return ""
+	}
+	if id := req.Header.Get(r.opt.RequestIDHeader); id != "" {
+		return id
+	}
+	generate := r.opt.RequestIDGenerator
+	if generate == nil {
+		generate = r.newRequestID
+	}
+	id := generate()
+	if id != "" {
+		req.Header.Set(r.opt.RequestIDHeader, id)
+	}
+	return id
+}
+
+// requestIDFuncs expose the request ID to templates.
+func (r *Render) requestIDFuncs(req *http.Request) template.FuncMap {
+	return template.FuncMap{
+		"requestID": func() string {
+			return r.RequestID(req)
+		},
+	}
+}
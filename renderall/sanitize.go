@@ -0,0 +1,50 @@
+package renderall
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+)
+
+// Sanitizer cleans untrusted HTML. A *bluemonday.Policy satisfies it.
+type Sanitizer interface {
+	Sanitize(s string) string
+}
+
+// SanitizerFunc adapts a function to the Sanitizer interface.
+type SanitizerFunc func(s string) string
+
+// Sanitize calls f(s).
+func (f SanitizerFunc) Sanitize(s string) string {
+	return f(s)
+}
+
+// EscapeSanitizer escapes all markup. It is used when no Sanitizer is configured.
+var EscapeSanitizer Sanitizer = SanitizerFunc(html.EscapeString)
+
+func (r *Render) sanitizer() Sanitizer {
+	if r.opt.Sanitizer != nil {
+		return r.opt.Sanitizer
+	}
+	return EscapeSanitizer
+}
+
+// sanitizeFuncs expose the sanitizers to templates. safeHTML is an alias of
+// sanitize for templates ported from engines using that name; neither ever
+// trusts its input without running it through a policy.
+func (r *Render) sanitizeFuncs() template.FuncMap {
+	sanitize := func(s string) template.HTML {
+		return template.HTML(r.sanitizer().Sanitize(s))
+	}
+	return template.FuncMap{
+		"sanitize": sanitize,
+		"safeHTML": sanitize,
+		"sanitizeWith": func(policy, s string) (template.HTML, error) {
+			p, ok := r.opt.Sanitizers[policy]
+			if !ok {
+				return "", fmt.Errorf("renderall: no sanitizer policy %q", policy)
+			}
+			return template.HTML(p.Sanitize(s)), nil
+		},
+	}
+}
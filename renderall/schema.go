@@ -0,0 +1,436 @@
+package renderall
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ResponseValidator checks a response body, decoded from JSON into the
+// generic interface{} form, against a contract. *Schema is the built-in
+// implementation; wrap a full JSON Schema library to cover more of the spec.
+type ResponseValidator interface {
+	Validate(v interface{}) error
+}
+
+// Schema is a JSON Schema supporting the keywords most response contracts
+// use: type, enum, const, properties, required, additionalProperties, items,
+// minItems, maxItems, minLength, maxLength, pattern, minimum, maximum,
+// exclusiveMinimum, exclusiveMaximum, allOf, anyOf, oneOf, OpenAPI's
+// nullable, and local $refs. Unknown keywords are ignored.
+type Schema struct {
+	root interface{}
+	node interface{}
+}
+
+// ParseSchema parses a JSON Schema document.
+func ParseSchema(b []byte) (*Schema, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return &Schema{root: doc, node: doc}, nil
+}
+
+// Ref returns the sub-schema at the JSON pointer, e.g.
+// "#/components/schemas/User" to validate against one schema of an OpenAPI
+// document. $refs in the sub-schema still resolve against the whole document.
+func (s *Schema) Ref(pointer string) (*Schema, error) {
+	node, err := s.resolve(pointer)
+	if err != nil {
+		return nil, err
+	}
+	return &Schema{root: s.root, node: node}, nil
+}
+
+func (s *Schema) resolve(pointer string) (interface{}, error) {
+	if !strings.HasPrefix(pointer, "#") {
+		return nil, fmt.Errorf("renderall: only local schema refs are supported, got %q", pointer)
+	}
+	node := s.root
+	for _, tok := range strings.Split(strings.TrimPrefix(pointer, "#"), "/")[1:] {
+		tok = strings.Replace(strings.Replace(tok, "~1", "/", -1), "~0", "~", -1)
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("renderall: schema ref %q not found", pointer)
+		}
+		if node, ok = m[tok]; !ok {
+			return nil, fmt.Errorf("renderall: schema ref %q not found", pointer)
+		}
+	}
+	return node, nil
+}
+
+// SchemaError is a single contract violation.
+type SchemaError struct {
+	// Path is a JSON pointer to the offending value.
+	Path    string
+	Message string
+}
+
+func (e SchemaError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// SchemaErrors is every violation found in a response.
+type SchemaErrors []SchemaError
+
+func (e SchemaErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return "renderall: response violates schema: " + strings.Join(msgs, "; ")
+}
+
+// Validate implements ResponseValidator.
+func (s *Schema) Validate(v interface{}) error {
+	var errs SchemaErrors
+	s.validate(s.node, v, "", &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (s *Schema) validate(node, v interface{}, path string, errs *SchemaErrors) {
+	fail := func(format string, args ...interface{}) {
+		p := path
+		if p == "" {
+			p = "/"
+		}
+		*errs = append(*errs, SchemaError{Path: p, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch n := node.(type) {
+	case bool:
+		if !n {
+			fail("no value is allowed")
+		}
+		return
+	case map[string]interface{}:
+		if ref, ok := n["$ref"].(string); ok {
+			target, err := s.resolve(ref)
+			if err != nil {
+				fail("%v", err)
+				return
+			}
+			s.validate(target, v, path, errs)
+			return
+		}
+		s.validateObject(n, v, path, errs, fail)
+	}
+}
+
+func (s *Schema) validateObject(n map[string]interface{}, v interface{}, path string, errs *SchemaErrors, fail func(string, ...interface{})) {
+	if v == nil && n["nullable"] == true {
+		return
+	}
+	if t, ok := n["type"]; ok && !typeMatches(t, v) {
+		fail("expected %v, got %s", t, jsonType(v))
+		return
+	}
+	if enum, ok := n["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if jsonEqual(e, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("value is not one of the enum")
+		}
+	}
+	if c, ok := n["const"]; ok && !jsonEqual(c, v) {
+		fail("value does not match const")
+	}
+
+	for _, sub := range schemaList(n["allOf"]) {
+		s.validate(sub, v, path, errs)
+	}
+	if subs := schemaList(n["anyOf"]); subs != nil && s.matching(subs, v) == 0 {
+		fail("value matches none of anyOf")
+	}
+	if subs := schemaList(n["oneOf"]); subs != nil {
+		if m := s.matching(subs, v); m != 1 {
+			fail("value matches %d of oneOf, want exactly 1", m)
+		}
+	}
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for _, name := range schemaStrings(n["required"]) {
+			if _, ok := val[name]; !ok {
+				fail("missing required property %q", name)
+			}
+		}
+		props, _ := n["properties"].(map[string]interface{})
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child := path + "/" + strings.Replace(strings.Replace(k, "~", "~0", -1), "/", "~1", -1)
+			if sub, ok := props[k]; ok {
+				s.validate(sub, val[k], child, errs)
+			} else if extra, ok := n["additionalProperties"]; ok {
+				if extra == false {
+					fail("unexpected property %q", k)
+				} else {
+					s.validate(extra, val[k], child, errs)
+				}
+			}
+		}
+	case []interface{}:
+		if min, ok := schemaNumber(n["minItems"]); ok && float64(len(val)) < min {
+			fail("expected at least %v items, got %d", min, len(val))
+		}
+		if max, ok := schemaNumber(n["maxItems"]); ok && float64(len(val)) > max {
+			fail("expected at most %v items, got %d", max, len(val))
+		}
+		if items, ok := n["items"]; ok {
+			for i, item := range val {
+				s.validate(items, item, fmt.Sprintf("%s/%d", path, i), errs)
+			}
+		}
+	case string:
+		length := float64(len([]rune(val)))
+		if min, ok := schemaNumber(n["minLength"]); ok && length < min {
+			fail("expected at least %v characters", min)
+		}
+		if max, ok := schemaNumber(n["maxLength"]); ok && length > max {
+			fail("expected at most %v characters", max)
+		}
+		if pattern, ok := n["pattern"].(string); ok {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				fail("invalid pattern %q: %v", pattern, err)
+			} else if !re.MatchString(val) {
+				fail("%q does not match pattern %q", val, pattern)
+			}
+		}
+	case json.Number:
+		f, _ := val.Float64()
+		if min, ok := schemaNumber(n["minimum"]); ok && f < min {
+			fail("%v is less than the minimum %v", val, min)
+		}
+		if max, ok := schemaNumber(n["maximum"]); ok && f > max {
+			fail("%v is greater than the maximum %v", val, max)
+		}
+		if min, ok := schemaNumber(n["exclusiveMinimum"]); ok && f <= min {
+			fail("%v is not greater than %v", val, min)
+		}
+		if max, ok := schemaNumber(n["exclusiveMaximum"]); ok && f >= max {
+			fail("%v is not less than %v", val, max)
+		}
+	}
+}
+
+// matching counts the schemas v satisfies.
+func (s *Schema) matching(subs []interface{}, v interface{}) int {
+	count := 0
+	for _, sub := range subs {
+		var errs SchemaErrors
+		s.validate(sub, v, "", &errs)
+		if len(errs) == 0 {
+			count++
+		}
+	}
+	return count
+}
+
+func jsonType(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	case json.Number:
+		if f, err := val.Float64(); err == nil && f == math.Trunc(f) {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// typeMatches reports whether v is of the schema type t, a name or a list.
+func typeMatches(t, v interface{}) bool {
+	wants := schemaStrings(t)
+	if want, ok := t.(string); ok {
+		wants = []string{want}
+	}
+	actual := jsonType(v)
+	for _, want := range wants {
+		if want == actual || (want == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonEqual(a, b interface{}) bool {
+	if x, ok := a.(json.Number); ok {
+		y, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		fx, _ := x.Float64()
+		fy, _ := y.Float64()
+		return fx == fy
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func schemaList(v interface{}) []interface{} {
+	list, _ := v.([]interface{})
+	return list
+}
+
+func schemaStrings(v interface{}) []string {
+	var out []string
+	for _, item := range schemaList(v) {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func schemaNumber(v interface{}) (float64, bool) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return 0, false
+	}
+	f, err := n.Float64()
+	return f, err == nil
+}
+
+// SchemaRegistry maps response types and routes to the validators their JSON
+// renders must satisfy.
+type SchemaRegistry struct {
+	mu     sync.RWMutex
+	types  map[reflect.Type]ResponseValidator
+	routes []schemaRoute
+}
+
+type schemaRoute struct {
+	method   string
+	segments []string
+	v        ResponseValidator
+}
+
+// NewSchemaRegistry creates an empty SchemaRegistry.
+func NewSchemaRegistry() *SchemaRegistry {
+	return &SchemaRegistry{types: map[reflect.Type]ResponseValidator{}}
+}
+
+// RegisterType validates renders of values with the same type as example.
+func (reg *SchemaRegistry) RegisterType(example interface{}, v ResponseValidator) {
+	reg.mu.Lock()
+	reg.types[reflect.TypeOf(example)] = v
+	reg.mu.Unlock()
+}
+
+// RegisterRoute validates renders for requests matching route, a path with
+// optional method and {name} wildcard segments, e.g. "GET /users/{id}".
+func (reg *SchemaRegistry) RegisterRoute(route string, v ResponseValidator) {
+	method, path := "", route
+	if i := strings.IndexByte(route, ' '); i >= 0 {
+		method, path = route[:i], strings.TrimSpace(route[i+1:])
+	}
+	reg.mu.Lock()
+	reg.routes = append(reg.routes, schemaRoute{method: method, segments: strings.Split(path, "/"), v: v})
+	reg.mu.Unlock()
+}
+
+// Lookup returns the validator for a render of v while serving req, which
+// may be nil. Routes are checked in registration order before types.
+func (reg *SchemaRegistry) Lookup(req *http.Request, v interface{}) (ResponseValidator, bool) {
+	if reg == nil {
+		return nil, false
+	}
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	if req != nil {
+		segments := strings.Split(req.URL.Path, "/")
+		for _, route := range reg.routes {
+			if route.matches(req.Method, segments) {
+				return route.v, true
+			}
+		}
+	}
+	validator, ok := reg.types[reflect.TypeOf(v)]
+	return validator, ok
+}
+
+func (route schemaRoute) matches(method string, segments []string) bool {
+	if route.method != "" && route.method != method {
+		return false
+	}
+	if len(route.segments) != len(segments) {
+		return false
+	}
+	for i, seg := range route.segments {
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			continue
+		}
+		if seg != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// validateSchema checks a JSON render against Options.Schemas in development.
+// The schema is looked up by v, and checked against v as the JSON engine
+// prepares it, after the marshal hook, redaction, and JSONMarshaler.
+// Violations go to Options.OnSchemaError when set and fail the render otherwise.
+func (r *Render) validateSchema(req *http.Request, v interface{}) error {
+	if !r.opt.IsDevelopment || r.opt.Schemas == nil {
+		return nil
+	}
+	validator, ok := r.opt.Schemas.Lookup(req, v)
+	if !ok {
+		return nil
+	}
+
+	prepared, err := prepareJSON(r.marshalHook(), v)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(prepared)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return err
+	}
+
+	err = validator.Validate(doc)
+	if err != nil && r.opt.OnSchemaError != nil {
+		r.opt.OnSchemaError(req, err)
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,126 @@
+package renderall
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testSchemaDoc = `{
+	"components": {"schemas": {
+		"User": {
+			"type": "object",
+			"required": ["id", "name"],
+			"additionalProperties": false,
+			"properties": {
+				"id": {"type": "integer", "minimum": 1},
+				"name": {"type": "string", "minLength": 1, "pattern": "^[a-z]+$"},
+				"email": {"type": "string", "nullable": true},
+				"role": {"enum": ["admin", "member"]},
+				"tags": {"type": "array", "maxItems": 2, "items": {"$ref": "#/components/schemas/Tag"}}
+			}
+		},
+		"Tag": {"oneOf": [{"type": "string"}, {"type": "integer"}]}
+	}}
+}`
+
+func testSchema(t *testing.T) *Schema {
+	t.Helper()
+	doc, err := ParseSchema([]byte(testSchemaDoc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := doc.Ref("#/components/schemas/User")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestSchemaValidate(t *testing.T) {
+	s := testSchema(t)
+	tests := []struct {
+		doc  string
+		errs []string
+	}{
+		{`{"id": 1, "name": "ann", "email": null, "role": "admin", "tags": ["a", 2]}`, nil},
+		{`{"id": 0, "name": "Ann"}`, []string{"/id", "/name"}},
+		{`{"name": ""}`, []string{"/", "/name", "/name"}},
+		{`{"id": 1.5, "name": "a", "extra": true}`, []string{"/", "/id"}},
+		{`{"id": 1, "name": "a", "role": "owner", "tags": [true, 1, 2]}`, []string{"/role", "/tags", "/tags/0"}},
+		{`[]`, []string{"/"}},
+	}
+	for _, tt := range tests {
+		var v interface{}
+		dec := json.NewDecoder(strings.NewReader(tt.doc))
+		dec.UseNumber()
+		if err := dec.Decode(&v); err != nil {
+			t.Fatal(err)
+		}
+		err := s.Validate(v)
+		var paths []string
+		var errs SchemaErrors
+		if errors.As(err, &errs) {
+			for _, e := range errs {
+				paths = append(paths, e.Path)
+			}
+		}
+		if !reflect.DeepEqual(paths, tt.errs) {
+			t.Errorf("%s: violations at %q, want %q (%v)", tt.doc, paths, tt.errs, err)
+		}
+	}
+
+	if _, err := s.Ref("#/components/schemas/Missing"); err == nil {
+		t.Error("missing ref resolved")
+	}
+	if _, err := s.Ref("other.json#/User"); err == nil {
+		t.Error("remote ref resolved")
+	}
+}
+
+type schemaUser struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestSchemaRegistry(t *testing.T) {
+	reg := NewSchemaRegistry()
+	reg.RegisterRoute("GET /users/{id}", testSchema(t))
+	reg.RegisterType(schemaUser{}, testSchema(t))
+
+	if _, ok := reg.Lookup(httptest.NewRequest(http.MethodGet, "/users/7", nil), 1); !ok {
+		t.Error("route not matched")
+	}
+	if _, ok := reg.Lookup(httptest.NewRequest(http.MethodPost, "/users/7", nil), 1); ok {
+		t.Error("route matched another method")
+	}
+	if _, ok := reg.Lookup(nil, schemaUser{}); !ok {
+		t.Error("type not matched")
+	}
+
+	var reported error
+	for _, tt := range []struct {
+		opt  Options
+		fail bool
+	}{
+		{Options{IsDevelopment: true, Schemas: reg}, true},
+		{Options{IsDevelopment: true, Schemas: reg, OnSchemaError: func(_ *http.Request, err error) { reported = err }}, false},
+		{Options{Schemas: reg}, false},
+	} {
+		w := httptest.NewRecorder()
+		err := New(tt.opt).JSON(w, http.StatusOK, schemaUser{ID: 0, Name: "ann"})
+		if (err != nil) != tt.fail {
+			t.Errorf("render error %v, want failure %v", err, tt.fail)
+		}
+		if !tt.fail && w.Code != http.StatusOK {
+			t.Errorf("status %d, want 200", w.Code)
+		}
+	}
+	if reported == nil {
+		t.Error("OnSchemaError not called")
+	}
+}
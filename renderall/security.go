@@ -0,0 +1,61 @@
+package renderall
+
+import "net/http"
+
+// SecurityHeaders is a set of headers attached to every render. Blank fields
+// are not sent, and headers already set by the handler are left alone.
+type SecurityHeaders struct {
+	// X-Content-Type-Options value, e.g. "nosniff".
+	ContentTypeOptions string
+	// X-Frame-Options value, e.g. "DENY" or "SAMEORIGIN".
+	FrameOptions string
+	// Referrer-Policy value, e.g. "strict-origin-when-cross-origin".
+	ReferrerPolicy string
+	// Strict-Transport-Security value, e.g. "max-age=63072000; includeSubDomains".
+	StrictTransportSecurity string
+	// Extra headers sent in addition to the above.
+	Extra http.Header
+}
+
+// DefaultSecurityHeaders returns a conservative preset. HSTS is not included
+// since it should only be enabled deliberately for HTTPS-only hosts.
+func DefaultSecurityHeaders() *SecurityHeaders {
+	return &SecurityHeaders{
+		ContentTypeOptions: "nosniff",
+		FrameOptions:       "DENY",
+		ReferrerPolicy:     "strict-origin-when-cross-origin",
+	}
+}
+
+// Apply sets the headers on h that are not already present.
+func (s *SecurityHeaders) Apply(h http.Header) {
+	setDefault(h, "X-Content-Type-Options", s.ContentTypeOptions)
+	setDefault(h, "X-Frame-Options", s.FrameOptions)
+	setDefault(h, "Referrer-Policy", s.ReferrerPolicy)
+	setDefault(h, "Strict-Transport-Security", s.StrictTransportSecurity)
+	for k, v := range s.Extra {
+		if _, ok := h[http.CanonicalHeaderKey(k)]; !ok && len(v) > 0 {
+			h[http.CanonicalHeaderKey(k)] = append([]string(nil), v...)
+		}
+	}
+}
+
+func setDefault(h http.Header, key, value string) {
+	if value != "" && h.Get(key) == "" {
+		h.Set(key, value)
+	}
+}
+
+// applySecurityHeaders sets the configured preset plus the per-format
+// defaults: raw data and JSONP are always sent with nosniff, since a sniffed
+// type is how they end up executed as script or markup, including when
+// wrapped by another engine.
+func (r *Render) applySecurityHeaders(h http.Header, e Engine) {
+	if r.opt.SecurityHeaders != nil {
+		r.opt.SecurityHeaders.Apply(h)
+	}
+	switch unwrapEngine(e).(type) {
+	case Data, JSONP:
+		setDefault(h, "X-Content-Type-Options", "nosniff")
+	}
+}
@@ -0,0 +1,173 @@
+package renderall
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	// SignatureHeader header constant.
+	SignatureHeader = "Signature"
+	// SignatureInput header constant.
+	SignatureInput = "Signature-Input"
+)
+
+// Signer produces RFC 9421 signatures. Implementations typically fetch the
+// current key from a KMS or keyring.
+type Signer interface {
+	// KeyID identifies the key in the keyid signature parameter.
+	KeyID() string
+	// Algorithm is the alg signature parameter. Omitted if blank.
+	Algorithm() string
+	// Sign signs the signature base.
+	Sign(base []byte) ([]byte, error)
+}
+
+// SignatureOptions configures HTTP Message Signatures on rendered responses.
+type SignatureOptions struct {
+	// Signer signs every response. Required.
+	Signer Signer
+	// Label of the signature in the Signature and Signature-Input dictionaries. Default is "sig1".
+	Label string
+	// Covered components: "@status" or lowercase header names. Default is ["@status", "content-type", "content-digest"].
+	Components []string
+}
+
+var defaultSignatureComponents = []string{"@status", "content-type", "content-digest"}
+
+type hmacSigner struct {
+	keyID string
+	key   []byte
+}
+
+// HMACSigner signs with hmac-sha256 using a shared secret.
+func HMACSigner(keyID string, key []byte) Signer {
+	return hmacSigner{keyID: keyID, key: key}
+}
+
+func (s hmacSigner) KeyID() string     { return s.keyID }
+func (s hmacSigner) Algorithm() string { return "hmac-sha256" }
+func (s hmacSigner) Sign(base []byte) ([]byte, error) {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write(base)
+	return mac.Sum(nil), nil
+}
+
+type ed25519Signer struct {
+	keyID string
+	key   ed25519.PrivateKey
+}
+
+// Ed25519Signer signs with an Ed25519 private key.
+func Ed25519Signer(keyID string, key ed25519.PrivateKey) Signer {
+	return ed25519Signer{keyID: keyID, key: key}
+}
+
+func (s ed25519Signer) KeyID() string     { return s.keyID }
+func (s ed25519Signer) Algorithm() string { return "ed25519" }
+func (s ed25519Signer) Sign(base []byte) ([]byte, error) {
+	return ed25519.Sign(s.key, base), nil
+}
+
+// coversDigest reports whether the signature covers Content-Digest.
+func (o *SignatureOptions) coversDigest() bool {
+	for _, c := range o.components() {
+		if c == "content-digest" {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *SignatureOptions) components() []string {
+	if len(o.Components) == 0 {
+		return defaultSignatureComponents
+	}
+	return o.Components
+}
+
+// signatureParams serializes the covered components and parameters as an
+// RFC 8941 inner list.
+func signatureParams(components []string, created int64, s Signer) string {
+	quoted := make([]string, len(components))
+	for i, c := range components {
+		quoted[i] = strconv.Quote(c)
+	}
+	params := "(" + strings.Join(quoted, " ") + ");created=" + strconv.FormatInt(created, 10)
+	params += ";keyid=" + strconv.Quote(s.KeyID())
+	if alg := s.Algorithm(); alg != "" {
+		params += ";alg=" + strconv.Quote(alg)
+	}
+	return params
+}
+
+// SignatureBase builds the RFC 9421 signature base for a response with the
+// given status and headers. Every covered header must be present.
+func SignatureBase(status int, h http.Header, components []string, params string) ([]byte, error) {
+	var b strings.Builder
+	for _, c := range components {
+		var value string
+		switch {
+		case c == "@status":
+			value = strconv.Itoa(status)
+		case strings.HasPrefix(c, "@"):
+			return nil, fmt.Errorf("renderall: unsupported response component %q", c)
+		default:
+			values, ok := h[http.CanonicalHeaderKey(c)]
+			if !ok {
+				return nil, fmt.Errorf("renderall: covered header %q is not set", c)
+			}
+			trimmed := make([]string, len(values))
+			for i, v := range values {
+				trimmed[i] = strings.TrimSpace(v)
+			}
+			value = strings.Join(trimmed, ", ")
+		}
+		b.WriteString(strconv.Quote(c) + ": " + value + "\n")
+	}
+	b.WriteString(`"@signature-params": ` + params)
+	return []byte(b.String()), nil
+}
+
+// sign adds Signature-Input and Signature headers for the captured response.
+func (r *Render) sign(h http.Header, status int, body []byte) error {
+	o := r.opt.Signature
+	if o == nil {
+		return nil
+	}
+	if o.Signer == nil {
+		return fmt.Errorf("renderall: SignatureOptions.Signer is nil")
+	}
+	if o.coversDigest() && h.Get(ContentDigest) == "" {
+		d, err := Digest(body, "sha-256")
+		if err != nil {
+			return err
+		}
+		h.Set(ContentDigest, d)
+	}
+
+	label := o.Label
+	if label == "" {
+		label = "sig1"
+	}
+	components := o.components()
+	params := signatureParams(components, r.now().Unix(), o.Signer)
+	base, err := SignatureBase(status, h, components, params)
+	if err != nil {
+		return err
+	}
+	sig, err := o.Signer.Sign(base)
+	if err != nil {
+		return err
+	}
+
+	h.Set(SignatureInput, label+"="+params)
+	h.Set(SignatureHeader, label+"=:"+base64.StdEncoding.EncodeToString(sig)+":")
+	return nil
+}
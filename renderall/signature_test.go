@@ -0,0 +1,97 @@
+package renderall
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignatureBase(t *testing.T) {
+	h := http.Header{}
+	h.Set("Content-Type", "application/json")
+	h.Add("X-Multi", " a ")
+	h.Add("X-Multi", "b")
+	got, err := SignatureBase(200, h, []string{"@status", "content-type", "x-multi"}, `("@status");created=1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "\"@status\": 200\n\"content-type\": application/json\n\"x-multi\": a, b\n\"@signature-params\": (\"@status\");created=1"
+	if string(got) != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	for _, components := range [][]string{{"@method"}, {"x-missing"}} {
+		if _, err := SignatureBase(200, h, components, ""); err == nil {
+			t.Errorf("SignatureBase(%v) succeeded", components)
+		}
+	}
+}
+
+// signedResponse renders a JSON response signed by s and returns it with
+// the signature base the client would rebuild and the decoded signature.
+func signedResponse(t *testing.T, s Signer) (*httptest.ResponseRecorder, []byte, []byte) {
+	t.Helper()
+	created := time.Unix(1700000000, 0)
+	r := New(Options{
+		Signature: &SignatureOptions{Signer: s},
+		Clock:     func() time.Time { return created },
+	})
+	rec := httptest.NewRecorder()
+	if err := r.JSON(rec, http.StatusOK, map[string]string{"a": "b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	input := rec.Header().Get(SignatureInput)
+	params, ok := strings.CutPrefix(input, "sig1=")
+	if !ok {
+		t.Fatalf("Signature-Input %q has no sig1", input)
+	}
+	if !strings.Contains(params, ";created=1700000000;") || !strings.Contains(params, `keyid="k1"`) {
+		t.Errorf("Signature-Input %q lacks created or keyid", input)
+	}
+	want, _ := Digest(rec.Body.Bytes(), "sha-256")
+	if got := rec.Header().Get(ContentDigest); got != want {
+		t.Errorf("Content-Digest %s, want %s", got, want)
+	}
+	base, err := SignatureBase(rec.Code, rec.Header(), defaultSignatureComponents, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sig := rec.Header().Get(SignatureHeader)
+	if !strings.HasPrefix(sig, "sig1=:") || !strings.HasSuffix(sig, ":") {
+		t.Fatalf("Signature %q is not a sig1 byte sequence", sig)
+	}
+	raw, err := base64.StdEncoding.DecodeString(sig[len("sig1=:") : len(sig)-1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rec, base, raw
+}
+
+func TestHMACSignature(t *testing.T) {
+	key := []byte("secret")
+	_, base, sig := signedResponse(t, HMACSigner("k1", key))
+	mac := hmac.New(sha256.New, key)
+	mac.Write(base)
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		t.Error("HMAC signature does not verify")
+	}
+}
+
+func TestEd25519Signature(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, base, sig := signedResponse(t, Ed25519Signer("k1", priv))
+	if !ed25519.Verify(pub, base, sig) {
+		t.Error("Ed25519 signature does not verify")
+	}
+}
@@ -0,0 +1,276 @@
+package renderall
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+)
+
+const (
+	// ContentSOAP11 header value for SOAP 1.1 messages.
+	ContentSOAP11 = "text/xml"
+	// ContentSOAP12 header value for SOAP 1.2 messages.
+	ContentSOAP12 = "application/soap+xml"
+)
+
+// SOAPVersion selects the envelope namespace, content type, and fault
+// structure.
+type SOAPVersion int
+
+const (
+	// SOAP11 is SOAP 1.1, sent as text/xml.
+	SOAP11 SOAPVersion = iota
+	// SOAP12 is SOAP 1.2, sent as application/soap+xml.
+	SOAP12
+)
+
+// namespace is the version's envelope namespace.
+func (v SOAPVersion) namespace() string {
+	if v == SOAP12 {
+		return "http://www.w3.org/2003/05/soap-envelope"
+	}
+	return "http://schemas.xmlsoap.org/soap/envelope/"
+}
+
+// SOAP fault codes. SOAP 1.1 names Sender Client and Receiver Server; either
+// spelling is sent as the version's own.
+const (
+	SOAPFaultSender              = "Sender"
+	SOAPFaultReceiver            = "Receiver"
+	SOAPFaultVersionMismatch     = "VersionMismatch"
+	SOAPFaultMustUnderstand      = "MustUnderstand"
+	SOAPFaultDataEncodingUnknown = "DataEncodingUnknown"
+)
+
+// SOAPFault is a fault sent in place of a SOAP body, written by SOAPFault.
+type SOAPFault struct {
+	// Code is one of the SOAPFault codes. Defaults to SOAPFaultReceiver.
+	Code string
+	// Subcode is a qualified name refining Code, e.g. "m:InvalidPrice". Only sent by SOAP 1.2.
+	Subcode string
+	// Message is the human readable faultstring or Reason.
+	Message string
+	// Lang is the xml:lang of a SOAP 1.2 Reason. Defaults to "en".
+	Lang string
+	// Actor is the URI of the node that faulted, faultactor in SOAP 1.1 and Node in SOAP 1.2.
+	Actor string
+	// Detail is marshaled into the fault's detail element.
+	Detail interface{}
+}
+
+// Error implements the error interface.
+func (f SOAPFault) Error() string {
+	return "soap " + f.code(SOAP12) + ": " + f.Message
+}
+
+// code returns the fault code as version names it.
+func (f SOAPFault) code(v SOAPVersion) string {
+	code := f.Code
+	switch code {
+	case "", SOAPFaultReceiver, "Server":
+		code = SOAPFaultReceiver
+		if v == SOAP11 {
+			code = "Server"
+		}
+	case SOAPFaultSender, "Client":
+		code = SOAPFaultSender
+		if v == SOAP11 {
+			code = "Client"
+		}
+	}
+	return code
+}
+
+// status is the HTTP status the SOAP HTTP bindings give the fault: always
+// 500 in SOAP 1.1, and 400 for sender faults in SOAP 1.2.
+func (f SOAPFault) status(v SOAPVersion) int {
+	if v == SOAP12 && f.code(v) == SOAPFaultSender {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// SOAPOptions is a struct for overriding some rendering Options for specific SOAP call.
+type SOAPOptions struct {
+	// Version of the envelope. Defaults to SOAP11.
+	Version SOAPVersion
+	// Header is marshaled into the envelope header, e.g. a WS-Security struct or a slice of header blocks. Omitted if nil.
+	Header interface{}
+	// Action is sent as the SOAP 1.2 content type's action parameter. Defaults to blank ("").
+	Action string
+	// Request being served, for PreRender hooks and request IDs. Defaults to nil.
+	Request *http.Request
+}
+
+// SOAP built-in renderer. It wraps the marshaled value, or a SOAPFault, in a
+// SOAP envelope.
+type SOAP struct {
+	Head
+	Version SOAPVersion
+	Header  interface{}
+	Hook    MarshalHook
+	// Prolog is written before the envelope.
+	Prolog string
+}
+
+// Render a SOAP response.
+func (s SOAP) Render(w http.ResponseWriter, v interface{}) error {
+	return renderEncoded(w, s.Head, s, v)
+}
+
+// Encode writes the SOAP envelope to w.
+func (s SOAP) Encode(w io.Writer, v interface{}) (Encoded, error) {
+	return encodeBuffered(w, s, s.Head.ContentType, v)
+}
+
+// encodeBuffer writes the SOAP envelope to buf.
+func (s SOAP) encodeBuffer(buf *bytes.Buffer, v interface{}) error {
+	buf.WriteString(s.Prolog)
+	fmt.Fprintf(buf, `<soap:Envelope xmlns:soap="%s">`, s.Version.namespace())
+	if s.Header != nil {
+		buf.WriteString("<soap:Header>")
+		if err := s.marshal(buf, s.Header); err != nil {
+			return err
+		}
+		buf.WriteString("</soap:Header>")
+	}
+	buf.WriteString("<soap:Body>")
+	switch f := v.(type) {
+	case SOAPFault:
+		if err := s.fault(buf, f); err != nil {
+			return err
+		}
+	case *SOAPFault:
+		if err := s.fault(buf, *f); err != nil {
+			return err
+		}
+	default:
+		if err := s.marshal(buf, v); err != nil {
+			return err
+		}
+	}
+	buf.WriteString("</soap:Body></soap:Envelope>")
+	return nil
+}
+
+// marshal writes v as XML, through the marshal hook and its XMLMarshaler if
+// it has one. Nil writes nothing.
+func (s SOAP) marshal(buf *bytes.Buffer, v interface{}) error {
+	v, err := applyHook(s.Hook, v)
+	if err != nil || v == nil {
+		return err
+	}
+	var b []byte
+	if m, ok := v.(XMLMarshaler); ok {
+		b, err = m.RenderXML()
+	} else {
+		b, err = xml.Marshal(v)
+	}
+	if err != nil {
+		return err
+	}
+	buf.Write(b)
+	return nil
+}
+
+// fault writes f as the version structures it.
+func (s SOAP) fault(buf *bytes.Buffer, f SOAPFault) error {
+	text := func(open, value, end string) {
+		buf.WriteString(open)
+		xml.EscapeText(buf, []byte(value))
+		buf.WriteString(end)
+	}
+
+	buf.WriteString("<soap:Fault>")
+	if s.Version == SOAP12 {
+		text("<soap:Code><soap:Value>", "soap:"+f.code(s.Version), "</soap:Value>")
+		if f.Subcode != "" {
+			text("<soap:Subcode><soap:Value>", f.Subcode, "</soap:Value></soap:Subcode>")
+		}
+		buf.WriteString("</soap:Code>")
+		lang := f.Lang
+		if lang == "" {
+			lang = "en"
+		}
+		text(`<soap:Reason><soap:Text xml:lang="`, lang, `">`)
+		text("", f.Message, "</soap:Text></soap:Reason>")
+		if f.Actor != "" {
+			text("<soap:Node>", f.Actor, "</soap:Node>")
+		}
+		if f.Detail != nil {
+			buf.WriteString("<soap:Detail>")
+			if err := s.marshal(buf, f.Detail); err != nil {
+				return err
+			}
+			buf.WriteString("</soap:Detail>")
+		}
+	} else {
+		text("<faultcode>", "soap:"+f.code(s.Version), "</faultcode>")
+		text("<faultstring>", f.Message, "</faultstring>")
+		if f.Actor != "" {
+			text("<faultactor>", f.Actor, "</faultactor>")
+		}
+		if f.Detail != nil {
+			buf.WriteString("<detail>")
+			if err := s.marshal(buf, f.Detail); err != nil {
+				return err
+			}
+			buf.WriteString("</detail>")
+		}
+	}
+	buf.WriteString("</soap:Fault>")
+	return nil
+}
+
+// SOAP marshals v into the body of a SOAP envelope and writes the response.
+func (r *Render) SOAP(w http.ResponseWriter, status int, v interface{}, soapOpt ...SOAPOptions) error {
+	opt := SOAPOptions{}
+	if len(soapOpt) > 0 {
+		opt = soapOpt[0]
+	}
+
+	contentType := ContentSOAP11
+	if opt.Version == SOAP12 {
+		contentType = ContentSOAP12
+	}
+	contentType = r.withCharset(contentType)
+	if opt.Version == SOAP12 && opt.Action != "" {
+		// FormatMediaType quotes and escapes the action, which is a URI
+		// that may hold ';' or '"', and encodes any control characters.
+		mediaType, params, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			return r.fail(w, err)
+		}
+		params["action"] = opt.Action
+		if contentType = mime.FormatMediaType(mediaType, params); contentType == "" {
+			return r.fail(w, fmt.Errorf("renderall: invalid SOAP action %q", opt.Action))
+		}
+	}
+
+	head := Head{
+		ContentType: contentType,
+		Status:      status,
+	}
+
+	s := SOAP{
+		Head:    head,
+		Version: opt.Version,
+		Header:  opt.Header,
+		Hook:    r.marshalHook(),
+		Prolog:  `<?xml version="1.0" encoding="` + r.opt.Charset + `"?>` + "\n",
+	}
+	return r.render(w, opt.Request, s, v)
+}
+
+// SOAPFault writes f in a SOAP envelope with the status the version's HTTP
+// binding gives it.
+func (r *Render) SOAPFault(w http.ResponseWriter, f SOAPFault, soapOpt ...SOAPOptions) error {
+	version := SOAP11
+	if len(soapOpt) > 0 {
+		version = soapOpt[0].Version
+	}
+	return r.SOAP(w, f.status(version), f, soapOpt...)
+}
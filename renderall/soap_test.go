@@ -0,0 +1,32 @@
+package renderall
+
+import (
+	"mime"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSOAPAction(t *testing.T) {
+	r := newTestRender(Options{})
+	for _, action := range []string{
+		"http://example.com/Quote",
+		`urn:x;y="z"`,
+		"urn:a\r\nX-Injected: 1",
+	} {
+		rec := httptest.NewRecorder()
+		if err := r.SOAP(rec, 200, nil, SOAPOptions{Version: SOAP12, Action: action}); err != nil {
+			t.Fatalf("action %q: %v", action, err)
+		}
+		ct := rec.Header().Get(ContentType)
+		mediaType, params, err := mime.ParseMediaType(ct)
+		if err != nil {
+			t.Fatalf("action %q: Content-Type %q: %v", action, ct, err)
+		}
+		if mediaType != ContentSOAP12 || params["action"] != action || params["charset"] == "" {
+			t.Errorf("action %q: Content-Type %q parses as %s %v", action, ct, mediaType, params)
+		}
+		if rec.Header().Get("X-Injected") != "" {
+			t.Errorf("action %q injected a header", action)
+		}
+	}
+}
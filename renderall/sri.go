@@ -0,0 +1,83 @@
+package renderall
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"io/fs"
+)
+
+// Integrity returns the Subresource Integrity value (sha384) for data.
+func Integrity(data []byte) string {
+	sum := sha512.Sum384(data)
+	return "sha384-" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// ComputeIntegrity walks fsys and returns the SRI value of every file keyed
+// by its slash separated path. Run it at build time (e.g. from go generate)
+// and pass the result as Options.Integrity to avoid hashing at runtime.
+func ComputeIntegrity(fsys fs.FS) (map[string]string, error) {
+	manifest := map[string]string{}
+	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return err
+		}
+		manifest[path] = Integrity(data)
+		return nil
+	})
+	return manifest, err
+}
+
+// integrity returns the SRI value for the named asset, preferring the
+// precomputed Options.Integrity entry. Source names listed in the asset
+// manifest resolve to their built file. Computed values are cached unless in
+// development mode, where assets may change between requests.
+func (r *Render) integrity(name string) (string, error) {
+	if manifest, err := r.manifest(); err != nil {
+		return "", err
+	} else if entry, ok := manifest[name]; ok {
+		name = entry.File
+	}
+	if v, ok := r.opt.Integrity[name]; ok {
+		return v, nil
+	}
+	if v, ok := r.sriCache.Load(name); ok {
+		return v.(string), nil
+	}
+	if r.opt.Assets == nil {
+		return "", fmt.Errorf("renderall: no integrity for %q and Options.Assets is nil", name)
+	}
+
+	data, err := fs.ReadFile(r.opt.Assets, name)
+	if err != nil {
+		return "", err
+	}
+	v := Integrity(data)
+	if !r.opt.IsDevelopment {
+		r.sriCache.Store(name, v)
+	}
+	return v, nil
+}
+
+// assetFuncs are the built-in asset template funcs. User Funcs may override them.
+func (r *Render) assetFuncs() template.FuncMap {
+	return mergeFuncs(r.manifestFuncs(), template.FuncMap{
+		"sri": func(name string) (template.HTMLAttr, error) {
+			// Files from the dev server change constantly and are not the built
+			// ones, and tests must not depend on asset contents.
+			if r.devServer() || r.opt.TestMode {
+				return "", nil
+			}
+			v, err := r.integrity(name)
+			if err != nil {
+				return "", err
+			}
+			return template.HTMLAttr(`integrity="` + v + `" crossorigin="anonymous"`), nil
+		},
+	})
+}
@@ -0,0 +1,101 @@
+package renderall
+
+import (
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+)
+
+// StatusError is returned for a render whose status code no response can
+// carry, and passed to Options.OnWarning for one whose body the status
+// forbids.
+type StatusError struct {
+	Status int
+	// Dropped is the number of body bytes discarded for a 204 or 304.
+	Dropped int
+}
+
+func (e *StatusError) Error() string {
+	if e.Dropped > 0 {
+		return fmt.Sprintf("renderall: status %d responses have no body, dropped %d bytes", e.Status, e.Dropped)
+	}
+	return fmt.Sprintf("renderall: invalid status code %d, want 100 to 599", e.Status)
+}
+
+// statusWriter checks the status every render sends, whichever engine or
+// wrapper sends it. It refuses status codes outside 100 to 599, which
+// net/http would panic on or send as a malformed status line, leaving the
+// response unsent for the error response, and discards the body of a 204
+// or 304 response, which net/http would otherwise refuse with
+// http.ErrBodyNotAllowed part way through the render.
+type statusWriter struct {
+	http.ResponseWriter
+	status  int
+	err     error
+	dropped int
+}
+
+func (s *statusWriter) WriteHeader(status int) {
+	if s.err != nil {
+		return
+	}
+	if status < 100 || status > 599 {
+		s.err = &StatusError{Status: status}
+		return
+	}
+	if status >= 200 && s.status == 0 {
+		s.status = status
+	}
+	if status == http.StatusNoContent {
+		s.Header().Del(ContentLength)
+	}
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *statusWriter) Write(p []byte) (int, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+	if s.status == 0 {
+		s.WriteHeader(http.StatusOK)
+	}
+	if s.status == http.StatusNoContent || s.status == http.StatusNotModified {
+		s.dropped += len(p)
+		return len(p), nil
+	}
+	return s.ResponseWriter.Write(p)
+}
+
+// Flush sends any buffered data to the client.
+func (s *statusWriter) Flush() {
+	if s.err == nil {
+		http.NewResponseController(s.ResponseWriter).Flush()
+	}
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (s *statusWriter) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+// warn passes err to Options.OnWarning, or logs it.
+func (r *Render) warn(req *http.Request, err error) {
+	if r.opt.OnWarning != nil {
+		r.opt.OnWarning(req, err)
+		return
+	}
+	log.Print(err)
+}
+
+// statusFuncs expose status code helpers to templates, e.g. for styling
+// error pages by class.
+func statusFuncs() template.FuncMap {
+	return template.FuncMap{
+		"statusText":    http.StatusText,
+		"isSuccess":     func(code int) bool { return code >= 200 && code < 300 },
+		"isRedirect":    func(code int) bool { return code >= 300 && code < 400 },
+		"isClientError": func(code int) bool { return code >= 400 && code < 500 },
+		"isServerError": func(code int) bool { return code >= 500 && code < 600 },
+	}
+}
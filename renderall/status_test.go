@@ -0,0 +1,68 @@
+package renderall
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStatusChecks(t *testing.T) {
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Engines and wrappers whose status is only seen as it is written.
+	engines := map[string]func(status int) Engine{
+		"json": func(status int) Engine {
+			return JSON{Head: Head{Status: status, ContentType: ContentJSON}}
+		},
+		"encrypted": func(status int) Engine {
+			return Encrypted{
+				Engine: JSON{Head: Head{Status: status, ContentType: ContentJSON}},
+				Key:    EncryptionKey{Public: key.PublicKey()},
+			}
+		},
+		"bound": func(status int) Engine {
+			return boundEngine{ctx: context.Background(), engine: JSON{Head: Head{Status: status, ContentType: ContentJSON}}}
+		},
+	}
+	for name, engine := range engines {
+		for _, buffered := range []bool{false, true} {
+			var warnings []error
+			r := newTestRender(Options{
+				ETag:      buffered,
+				OnWarning: func(_ *http.Request, err error) { warnings = append(warnings, err) },
+			})
+
+			for _, status := range []int{0, 99, 600} {
+				rec := httptest.NewRecorder()
+				err := r.Render(rec, engine(status), 1)
+				var se *StatusError
+				if !errors.As(err, &se) || se.Status != status {
+					t.Errorf("%s buffered=%v status %d: err %v, want a StatusError", name, buffered, status, err)
+				}
+				if rec.Code != http.StatusInternalServerError {
+					t.Errorf("%s buffered=%v status %d: sent %d, want 500", name, buffered, status, rec.Code)
+				}
+			}
+
+			for _, status := range []int{http.StatusNoContent, http.StatusNotModified} {
+				warnings = nil
+				rec := httptest.NewRecorder()
+				if err := r.Render(rec, engine(status), 1); err != nil {
+					t.Errorf("%s buffered=%v status %d: %v", name, buffered, status, err)
+				}
+				if rec.Code != status || rec.Body.Len() != 0 {
+					t.Errorf("%s buffered=%v status %d: sent %d with %q", name, buffered, status, rec.Code, rec.Body.String())
+				}
+				if len(warnings) != 1 {
+					t.Errorf("%s buffered=%v status %d: %d warnings, want 1", name, buffered, status, len(warnings))
+				}
+			}
+		}
+	}
+}
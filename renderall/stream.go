@@ -0,0 +1,26 @@
+package renderall
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+)
+
+// streamWrite writes s to a held open response and flushes it through rc,
+// within Options.StreamWriteTimeout. Writers that cannot flush or take
+// deadlines still get the write.
+func (r *Render) streamWrite(w http.ResponseWriter, rc *http.ResponseController, s string) error {
+	if r.opt.StreamWriteTimeout > 0 {
+		if err := rc.SetWriteDeadline(time.Now().Add(r.opt.StreamWriteTimeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+	}
+	if _, err := io.WriteString(w, s); err != nil {
+		return err
+	}
+	if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
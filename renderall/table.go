@@ -0,0 +1,238 @@
+package renderall
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TableOptions configures Table.
+type TableOptions struct {
+	// Named cell formatters selected by a field's format tag.
+	Formats map[string]func(interface{}) string
+	// Query parameter carrying the sort column; "-" prefixed for descending.
+	// Sort links are only emitted if set together with URL.
+	SortParam string
+	// URL of the current page, used to build sort links and read the active sort.
+	URL *url.URL
+	// Class attribute of the table element.
+	Class string
+	// Shows fields tagged `render:"redact"` and `render:"omit"` as is.
+	DisableRedaction bool
+}
+
+type tableColumn struct {
+	index    int
+	field    string
+	header   string
+	format   string
+	sortable bool
+	redact   bool
+}
+
+// Table renders a slice of structs as an HTML table. Columns come from the
+// exported fields, configured with struct tags:
+//
+//	Created time.Time `table:"Created at" format:"date" sortable:"true"`
+//
+// table sets the header ("-" skips the field), format names an entry in
+// TableOptions.Formats used to format the cells, and sortable makes the
+// header a sort link. Rows are sorted by the active sortable column. Fields
+// tagged `render:"omit"` are skipped and those tagged `render:"redact"` show
+// RedactedValue, unless TableOptions.DisableRedaction is set.
+func Table(rows interface{}, opt TableOptions) (template.HTML, error) {
+	columns, items, err := tableRows("table", rows, !opt.DisableRedaction)
+	if err != nil {
+		return "", err
+	}
+
+	active, desc := "", false
+	linked := opt.SortParam != "" && opt.URL != nil
+	if linked {
+		active = opt.URL.Query().Get(opt.SortParam)
+		if strings.HasPrefix(active, "-") {
+			active, desc = active[1:], true
+		}
+		for _, c := range columns {
+			if c.sortable && c.field == active {
+				sortRows(items, c.index, desc)
+				break
+			}
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("<table")
+	if opt.Class != "" {
+		b.WriteString(` class="` + template.HTMLEscapeString(opt.Class) + `"`)
+	}
+	b.WriteString("><thead><tr>")
+	for _, c := range columns {
+		b.WriteString("<th>")
+		header := template.HTMLEscapeString(c.header)
+		if linked && c.sortable {
+			key := c.field
+			if c.field == active && !desc {
+				key = "-" + c.field
+			}
+			u := *opt.URL
+			q := u.Query()
+			q.Set(opt.SortParam, key)
+			u.RawQuery = q.Encode()
+			b.WriteString(`<a href="` + template.HTMLEscapeString(u.String()) + `">` + header + "</a>")
+		} else {
+			b.WriteString(header)
+		}
+		b.WriteString("</th>")
+	}
+	b.WriteString("</tr></thead><tbody>")
+
+	for _, item := range items {
+		b.WriteString("<tr>")
+		for _, c := range columns {
+			b.WriteString("<td>")
+			if c.redact {
+				b.WriteString(RedactedValue)
+			} else if item.Kind() == reflect.Struct {
+				cell, err := tableCell(item.Field(c.index), c.format, opt.Formats)
+				if err != nil {
+					return "", err
+				}
+				b.WriteString(template.HTMLEscapeString(cell))
+			}
+			b.WriteString("</td>")
+		}
+		b.WriteString("</tr>")
+	}
+	b.WriteString("</tbody></table>")
+	return template.HTML(b.String()), nil
+}
+
+// tableRows returns the columns of a slice of structs, per the table tags, and
+// its dereferenced elements. what names the caller in errors. With redact,
+// fields tagged `render:"omit"` get no column and those tagged
+// `render:"redact"` a redacted one, which cannot be sorted.
+func tableRows(what string, rows interface{}, redact bool) ([]tableColumn, []reflect.Value, error) {
+	rv := reflect.ValueOf(rows)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, nil, fmt.Errorf("renderall: %s requires a slice, got %T", what, rows)
+	}
+	et := rv.Type().Elem()
+	for et.Kind() == reflect.Ptr {
+		et = et.Elem()
+	}
+	if et.Kind() != reflect.Struct {
+		return nil, nil, fmt.Errorf("renderall: %s requires a slice of structs, got %T", what, rows)
+	}
+
+	var columns []tableColumn
+	for i := 0; i < et.NumField(); i++ {
+		sf := et.Field(i)
+		if sf.PkgPath != "" || sf.Tag.Get("table") == "-" {
+			continue
+		}
+		rule := ""
+		if redact {
+			rule = sf.Tag.Get("render")
+		}
+		if rule == "omit" {
+			continue
+		}
+		c := tableColumn{
+			index:    i,
+			field:    sf.Name,
+			header:   sf.Tag.Get("table"),
+			format:   sf.Tag.Get("format"),
+			sortable: sf.Tag.Get("sortable") == "true" && rule != "redact",
+			redact:   rule == "redact",
+		}
+		if c.header == "" {
+			c.header = humanize(sf.Name)
+		}
+		columns = append(columns, c)
+	}
+
+	items := make([]reflect.Value, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		v := rv.Index(i)
+		for v.Kind() == reflect.Ptr && !v.IsNil() {
+			v = v.Elem()
+		}
+		items = append(items, v)
+	}
+	return columns, items, nil
+}
+
+func tableCell(v reflect.Value, format string, formats map[string]func(interface{}) string) (string, error) {
+	if format != "" {
+		f, ok := formats[format]
+		if !ok {
+			return "", fmt.Errorf("renderall: no table format %q", format)
+		}
+		return f(v.Interface()), nil
+	}
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+	return fmt.Sprint(v.Interface()), nil
+}
+
+// sortRows stably sorts struct values by the given field.
+func sortRows(items []reflect.Value, field int, desc bool) {
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].Kind() != reflect.Struct || items[j].Kind() != reflect.Struct {
+			return false
+		}
+		a, b := items[i].Field(field), items[j].Field(field)
+		if desc {
+			a, b = b, a
+		}
+		return lessValue(a, b)
+	})
+}
+
+func lessValue(a, b reflect.Value) bool {
+	if a.Type() == timeType {
+		return a.Interface().(time.Time).Before(b.Interface().(time.Time))
+	}
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() < b.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return a.Uint() < b.Uint()
+	case reflect.Float32, reflect.Float64:
+		return a.Float() < b.Float()
+	case reflect.Bool:
+		return !a.Bool() && b.Bool()
+	case reflect.String:
+		return a.String() < b.String()
+	}
+	return fmt.Sprint(a.Interface()) < fmt.Sprint(b.Interface())
+}
+
+// tableFuncs expose Table to templates, with sort links relative to req.
+func (r *Render) tableFuncs(req *http.Request) template.FuncMap {
+	opt := TableOptions{
+		Formats:   r.opt.TableFormats,
+		SortParam: r.opt.TableSortParam,
+		Class:     r.opt.TableClass,
+
+		DisableRedaction: r.opt.DisableRedaction,
+	}
+	if req != nil {
+		opt.URL = req.URL
+	}
+	return template.FuncMap{
+		"table": func(rows interface{}) (template.HTML, error) {
+			return Table(rows, opt)
+		},
+	}
+}
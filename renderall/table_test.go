@@ -0,0 +1,37 @@
+package renderall
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+type tableAccount struct {
+	Name     string `sortable:"true"`
+	Password string `render:"redact" sortable:"true"`
+	Internal int    `render:"omit"`
+}
+
+func TestTableRedaction(t *testing.T) {
+	rows := []tableAccount{{"ann", "secret", 7}}
+
+	html, err := Table(rows, TableOptions{SortParam: "sort", URL: &url.URL{Path: "/accounts"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := string(html)
+	if !strings.Contains(s, "<td>"+RedactedValue+"</td>") || strings.Contains(s, "secret") || strings.Contains(s, "Internal") {
+		t.Errorf("table = %s", s)
+	}
+	if !strings.Contains(s, "sort=Name") || strings.Contains(s, "sort=Password") {
+		t.Errorf("table = %s, want only Name sortable", s)
+	}
+
+	html, err = Table(rows, TableOptions{DisableRedaction: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := string(html); !strings.Contains(s, "secret") || !strings.Contains(s, "Internal") {
+		t.Errorf("table without redaction = %s", s)
+	}
+}
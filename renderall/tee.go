@@ -0,0 +1,88 @@
+package renderall
+
+import (
+	"errors"
+	"io"
+	"net/http"
+)
+
+// TeeWriter copies a response body to other writers as it is sent, so a
+// render can feed a cache store or audit log without encoding the payload
+// twice:
+//
+//	tw := renderall.Tee(w, entry, auditLog)
+//	err := r.JSON(tw, http.StatusOK, v)
+//	if tw.Status() == http.StatusOK && tw.Err() == nil {
+//		cache.Commit(tw.SentHeader(), entry)
+//	}
+//
+// Destinations see exactly the bytes the client was sent: compressed when
+// the response has a Content-Encoding, only the selected ranges of a 206,
+// and nothing for a 204 or 304. Store SentHeader alongside them to know
+// which. One that fails stops receiving the body, but the response carries
+// on; see Err. Outside of HTTP, Encode into an io.MultiWriter instead.
+//
+// The status, header, and byte tracking are those of the ResponseWriter it
+// embeds.
+type TeeWriter struct {
+	*ResponseWriter
+	dst    []io.Writer
+	errs   []error
+	header http.Header
+}
+
+// Tee returns w wrapped in a TeeWriter copying the body to dst.
+func Tee(w http.ResponseWriter, dst ...io.Writer) *TeeWriter {
+	return &TeeWriter{ResponseWriter: NewResponseWriter(w), dst: dst, errs: make([]error, len(dst))}
+}
+
+// WriteHeader sends the header with status, keeping a copy of it for
+// SentHeader.
+func (t *TeeWriter) WriteHeader(status int) {
+	sent := t.HeaderWritten()
+	t.ResponseWriter.WriteHeader(status)
+	if !sent && t.HeaderWritten() {
+		t.header = t.Header().Clone()
+	}
+}
+
+// Write writes b to the response, then whatever of it was sent to the
+// destinations.
+func (t *TeeWriter) Write(b []byte) (int, error) {
+	if !t.HeaderWritten() {
+		t.WriteHeader(http.StatusOK)
+	}
+	n, err := t.ResponseWriter.Write(b)
+	if n > 0 {
+		for i, d := range t.dst {
+			if t.errs[i] == nil {
+				_, t.errs[i] = d.Write(b[:n])
+			}
+		}
+	}
+	return n, err
+}
+
+// ReadFrom copies the body from src through Write, so the destinations get
+// it too.
+func (t *TeeWriter) ReadFrom(src io.Reader) (int64, error) {
+	return io.Copy(struct{ io.Writer }{t}, src)
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (t *TeeWriter) Unwrap() http.ResponseWriter {
+	return t.ResponseWriter
+}
+
+// SentHeader returns a copy of the header as it was sent, or nil if it has
+// not been sent. Its Content-Encoding, Content-Range, and Content-Type
+// describe the bytes the destinations received.
+func (t *TeeWriter) SentHeader() http.Header {
+	return t.header
+}
+
+// Err returns the errors of the destinations that failed, or nil if every
+// one received the whole body.
+func (t *TeeWriter) Err() error {
+	return errors.Join(t.errs...)
+}
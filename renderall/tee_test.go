@@ -0,0 +1,61 @@
+package renderall
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type failWriter struct{}
+
+func (failWriter) Write([]byte) (int, error) { return 0, errors.New("store full") }
+
+func TestTee(t *testing.T) {
+	r := newTestRender(Options{Compress: true})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+
+	var copied bytes.Buffer
+	tw := Tee(rec, &copied, failWriter{})
+	v := map[string]string{"a": strings.Repeat("b", 2048)}
+	if err := r.JSON(tw, http.StatusOK, v, JSONOptions{Request: req}); err != nil {
+		t.Fatal(err)
+	}
+
+	if tw.Status() != http.StatusOK || tw.BytesWritten() != int64(rec.Body.Len()) {
+		t.Errorf("status %d, %d bytes, want 200 and %d", tw.Status(), tw.BytesWritten(), rec.Body.Len())
+	}
+	if !bytes.Equal(copied.Bytes(), rec.Body.Bytes()) {
+		t.Error("destination did not get the bytes sent")
+	}
+	if got := tw.SentHeader().Get(ContentEncoding); got != "gzip" {
+		t.Fatalf("SentHeader Content-Encoding %q, want gzip", got)
+	}
+	zr, err := gzip.NewReader(&copied)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body, _ := io.ReadAll(zr); string(body) != `{"a":"`+v["a"]+`"}` {
+		t.Errorf("decompressed copy is %d bytes", len(body))
+	}
+	if tw.Err() == nil {
+		t.Error("Err did not report the failed destination")
+	}
+
+	// A handler setting headers after the fact does not change the snapshot.
+	tw.Header().Set("X-Late", "1")
+	if tw.SentHeader().Get("X-Late") != "" {
+		t.Error("SentHeader changed after the header was sent")
+	}
+
+	// Error responses are not written over a teed response already sent.
+	if !headerWritten(NewResponseWriter(tw)) {
+		t.Error("a sent TeeWriter did not count as sent")
+	}
+}
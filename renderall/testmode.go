@@ -0,0 +1,42 @@
+package renderall
+
+import (
+	"crypto/rand"
+	"io"
+	"time"
+)
+
+// TestTime is the time Options.TestMode freezes the clock at.
+var TestTime = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// zeroReader reads endless zero bytes, making "random" values constant.
+type zeroReader struct{}
+
+func (zeroReader) Read(b []byte) (int, error) {
+	for i := range b {
+		b[i] = 0
+	}
+	return len(b), nil
+}
+
+// now returns the current time from Options.Clock.
+func (r *Render) now() time.Time {
+	if r.opt.Clock != nil {
+		return r.opt.Clock()
+	}
+	if r.opt.TestMode {
+		return TestTime
+	}
+	return time.Now()
+}
+
+// random returns the Options.Random source.
+func (r *Render) random() io.Reader {
+	if r.opt.Random != nil {
+		return r.opt.Random
+	}
+	if r.opt.TestMode {
+		return zeroReader{}
+	}
+	return rand.Reader
+}
@@ -0,0 +1,140 @@
+package renderall
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"regexp"
+	texttemplate "text/template"
+)
+
+// ContentSVG header value for SVG images.
+const ContentSVG = "image/svg+xml"
+
+// newTextSet returns an empty text/template set. Every template file is
+// compiled into it as well as into the HTML set, for output that contextual
+// HTML escaping would mangle.
+func (r *Render) newTextSet(name string) *texttemplate.Template {
+	return texttemplate.New(name).Delims(r.opt.Delims.Left, r.opt.Delims.Right)
+}
+
+// parseText adds a named template to the text set with our funcmaps applied.
+func (r *Render) parseText(set *texttemplate.Template, name string, buf []byte) error {
+	tmpl := set.New(name)
+	tmpl.Funcs(texttemplate.FuncMap(helperFuncs))
+	tmpl.Funcs(texttemplate.FuncMap(r.builtinFuncs()))
+	for _, funcs := range r.opt.Funcs {
+		tmpl.Funcs(texttemplate.FuncMap(funcs))
+	}
+	_, err := tmpl.Funcs(texttemplate.FuncMap(layoutHelpers)).Parse(string(buf))
+	return err
+}
+
+// TextTemplate built-in renderer. It executes a text/template, so nothing in
+// the output is escaped.
+type TextTemplate struct {
+	Head
+	Name      string
+	Templates *texttemplate.Template
+	// Minify is applied to the output before it is written, if set.
+	Minify func([]byte) []byte
+}
+
+// Render a text template response.
+func (t TextTemplate) Render(w http.ResponseWriter, binding interface{}) error {
+	return renderEncoded(w, t.Head, t, binding)
+}
+
+// Encode executes the template into w.
+func (t TextTemplate) Encode(w io.Writer, binding interface{}) (Encoded, error) {
+	return encodeBuffered(w, t, t.Head.ContentType, binding)
+}
+
+// encodeBuffer executes the template into out, then minifies it.
+func (t TextTemplate) encodeBuffer(out *bytes.Buffer, binding interface{}) error {
+	if err := t.Templates.ExecuteTemplate(out, t.Name, binding); err != nil {
+		return err
+	}
+	if t.Minify != nil {
+		// The minified body may share out's memory; copy handles the overlap.
+		minified := t.Minify(out.Bytes())
+		out.Reset()
+		out.Write(minified)
+	}
+	return nil
+}
+
+// textSet returns the text set, recompiling it first in development.
+func (r *Render) textSet() (*texttemplate.Template, error) {
+	if r.opt.IsDevelopment {
+		if err := r.compileTemplates(); err != nil {
+			return nil, err
+		}
+	}
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.textTemplates, nil
+}
+
+// SVGOptions is a struct for overriding some rendering Options for specific SVG call.
+type SVGOptions struct {
+	// Minifies the output even if Options.MinifySVG is false.
+	Minify bool
+}
+
+var (
+	svgComments   = regexp.MustCompile(`(?s)<!--.*?-->`)
+	svgBetweenTag = regexp.MustCompile(`>\s+<`)
+)
+
+// minifySVG strips comments and the whitespace between tags. Whitespace
+// inside text elements is kept, apart from runs that only separate tags.
+func minifySVG(b []byte) []byte {
+	b = svgComments.ReplaceAll(b, nil)
+	b = svgBetweenTag.ReplaceAll(b, []byte("><"))
+	return bytes.TrimSpace(b)
+}
+
+// SVG renders the named template as an SVG image, with text/template
+// semantics so attribute values are not HTML escaped.
+func (r *Render) SVG(w http.ResponseWriter, status int, name string, binding interface{}, svgOpt ...SVGOptions) error {
+	opt := SVGOptions{}
+	if len(svgOpt) > 0 {
+		opt = svgOpt[0]
+	}
+
+	var minify func([]byte) []byte
+	if r.opt.MinifySVG || opt.Minify {
+		minify = minifySVG
+	}
+	return r.textTemplate(w, status, ContentSVG, name, binding, minify)
+}
+
+// TextTemplate renders the named template with text/template semantics as
+// contentType, for formats such as nginx configs, systemd units, or
+// cloud-init user data that HTML escaping would mangle. The charset is
+// appended to textual types unless contentType already names one.
+func (r *Render) TextTemplate(w http.ResponseWriter, status int, contentType, name string, binding interface{}) error {
+	return r.textTemplate(w, status, contentType, name, binding, nil)
+}
+
+func (r *Render) textTemplate(w http.ResponseWriter, status int, contentType, name string, binding interface{}, minify func([]byte) []byte) error {
+	set, err := r.textSet()
+	if err != nil {
+		return r.fail(w, err)
+	}
+
+	head := Head{
+		ContentType: r.withCharset(contentType),
+		Status:      status,
+	}
+
+	t := TextTemplate{
+		Head:      head,
+		Name:      name,
+		Templates: set,
+		Minify:    minify,
+	}
+
+	return r.Render(w, t, binding)
+}
@@ -0,0 +1,80 @@
+package renderall
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ServerTimingHeader header constant.
+const ServerTimingHeader = "Server-Timing"
+
+// AddServerTiming appends a Server-Timing metric to h, e.g. a database query
+// timed by the handler. It must be called before the render starts writing.
+func AddServerTiming(h http.Header, name string, d time.Duration, desc string) {
+	metric := name + ";dur=" + strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 3, 64)
+	if desc != "" {
+		metric += ";desc=" + strconv.Quote(desc)
+	}
+	h.Add(ServerTimingHeader, metric)
+}
+
+// timingMetric names the Server-Timing metric for the work e does before
+// writing: executing templates or marshalling data.
+func timingMetric(e Engine) string {
+	switch e.(type) {
+	case HTML, TextTemplate:
+		return "tmpl"
+	case JSON, JSONP, XML, BrowsableJSON, CSV:
+		return "marshal"
+	}
+	return "render"
+}
+
+// timingWriter adds the render's Server-Timing metrics just before the
+// headers go out, which is when engines have finished that work.
+type timingWriter struct {
+	http.ResponseWriter
+	req         *http.Request
+	metric      string
+	start       time.Time
+	now         func() time.Time
+	wroteHeader bool
+}
+
+func (t *timingWriter) WriteHeader(status int) {
+	if !t.wroteHeader {
+		t.wroteHeader = true
+		h := t.Header()
+		AddServerTiming(h, t.metric, t.now().Sub(t.start), "")
+		if start, ok := requestStart(t.req); ok {
+			AddServerTiming(h, "total", t.now().Sub(start), "")
+		}
+	}
+	t.ResponseWriter.WriteHeader(status)
+}
+
+func (t *timingWriter) Write(b []byte) (int, error) {
+	if !t.wroteHeader {
+		t.WriteHeader(http.StatusOK)
+	}
+	return t.ResponseWriter.Write(b)
+}
+
+// Flush sends any buffered data to the client.
+func (t *timingWriter) Flush() {
+	http.NewResponseController(t.ResponseWriter).Flush()
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (t *timingWriter) Unwrap() http.ResponseWriter {
+	return t.ResponseWriter
+}
+
+// withTiming wraps w to emit Server-Timing for e when the option is on.
+func (r *Render) withTiming(w http.ResponseWriter, req *http.Request, e Engine) http.ResponseWriter {
+	if !r.opt.ServerTiming {
+		return w
+	}
+	return &timingWriter{ResponseWriter: w, req: req, metric: timingMetric(e), start: r.now(), now: r.now}
+}
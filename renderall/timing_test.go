@@ -0,0 +1,24 @@
+package renderall
+
+import (
+	"context"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestServerTimingClock(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	r := New(Options{ServerTiming: true, Clock: func() time.Time { return now }})
+	req := httptest.NewRequest("GET", "/", nil)
+	req = req.WithContext(context.WithValue(req.Context(), startKey, now.Add(-5*time.Millisecond)))
+	w := httptest.NewRecorder()
+	if err := r.JSON(w, 200, 1, JSONOptions{Request: req}); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"marshal;dur=0.000", "total;dur=5.000"}
+	if got := w.Header().Values(ServerTimingHeader); !reflect.DeepEqual(got, want) {
+		t.Errorf("%s = %q, want %q", ServerTimingHeader, got, want)
+	}
+}
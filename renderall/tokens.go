@@ -0,0 +1,62 @@
+package renderall
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+)
+
+// TokenFunc resolves the value of a placeholder for one render.
+type TokenFunc func(*RenderContext) string
+
+// ReplaceTokens returns a PostRender hook substituting placeholders such as
+// "__REQUEST_ID__" in rendered bodies, for values only known late in the
+// request. Each value is resolved once per render and only when its token
+// occurs, and the body is rewritten in a single pass. Values are inserted
+// verbatim, so they must already be safe for the response's content type.
+func ReplaceTokens(tokens map[string]TokenFunc) func(*RenderContext) error {
+	// Longest first so overlapping tokens resolve to the most specific.
+	names := make([]string, 0, len(tokens))
+	for name := range tokens {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return len(names[i]) > len(names[j])
+	})
+
+	return func(ctx *RenderContext) error {
+		var pairs []string
+		for _, name := range names {
+			if bytes.Contains(ctx.Body, []byte(name)) {
+				pairs = append(pairs, name, tokens[name](ctx))
+			}
+		}
+		if len(pairs) == 0 {
+			return nil
+		}
+
+		var out bytes.Buffer
+		out.Grow(len(ctx.Body))
+		if _, err := strings.NewReplacer(pairs...).WriteString(&out, string(ctx.Body)); err != nil {
+			return err
+		}
+		ctx.Body = out.Bytes()
+		return nil
+	}
+}
+
+// NonceToken resolves to the response's CSP nonce, see RenderContext.Nonce.
+func NonceToken(ctx *RenderContext) string {
+	return ctx.Nonce
+}
+
+// HeaderToken returns a TokenFunc resolving to the named request header,
+// e.g. HeaderToken("X-Request-ID").
+func HeaderToken(header string) TokenFunc {
+	return func(ctx *RenderContext) string {
+		if ctx.Request == nil {
+			return ""
+		}
+		return ctx.Request.Header.Get(header)
+	}
+}
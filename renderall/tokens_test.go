@@ -0,0 +1,31 @@
+package renderall
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNonceToken(t *testing.T) {
+	r := New(Options{
+		Directory: templateDir(t, map[string]string{
+			"page.tmpl": `<script nonce="{{ cspNonce }}">x</script><p>__NONCE__</p>`,
+		}),
+		Random:                bytes.NewReader(bytes.Repeat([]byte{1}, 16)),
+		ContentSecurityPolicy: "script-src 'nonce-{nonce}'",
+		PostRender:            []func(*RenderContext) error{ReplaceTokens(map[string]TokenFunc{"__NONCE__": NonceToken})},
+	})
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/", nil)
+	if err := r.HTML(w, 200, "page", nil, HTMLOptions{Request: req}); err != nil {
+		t.Fatal(err)
+	}
+	const nonce = "AQEBAQEBAQEBAQEBAQEBAQ"
+	if got, want := w.Body.String(), `<script nonce="`+nonce+`">x</script><p>`+nonce+`</p>`; got != want {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+	if csp := w.Header().Get("Content-Security-Policy"); !strings.Contains(csp, nonce) {
+		t.Errorf("Content-Security-Policy = %q", csp)
+	}
+}
@@ -0,0 +1,125 @@
+package renderall
+
+import (
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"net/http"
+	"strings"
+)
+
+// TrailerHeader header constant.
+const TrailerHeader = "Trailer"
+
+// TrailerWriter is a ResponseWriter that announces trailers before the body
+// and sends them after it, e.g. Server-Timing or a Content-Digest of a
+// streamed body. Render into it as usual, then call Close before the
+// handler returns.
+type TrailerWriter struct {
+	http.ResponseWriter
+	names   []string
+	values  http.Header
+	hashes  []hash.Hash
+	algs    []string
+	started bool
+}
+
+// Trailers wraps w to send the named trailers. Naming Content-Digest has the
+// body hashed as it is written, with Options.DigestAlgorithms or sha-256.
+func (r *Render) Trailers(w http.ResponseWriter, names ...string) (*TrailerWriter, error) {
+	t := &TrailerWriter{ResponseWriter: w, values: http.Header{}}
+	for _, name := range names {
+		name = http.CanonicalHeaderKey(name)
+		t.names = append(t.names, name)
+		if name != ContentDigest {
+			continue
+		}
+		algs := r.opt.DigestAlgorithms
+		if len(algs) == 0 {
+			algs = []string{"sha-256"}
+		}
+		for _, alg := range algs {
+			newHash, ok := digestAlgorithms[strings.ToLower(alg)]
+			if !ok {
+				return nil, fmt.Errorf("renderall: unsupported digest algorithm %q", alg)
+			}
+			t.algs = append(t.algs, strings.ToLower(alg))
+			t.hashes = append(t.hashes, newHash())
+		}
+	}
+	if len(t.names) > 0 {
+		w.Header().Add(TrailerHeader, strings.Join(t.names, ", "))
+	}
+	return t, nil
+}
+
+// Set records a trailer value, sent once the body is done. Names that were
+// not announced are still sent where the protocol allows it.
+func (t *TrailerWriter) Set(name, value string) {
+	t.values.Set(name, value)
+}
+
+// WriteHeader sends the status and headers. Any Content-Length is dropped so
+// HTTP/1.1 responses are chunked, which trailers need.
+func (t *TrailerWriter) WriteHeader(status int) {
+	if t.started {
+		return
+	}
+	t.started = true
+	t.Header().Del(ContentLength)
+	t.ResponseWriter.WriteHeader(status)
+}
+
+// Write sends b, hashing it for a Content-Digest trailer.
+func (t *TrailerWriter) Write(b []byte) (int, error) {
+	if !t.started {
+		t.WriteHeader(http.StatusOK)
+	}
+	for _, h := range t.hashes {
+		h.Write(b)
+	}
+	return t.ResponseWriter.Write(b)
+}
+
+// Flush sends any buffered data to the client.
+func (t *TrailerWriter) Flush() {
+	http.NewResponseController(t.ResponseWriter).Flush()
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (t *TrailerWriter) Unwrap() http.ResponseWriter {
+	return t.ResponseWriter
+}
+
+// Close sets the trailers on the underlying writer, computing Content-Digest
+// if it was announced. The response must not be written to afterwards.
+func (t *TrailerWriter) Close() error {
+	if !t.started {
+		t.WriteHeader(http.StatusOK)
+	}
+	if len(t.hashes) > 0 {
+		fields := make([]string, len(t.hashes))
+		for i, h := range t.hashes {
+			fields[i] = t.algs[i] + "=:" + base64.StdEncoding.EncodeToString(h.Sum(nil)) + ":"
+		}
+		t.values.Set(ContentDigest, strings.Join(fields, ", "))
+	}
+
+	h := t.Header()
+	for name, v := range t.values {
+		if !t.announced(name) {
+			name = http.TrailerPrefix + name
+		}
+		h[name] = v
+	}
+	return nil
+}
+
+func (t *TrailerWriter) announced(name string) bool {
+	for _, n := range t.names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
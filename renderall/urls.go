@@ -0,0 +1,113 @@
+package renderall
+
+import (
+	"fmt"
+	"html/template"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// URLBuilder builds the URL of a named route for the url template func.
+// Params are the route's variables in order. Wrap a router's own naming,
+// e.g. gorilla/mux's Router.Get(name).URL, or use Routes.
+type URLBuilder interface {
+	BuildURL(name string, params ...string) (string, error)
+}
+
+// URLBuilderFunc adapts an ordinary function to a URLBuilder.
+type URLBuilderFunc func(name string, params ...string) (string, error)
+
+// BuildURL calls f(name, params...).
+func (f URLBuilderFunc) BuildURL(name string, params ...string) (string, error) {
+	return f(name, params...)
+}
+
+// Routes is a URLBuilder over patterns in the gorilla/mux and chi syntax,
+// e.g. "/users/{id}" or "/users/{id:[0-9]+}", for routers that don't name
+// routes themselves.
+type Routes struct {
+	mu       sync.RWMutex
+	patterns map[string]string
+}
+
+// NewRoutes creates an empty Routes.
+func NewRoutes() *Routes {
+	return &Routes{patterns: map[string]string{}}
+}
+
+// Add names a route pattern.
+func (rt *Routes) Add(name, pattern string) {
+	rt.mu.Lock()
+	rt.patterns[name] = pattern
+	rt.mu.Unlock()
+}
+
+// BuildURL fills the pattern's {variables} with params, path escaped.
+func (rt *Routes) BuildURL(name string, params ...string) (string, error) {
+	rt.mu.RLock()
+	pattern, ok := rt.patterns[name]
+	rt.mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("renderall: no route named %q", name)
+	}
+
+	var b strings.Builder
+	n := 0
+	for {
+		open := strings.IndexByte(pattern, '{')
+		if open < 0 {
+			b.WriteString(pattern)
+			break
+		}
+		end := variableEnd(pattern[open:])
+		if end < 0 {
+			return "", fmt.Errorf("renderall: route %q has an unclosed variable", name)
+		}
+		if n == len(params) {
+			return "", fmt.Errorf("renderall: route %q needs more than %d params", name, len(params))
+		}
+		b.WriteString(pattern[:open])
+		b.WriteString(url.PathEscape(params[n]))
+		n++
+		pattern = pattern[open+end+1:]
+	}
+	if n != len(params) {
+		return "", fmt.Errorf("renderall: route %q takes %d params, got %d", name, n, len(params))
+	}
+	return b.String(), nil
+}
+
+// variableEnd returns the index of the brace closing the variable s starts
+// with, skipping the braces nested in its pattern, as in "{id:[0-9]{3}}",
+// or -1 if it is unclosed.
+func variableEnd(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// urlFuncs expose Options.URLBuilder to templates as url.
+func (r *Render) urlFuncs() template.FuncMap {
+	return template.FuncMap{
+		"url": func(name string, params ...interface{}) (string, error) {
+			if r.opt.URLBuilder == nil {
+				return "", fmt.Errorf("renderall: url %q used without Options.URLBuilder", name)
+			}
+			s := make([]string, len(params))
+			for i, p := range params {
+				s[i] = fmt.Sprint(p)
+			}
+			return r.opt.URLBuilder.BuildURL(name, s...)
+		},
+	}
+}
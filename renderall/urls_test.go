@@ -0,0 +1,29 @@
+package renderall
+
+import "testing"
+
+func TestRoutesBuildURL(t *testing.T) {
+	rt := NewRoutes()
+	rt.Add("user", "/users/{id}")
+	rt.Add("code", "/codes/{id:[0-9]{3}}/{name}")
+	rt.Add("open", "/users/{id")
+	tests := []struct {
+		name   string
+		params []string
+		want   string
+		err    bool
+	}{
+		{"user", []string{"a b"}, "/users/a%20b", false},
+		{"code", []string{"123", "x/y"}, "/codes/123/x%2Fy", false},
+		{"code", []string{"123"}, "", true},
+		{"user", []string{"1", "2"}, "", true},
+		{"open", []string{"1"}, "", true},
+		{"missing", nil, "", true},
+	}
+	for _, tt := range tests {
+		got, err := rt.BuildURL(tt.name, tt.params...)
+		if (err != nil) != tt.err || got != tt.want {
+			t.Errorf("BuildURL(%q, %q) = %q, %v; want %q, error %v", tt.name, tt.params, got, err, tt.want, tt.err)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package renderall
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// TemplateStats is a template's usage since the Render was created, as
+// tracked with Options.TemplateStats.
+type TemplateStats struct {
+	Name string `json:"name"`
+	// Renders counts executions as a page, layout, yielded page, or partial.
+	Renders  int64     `json:"renders"`
+	LastUsed time.Time `json:"last_used"`
+	// AverageDuration is the mean execution time, including the templates it calls.
+	AverageDuration time.Duration `json:"average_duration"`
+	// Included is set when a rendered template invokes this one with
+	// {{ template }} or {{ block }}, which are not counted as renders.
+	Included bool `json:"included"`
+}
+
+// Unused reports whether the template was neither rendered nor included.
+func (s TemplateStats) Unused() bool {
+	return s.Renders == 0 && !s.Included
+}
+
+type templateCounter struct {
+	renders int64
+	total   time.Duration
+	last    time.Time
+}
+
+// templateUsage tallies template executions by name.
+type templateUsage struct {
+	mu       sync.Mutex
+	counters map[string]*templateCounter
+}
+
+// trackTemplate counts an execution of name that began at start.
+func (r *Render) trackTemplate(name string, start time.Time) {
+	if !r.opt.TemplateStats {
+		return
+	}
+	now := time.Now()
+	u := &r.usage
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.counters == nil {
+		u.counters = map[string]*templateCounter{}
+	}
+	c := u.counters[name]
+	if c == nil {
+		c = &templateCounter{}
+		u.counters[name] = c
+	}
+	c.renders++
+	c.total += now.Sub(start)
+	c.last = now
+}
+
+// TemplateUsage returns the stats of every template in the default set,
+// sorted by name, plus any rendered from a theme only. The built-in error
+// pages are listed only once rendered. Counts are only
+// kept while Options.TemplateStats is set.
+func (r *Render) TemplateUsage() []TemplateStats {
+	r.usage.mu.Lock()
+	stats := map[string]*TemplateStats{}
+	for name, c := range r.usage.counters {
+		stats[name] = &TemplateStats{
+			Name:            name,
+			Renders:         c.renders,
+			LastUsed:        c.last,
+			AverageDuration: c.total / time.Duration(c.renders),
+		}
+	}
+	r.usage.mu.Unlock()
+
+	for _, name := range r.TemplateNames() {
+		// The built-in error pages are always there should they be needed.
+		if _, builtin := builtinTemplates[name]; builtin {
+			continue
+		}
+		if stats[name] == nil {
+			stats[name] = &TemplateStats{Name: name}
+		}
+	}
+	var mark func(n *TemplateNode)
+	mark = func(n *TemplateNode) {
+		for _, call := range n.Calls {
+			if s := stats[call.Name]; s != nil && !s.Included {
+				s.Included = true
+				mark(call)
+			}
+		}
+	}
+	for _, s := range stats {
+		if s.Renders > 0 {
+			if tree := r.TemplateTree(s.Name); tree != nil {
+				mark(tree)
+			}
+		}
+	}
+
+	out := make([]TemplateStats, 0, len(stats))
+	for _, s := range stats {
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+var templateUsagePage = template.Must(template.New("usage").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Template usage</title>
+<style>body{font-family:sans-serif}td,th{padding:2px 8px;text-align:left}.unused{color:#b00}</style></head>
+<body>
+<h1>Template usage</h1>
+<table>
+<tr><th>Template</th><th>Renders</th><th>Average</th><th>Last used</th><th>Included</th></tr>
+{{ range . }}<tr{{ if .Unused }} class="unused"{{ end }}><td>{{ .Name }}</td><td>{{ .Renders }}</td><td>{{ if .Renders }}{{ .AverageDuration }}{{ end }}</td><td>{{ if .Renders }}{{ .LastUsed.Format "2006-01-02 15:04:05" }}{{ end }}</td><td>{{ if .Included }}yes{{ end }}</td></tr>
+{{ end }}</table>
+</body>
+</html>
+`))
+
+// TemplateUsageHandler serves TemplateUsage as an HTML table to browsers,
+// with unused templates highlighted, and as JSON otherwise. It writes
+// directly rather than rendering, so it doesn't count itself.
+func (r *Render) TemplateUsageHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		stats := r.TemplateUsage()
+		w.Header().Set("Cache-Control", "no-store")
+		if prefersHTML(req) {
+			w.Header().Set(ContentType, r.withCharset(ContentHTML))
+			templateUsagePage.Execute(w, stats)
+			return
+		}
+		b, err := json.MarshalIndent(stats, "", "  ")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set(ContentType, r.withCharset(ContentJSON))
+		w.Write(b)
+	})
+}
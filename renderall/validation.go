@@ -0,0 +1,127 @@
+package renderall
+
+import (
+	"html/template"
+	"net/http"
+	"reflect"
+	"sort"
+)
+
+// ValidationErrors maps field names to their validation failure messages.
+type ValidationErrors map[string][]string
+
+// FieldError is the per-field detail emitted in a validation error body.
+type FieldError struct {
+	Field    string   `json:"field" xml:"field,attr"`
+	Messages []string `json:"messages" xml:"message"`
+}
+
+// fieldError is satisfied by the elements of go-playground/validator's
+// ValidationErrors, without depending on that package.
+type fieldError interface {
+	Field() string
+	Error() string
+}
+
+// ToValidationErrors converts v to ValidationErrors. It accepts
+// ValidationErrors, a map[string][]string, a map[string]string, or an error
+// that is a slice of field errors such as validator.ValidationErrors. It
+// returns nil if v is none of these.
+func ToValidationErrors(v interface{}) ValidationErrors {
+	switch errs := v.(type) {
+	case nil:
+		return nil
+	case ValidationErrors:
+		return errs
+	case map[string][]string:
+		return ValidationErrors(errs)
+	case map[string]string:
+		out := ValidationErrors{}
+		for field, msg := range errs {
+			out[field] = []string{msg}
+		}
+		return out
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		return nil
+	}
+	out := ValidationErrors{}
+	for i := 0; i < rv.Len(); i++ {
+		fe, ok := rv.Index(i).Interface().(fieldError)
+		if !ok {
+			return nil
+		}
+		out[fe.Field()] = append(out[fe.Field()], fe.Error())
+	}
+	return out
+}
+
+// Fields returns the errors as FieldErrors sorted by field name.
+func (ve ValidationErrors) Fields() []FieldError {
+	names := make([]string, 0, len(ve))
+	for name := range ve {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fields := make([]FieldError, 0, len(names))
+	for _, name := range names {
+		fields = append(fields, FieldError{Field: name, Messages: ve[name]})
+	}
+	return fields
+}
+
+// Has reports whether field has any errors.
+func (ve ValidationErrors) Has(field string) bool {
+	return len(ve[field]) > 0
+}
+
+// Get returns the messages for field.
+func (ve ValidationErrors) Get(field string) []string {
+	return ve[field]
+}
+
+func (ve ValidationErrors) details() []interface{} {
+	fields := ve.Fields()
+	details := make([]interface{}, len(fields))
+	for i, f := range fields {
+		details[i] = f
+	}
+	return details
+}
+
+// Validation writes a 422 standard error body listing the field-level
+// failures in errs. See ToValidationErrors for the accepted types.
+func (r *Render) Validation(w http.ResponseWriter, errs interface{}) error {
+	ve := ToValidationErrors(errs)
+	return r.Error(w, http.StatusUnprocessableEntity, "validation_failed", "validation failed", ve.details()...)
+}
+
+// ValidationHTML re-renders the named form template with a 422 status and the
+// errors bound to the errors, hasError, and fieldErrors template funcs, as
+// well as to the form helpers.
+func (r *Render) ValidationHTML(w http.ResponseWriter, name string, binding interface{}, errs interface{}, htmlOpt ...HTMLOptions) error {
+	ve := ToValidationErrors(errs)
+	var req *http.Request
+	if len(htmlOpt) > 0 {
+		req = htmlOpt[0].Request
+	}
+	funcs := mergeFuncs(validationFuncs(ve), r.formFuncs(req, ve))
+	return r.html(w, http.StatusUnprocessableEntity, name, binding, funcs, htmlOpt)
+}
+
+func validationFuncs(ve ValidationErrors) template.FuncMap {
+	return template.FuncMap{
+		"errors": func() ValidationErrors {
+			return ve
+		},
+		"hasError": func(field string) bool {
+			return ve.Has(field)
+		},
+		"fieldErrors": func(field string) []string {
+			return ve.Get(field)
+		},
+	}
+}
@@ -0,0 +1,45 @@
+package renderall
+
+import (
+	"html/template"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// templateDir writes files, by slash separated name, to a temporary
+// directory for Options.Directory.
+func templateDir(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, data := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestValidationHTMLUserFuncs(t *testing.T) {
+	r := New(Options{
+		Directory: templateDir(t, map[string]string{
+			"form.tmpl": `{{ hasError "name" }} {{ len errors }}`,
+		}),
+		Funcs: []template.FuncMap{{"hasError": func(string) string { return "mine" }}},
+	})
+	w := httptest.NewRecorder()
+	if err := r.ValidationHTML(w, "form", nil, ValidationErrors{"name": {"required"}}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := w.Body.String(), "mine 1"; got != want {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+	if w.Code != 422 {
+		t.Errorf("status = %d, want 422", w.Code)
+	}
+}
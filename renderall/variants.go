@@ -0,0 +1,82 @@
+package renderall
+
+import (
+	"html/template"
+	"net/http"
+	"strings"
+)
+
+// VariantParam is the query parameter the default Options.VariantURL uses.
+const VariantParam = "variant"
+
+// variant resolves the variant a render asks for.
+func (r *Render) variant(opt HTMLOptions) string {
+	if opt.Variant != "" {
+		return opt.Variant
+	}
+	if r.opt.VariantResolver != nil && opt.Request != nil {
+		return r.opt.VariantResolver(opt.Request)
+	}
+	return ""
+}
+
+// variantURL returns the URL of variant of the page req is for, or of the
+// canonical page for a blank variant. The default is absolute, as AMP
+// requires of canonical links, when Options.BaseURL or TrustHost allow it.
+func (r *Render) variantURL(req *http.Request, variant string) string {
+	if r.opt.VariantURL != nil {
+		return r.opt.VariantURL(req, variant)
+	}
+	u := *req.URL
+	q := u.Query()
+	if variant == "" {
+		q.Del(VariantParam)
+	} else {
+		q.Set(VariantParam, variant)
+	}
+	u.RawQuery = q.Encode()
+	if r.opt.BaseURL != "" {
+		return strings.TrimSuffix(r.opt.BaseURL, "/") + u.RequestURI()
+	}
+	if !r.opt.TrustHost {
+		return u.RequestURI()
+	}
+	scheme := "http"
+	if req.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + req.Host + u.RequestURI()
+}
+
+// variantFuncs expose the rendered variant and the links between a page
+// and its variants. The canonical page links each variant that has a
+// template, as amphtml for "amp" and alternate otherwise; a variant links
+// back to the canonical page.
+func (r *Render) variantFuncs(tmpl *template.Template, name, variant string, req *http.Request) template.FuncMap {
+	return template.FuncMap{
+		"variant": func() string {
+			return variant
+		},
+		"alternateLinks": func() template.HTML {
+			if req == nil {
+				return ""
+			}
+			esc := template.HTMLEscapeString
+			if variant != "" {
+				return template.HTML(`<link rel="canonical" href="` + esc(r.variantURL(req, "")) + `">`)
+			}
+			var b strings.Builder
+			for _, v := range r.opt.Variants {
+				if tmpl.Lookup(name+"."+v) == nil {
+					continue
+				}
+				rel := "alternate"
+				if v == "amp" {
+					rel = "amphtml"
+				}
+				b.WriteString(`<link rel="` + rel + `" href="` + esc(r.variantURL(req, v)) + `">`)
+			}
+			return template.HTML(b.String())
+		},
+	}
+}
@@ -0,0 +1,236 @@
+package renderall
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ContentVCard header value for vCard contacts.
+const ContentVCard = "text/vcard"
+
+// Contact is a person or organisation rendered as a vCard 4.0 (RFC 6350).
+// Blank fields are left out.
+type Contact struct {
+	// FormattedName is the display name. Defaults to the Name parts, then Organization.
+	FormattedName string
+	Name          ContactName
+	Nickname      string
+	Organization  string
+	Title         string
+	Emails        []ContactValue
+	Phones        []ContactValue
+	Addresses     []ContactAddress
+	URL           string
+	Note          string
+	// Birthday, if not zero, is written as a date.
+	Birthday time.Time
+	// UID identifies the contact across downloads, e.g. "urn:uuid:...".
+	UID string
+}
+
+// ContactName is the structured name of a Contact.
+type ContactName struct {
+	Family     string
+	Given      string
+	Additional string
+	Prefix     string
+	Suffix     string
+}
+
+// ContactValue is an email address or phone number with an optional type,
+// e.g. "work", "home", or "cell".
+type ContactValue struct {
+	Type  string
+	Value string
+}
+
+// ContactAddress is a postal address with an optional type.
+type ContactAddress struct {
+	Type       string
+	POBox      string
+	Extended   string
+	Street     string
+	Locality   string
+	Region     string
+	PostalCode string
+	Country    string
+}
+
+// VCardOptions is a struct for overriding some rendering Options for specific VCard call.
+type VCardOptions struct {
+	// Offers the contacts as a download with this file name, e.g. "contact.vcf". Defaults to blank ("").
+	Filename string
+}
+
+// VCard built-in renderer. It renders a Contact, *Contact, or []Contact.
+type VCard struct {
+	Head
+	// Filename, if set, offers the contacts as a download.
+	Filename string
+}
+
+// Render a vCard response.
+func (c VCard) Render(w http.ResponseWriter, v interface{}) error {
+	var contacts []Contact
+	switch v := v.(type) {
+	case Contact:
+		contacts = []Contact{v}
+	case *Contact:
+		contacts = []Contact{*v}
+	case []Contact:
+		contacts = v
+	default:
+		return fmt.Errorf("renderall: vcard requires a Contact or []Contact, got %T", v)
+	}
+
+	buf := bufPool.Get()
+	defer bufPool.Put(buf)
+	for _, contact := range contacts {
+		writeVCard(buf, contact)
+	}
+
+	setAttachment(w.Header(), c.Filename)
+	w.Header().Set(ContentLength, strconv.Itoa(buf.Len()))
+	c.Head.Write(w)
+	buf.WriteTo(w)
+	return nil
+}
+
+// writeVCard writes one vCard, escaping values and folding long lines.
+func writeVCard(b *bytes.Buffer, c Contact) {
+	line := func(name, value string) {
+		if value != "" {
+			foldVCardLine(b, name+":"+value)
+		}
+	}
+	typed := func(name, typ, value string) {
+		if value == "" {
+			return
+		}
+		if typ != "" {
+			name += ";TYPE=" + vcardParam(typ)
+		}
+		foldVCardLine(b, name+":"+value)
+	}
+
+	n := c.Name
+	fn := c.FormattedName
+	if fn == "" {
+		fn = strings.Join(nonBlankStrings(n.Prefix, n.Given, n.Additional, n.Family, n.Suffix), " ")
+	}
+	if fn == "" {
+		fn = c.Organization
+	}
+
+	b.WriteString("BEGIN:VCARD\r\nVERSION:4.0\r\n")
+	// FN is required even if blank.
+	foldVCardLine(b, "FN:"+vcardEscape(fn))
+	if n != (ContactName{}) {
+		line("N", vcardCompound(n.Family, n.Given, n.Additional, n.Prefix, n.Suffix))
+	}
+	line("NICKNAME", vcardEscape(c.Nickname))
+	line("ORG", vcardEscape(c.Organization))
+	line("TITLE", vcardEscape(c.Title))
+	for _, e := range c.Emails {
+		typed("EMAIL", e.Type, vcardEscape(e.Value))
+	}
+	for _, p := range c.Phones {
+		typed("TEL", p.Type, vcardEscape(p.Value))
+	}
+	for _, a := range c.Addresses {
+		if a == (ContactAddress{Type: a.Type}) {
+			continue
+		}
+		typed("ADR", a.Type, vcardCompound(a.POBox, a.Extended, a.Street, a.Locality, a.Region, a.PostalCode, a.Country))
+	}
+	line("URL", vcardURI(c.URL))
+	line("NOTE", vcardEscape(c.Note))
+	if !c.Birthday.IsZero() {
+		line("BDAY", c.Birthday.Format("20060102"))
+	}
+	line("UID", vcardURI(c.UID))
+	b.WriteString("END:VCARD\r\n")
+}
+
+func nonBlankStrings(s ...string) []string {
+	out := s[:0:0]
+	for _, v := range s {
+		if v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+var vcardEscaper = strings.NewReplacer(`\`, `\\`, ",", `\,`, ";", `\;`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+// vcardEscape escapes a text value.
+func vcardEscape(s string) string {
+	return vcardEscaper.Replace(s)
+}
+
+// vcardURI percent-encodes line breaks in a URI value, which cannot take
+// text escapes, so it cannot start a property of its own.
+func vcardURI(s string) string {
+	return strings.NewReplacer("\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// vcardCompound joins escaped components with semicolons.
+func vcardCompound(parts ...string) string {
+	for i, p := range parts {
+		parts[i] = vcardEscape(p)
+	}
+	return strings.Join(parts, ";")
+}
+
+// vcardParam quotes a parameter value that holds separators.
+func vcardParam(s string) string {
+	s = strings.NewReplacer(`"`, "", "\r", "", "\n", "").Replace(s)
+	if strings.ContainsAny(s, ",;:") {
+		return `"` + s + `"`
+	}
+	return s
+}
+
+// foldVCardLine writes a content line, folding it at 75 octets without
+// splitting a UTF-8 sequence. Continuation lines start with a space.
+func foldVCardLine(b *bytes.Buffer, s string) {
+	limit := 75
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		b.WriteString(s[:cut])
+		b.WriteString("\r\n ")
+		s = s[cut:]
+		// The leading space counts towards the next line's length.
+		limit = 74
+	}
+	b.WriteString(s)
+	b.WriteString("\r\n")
+}
+
+// VCard renders one or more contacts as a vCard file.
+func (r *Render) VCard(w http.ResponseWriter, status int, v interface{}, vcardOpt ...VCardOptions) error {
+	opt := VCardOptions{}
+	if len(vcardOpt) > 0 {
+		opt = vcardOpt[0]
+	}
+	head := Head{
+		ContentType: ContentVCard + "; charset=utf-8",
+		Status:      status,
+	}
+
+	c := VCard{
+		Head:     head,
+		Filename: opt.Filename,
+	}
+
+	return r.Render(w, c, v)
+}
@@ -0,0 +1,32 @@
+package renderall
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestVCardLineBreaks(t *testing.T) {
+	var b bytes.Buffer
+	writeVCard(&b, Contact{
+		FormattedName: "A\r\nTEL:1",
+		URL:           "http://x\r\nTEL:123",
+		UID:           "urn:uuid:1\nEMAIL:a@b",
+		Phones:        []ContactValue{{Type: "work\r\nX-A:1", Value: "2\nTEL:3"}},
+		Note:          "line\rbreak",
+	})
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\r\n"), "\r\n")
+	want := []string{
+		"BEGIN:VCARD",
+		"VERSION:4.0",
+		`FN:A\nTEL:1`,
+		`TEL;TYPE="workX-A:1":2\nTEL:3`,
+		"URL:http://x%0D%0ATEL:123",
+		`NOTE:line\nbreak`,
+		"UID:urn:uuid:1%0AEMAIL:a@b",
+		"END:VCARD",
+	}
+	if strings.Join(lines, "|") != strings.Join(want, "|") {
+		t.Fatalf("got lines\n%s\nwant\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+}
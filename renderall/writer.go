@@ -0,0 +1,144 @@
+package renderall
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+)
+
+// ResponseWriter wraps an http.ResponseWriter to track what a response
+// actually emitted: its status, whether the header has been sent, and the
+// body bytes written. Renders use it to avoid writing error responses over
+// ones already under way, and middleware can use it for metrics:
+//
+//	rw := renderall.NewResponseWriter(w)
+//	next.ServeHTTP(rw, req)
+//	observe(req, rw.Status(), rw.BytesWritten())
+//
+// It implements http.Flusher, http.Hijacker, and http.Pusher whatever the
+// underlying writer supports, returning http.ErrNotSupported from Hijack
+// and Push when it does not, and Unwrap for http.ResponseController.
+type ResponseWriter struct {
+	http.ResponseWriter
+	status   int
+	written  int64
+	hijacked bool
+}
+
+// NewResponseWriter returns w wrapped in a ResponseWriter, or w itself if
+// it already is one.
+func NewResponseWriter(w http.ResponseWriter) *ResponseWriter {
+	if rw, ok := w.(*ResponseWriter); ok {
+		return rw
+	}
+	return &ResponseWriter{ResponseWriter: w}
+}
+
+// WriteHeader sends the header with status. Informational 1xx statuses are
+// passed on without committing the response; calls after the header was
+// sent are ignored rather than passed on.
+func (rw *ResponseWriter) WriteHeader(status int) {
+	if rw.status != 0 {
+		return
+	}
+	if status >= 100 && status < 200 && status != http.StatusSwitchingProtocols {
+		rw.ResponseWriter.WriteHeader(status)
+		return
+	}
+	rw.status = status
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+// Write writes the body, sending a 200 header first if none was sent.
+func (rw *ResponseWriter) Write(b []byte) (int, error) {
+	if rw.status == 0 {
+		rw.WriteHeader(http.StatusOK)
+	}
+	n, err := rw.ResponseWriter.Write(b)
+	rw.written += int64(n)
+	return n, err
+}
+
+// ReadFrom copies the body from src, keeping the underlying writer's
+// io.ReaderFrom, such as sendfile for files, if it has one.
+func (rw *ResponseWriter) ReadFrom(src io.Reader) (int64, error) {
+	if rw.status == 0 {
+		rw.WriteHeader(http.StatusOK)
+	}
+	var n int64
+	var err error
+	if rf, ok := rw.ResponseWriter.(io.ReaderFrom); ok {
+		n, err = rf.ReadFrom(src)
+	} else {
+		n, err = io.Copy(struct{ io.Writer }{rw.ResponseWriter}, src)
+	}
+	rw.written += n
+	return n, err
+}
+
+// Flush sends any buffered data to the client.
+func (rw *ResponseWriter) Flush() {
+	if http.NewResponseController(rw.ResponseWriter).Flush() == nil && rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+}
+
+// Hijack takes over the connection, e.g. for a WebSocket upgrade, if the
+// underlying writer supports it. The response counts as sent afterwards.
+func (rw *ResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, brw, err := http.NewResponseController(rw.ResponseWriter).Hijack()
+	if err == nil {
+		rw.hijacked = true
+	}
+	return conn, brw, err
+}
+
+// Push starts an HTTP/2 server push of target if the underlying writer
+// supports it.
+func (rw *ResponseWriter) Push(target string, opts *http.PushOptions) error {
+	if p, ok := rw.ResponseWriter.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (rw *ResponseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// Status returns the status sent with the header, or 0 if it has not been sent.
+func (rw *ResponseWriter) Status() int {
+	return rw.status
+}
+
+// HeaderWritten reports whether the header has been sent or the connection
+// hijacked, after which the status and headers can no longer change.
+func (rw *ResponseWriter) HeaderWritten() bool {
+	return rw.status != 0 || rw.hijacked
+}
+
+// BytesWritten returns the number of body bytes written so far.
+func (rw *ResponseWriter) BytesWritten() int64 {
+	return rw.written
+}
+
+// headerWritten reports whether a ResponseWriter in w's chain has sent the
+// header. Every one is checked, as a render wraps its own around any the
+// handler passed in, such as a TeeWriter's. Untracked writers report false.
+func headerWritten(w http.ResponseWriter) bool {
+	for {
+		switch v := w.(type) {
+		case *ResponseWriter:
+			if v.HeaderWritten() {
+				return true
+			}
+			w = v.ResponseWriter
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = v.Unwrap()
+		default:
+			return false
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package renderall
+
+import (
+	"bufio"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResponseWriterTracking(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rw := NewResponseWriter(rec)
+	if NewResponseWriter(rw) != rw {
+		t.Error("NewResponseWriter rewrapped a ResponseWriter")
+	}
+	if rw.HeaderWritten() {
+		t.Error("a new ResponseWriter counted as sent")
+	}
+	rw.Write([]byte("abc"))
+	rw.WriteHeader(http.StatusTeapot)
+	if rw.Status() != http.StatusOK || rw.BytesWritten() != 3 || !headerWritten(&statusWriter{ResponseWriter: rw}) {
+		t.Errorf("status %d, %d bytes, want 200 and 3", rw.Status(), rw.BytesWritten())
+	}
+}
+
+func TestResponseWriterHijack(t *testing.T) {
+	r := newTestRender(Options{})
+	srv := httptest.NewServer(r.RecoverHandler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		h, ok := w.(http.Hijacker)
+		if !ok {
+			t.Error("RecoverHandler hid http.Hijacker")
+			return
+		}
+		conn, brw, err := h.Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		if !headerWritten(w) {
+			t.Error("a hijacked response did not count as sent")
+		}
+		brw.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 8\r\nConnection: close\r\n\r\nhijacked")
+		brw.Flush()
+	})))
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	line, _ := bufio.NewReader(res.Body).ReadString('\n')
+	if line != "hijacked" {
+		t.Errorf("body %q, want hijacked", line)
+	}
+
+	rw := NewResponseWriter(httptest.NewRecorder())
+	if _, _, err := rw.Hijack(); err == nil {
+		t.Error("Hijack of a recorder succeeded")
+	}
+	if err := rw.Push("/app.css", nil); !errors.Is(err, http.ErrNotSupported) {
+		t.Errorf("Push of a recorder: %v, want http.ErrNotSupported", err)
+	}
+}
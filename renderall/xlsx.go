@@ -0,0 +1,372 @@
+package renderall
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ContentXLSX header value for Excel workbooks.
+const ContentXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// SheetWriter streams rows into a workbook, one sheet at a time. Wrap
+// excelize's StreamWriter to get styles and formulas; NewXLSXWriter is a
+// dependency free writer covering plain data.
+type SheetWriter interface {
+	// Sheet starts a new worksheet. Rows written before the first call go
+	// to a sheet named "Sheet1".
+	Sheet(name string) error
+	// Row appends a row of cells to the current sheet.
+	Row(cells ...interface{}) error
+	// Close finishes the workbook. It does not close the underlying writer.
+	Close() error
+}
+
+// XLSXOptions is a struct for overriding some rendering Options for specific XLSX call.
+type XLSXOptions struct {
+	// Offers the workbook as a download with this file name. Defaults to blank ("").
+	Filename string
+}
+
+// XLSX built-in renderer. It renders a func(SheetWriter) error building the
+// workbook, a [][]interface{} or [][]string of rows, or a slice of structs
+// with a header row, using the same tags as Table.
+type XLSX struct {
+	Head
+	// NewWriter creates the SheetWriter; defaults to NewXLSXWriter.
+	NewWriter func(io.Writer) SheetWriter
+	Formats   map[string]func(interface{}) string
+	// DisableRedaction writes struct fields tagged `render:"redact"` and
+	// `render:"omit"` as is.
+	DisableRedaction bool
+}
+
+// Render an XLSX response. Rows are streamed to the client as they are
+// written, so errors from a builder func can only abort the download.
+func (x XLSX) Render(w http.ResponseWriter, v interface{}) error {
+	build, err := xlsxBuilder(v, x.Formats, !x.DisableRedaction)
+	if err != nil {
+		return err
+	}
+
+	newWriter := x.NewWriter
+	if newWriter == nil {
+		newWriter = NewXLSXWriter
+	}
+
+	x.Head.Write(w)
+	sw := newWriter(w)
+	if err := build(sw); err != nil {
+		return err
+	}
+	return sw.Close()
+}
+
+// xlsxBuilder returns a func writing v to a workbook, applying the
+// redaction tags to structs if redact.
+func xlsxBuilder(v interface{}, formats map[string]func(interface{}) string, redact bool) (func(SheetWriter) error, error) {
+	switch rows := v.(type) {
+	case func(SheetWriter) error:
+		return rows, nil
+	case [][]interface{}:
+		return func(sw SheetWriter) error {
+			for _, row := range rows {
+				if err := sw.Row(row...); err != nil {
+					return err
+				}
+			}
+			return nil
+		}, nil
+	case [][]string:
+		return func(sw SheetWriter) error {
+			for _, row := range rows {
+				cells := make([]interface{}, len(row))
+				for i, cell := range row {
+					cells[i] = cell
+				}
+				if err := sw.Row(cells...); err != nil {
+					return err
+				}
+			}
+			return nil
+		}, nil
+	}
+
+	columns, items, err := tableRows("xlsx", v, redact)
+	if err != nil {
+		return nil, err
+	}
+	return func(sw SheetWriter) error {
+		header := make([]interface{}, len(columns))
+		for i, col := range columns {
+			header[i] = col.header
+		}
+		if err := sw.Row(header...); err != nil {
+			return err
+		}
+		for _, item := range items {
+			cells := make([]interface{}, len(columns))
+			for i, col := range columns {
+				if col.redact {
+					cells[i] = RedactedValue
+					continue
+				}
+				if item.Kind() != reflect.Struct {
+					continue
+				}
+				field := item.Field(col.index)
+				if col.format != "" {
+					cell, err := tableCell(field, col.format, formats)
+					if err != nil {
+						return err
+					}
+					cells[i] = cell
+				} else if field.Kind() != reflect.Ptr || !field.IsNil() {
+					cells[i] = reflect.Indirect(field).Interface()
+				}
+			}
+			if err := sw.Row(cells...); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, nil
+}
+
+// XLSX streams v as an Excel workbook.
+func (r *Render) XLSX(w http.ResponseWriter, status int, v interface{}, xlsxOpt ...XLSXOptions) error {
+	opt := XLSXOptions{}
+	if len(xlsxOpt) > 0 {
+		opt = xlsxOpt[0]
+	}
+	setAttachment(w.Header(), opt.Filename)
+
+	head := Head{
+		ContentType: ContentXLSX,
+		Status:      status,
+	}
+
+	x := XLSX{
+		Head:      head,
+		NewWriter: r.opt.XLSXWriter,
+		Formats:   r.opt.TableFormats,
+
+		DisableRedaction: r.opt.DisableRedaction,
+	}
+
+	return r.Render(w, x, v)
+}
+
+const (
+	ooxmlMain     = "http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+	ooxmlRels     = "http://schemas.openxmlformats.org/package/2006/relationships"
+	ooxmlDocRels  = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+	ooxmlXMLDecl  = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+	xlsxMaxSheet  = 31
+	xlsxDateStyle = 1
+)
+
+// xlsxWriter is the built-in SheetWriter. Each sheet is a zip entry written
+// as rows arrive; the parts listing them are written on Close.
+type xlsxWriter struct {
+	zw     *zip.Writer
+	sheet  *bufio.Writer
+	sheets []string
+	row    int
+	err    error
+}
+
+// NewXLSXWriter returns a SheetWriter streaming a minimal .xlsx workbook to
+// w. Strings, numbers, booleans, and time.Time cells are stored natively;
+// anything else is written as fmt.Sprint text and nil leaves the cell empty.
+func NewXLSXWriter(w io.Writer) SheetWriter {
+	return &xlsxWriter{zw: zip.NewWriter(w)}
+}
+
+func (x *xlsxWriter) Sheet(name string) error {
+	if x.err != nil {
+		return x.err
+	}
+	if name == "" || len([]rune(name)) > xlsxMaxSheet || strings.ContainsAny(name, `[]:*?/\`) {
+		return fmt.Errorf("renderall: invalid sheet name %q", name)
+	}
+	for _, s := range x.sheets {
+		if strings.EqualFold(s, name) {
+			return fmt.Errorf("renderall: duplicate sheet name %q", name)
+		}
+	}
+	if err := x.endSheet(); err != nil {
+		return err
+	}
+
+	x.sheets = append(x.sheets, name)
+	f, err := x.zw.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", len(x.sheets)))
+	if err != nil {
+		x.err = err
+		return err
+	}
+	x.sheet = bufio.NewWriter(f)
+	x.row = 0
+	x.sheet.WriteString(ooxmlXMLDecl + `<worksheet xmlns="` + ooxmlMain + `"><sheetData>`)
+	return nil
+}
+
+func (x *xlsxWriter) endSheet() error {
+	if x.sheet == nil {
+		return nil
+	}
+	x.sheet.WriteString(`</sheetData></worksheet>`)
+	if err := x.sheet.Flush(); err != nil {
+		x.err = err
+		return err
+	}
+	x.sheet = nil
+	return nil
+}
+
+func (x *xlsxWriter) Row(cells ...interface{}) error {
+	if x.err != nil {
+		return x.err
+	}
+	if x.sheet == nil {
+		if err := x.Sheet(fmt.Sprintf("Sheet%d", len(x.sheets)+1)); err != nil {
+			return err
+		}
+	}
+
+	x.row++
+	b := x.sheet
+	fmt.Fprintf(b, `<row r="%d">`, x.row)
+	for i, cell := range cells {
+		ref := xlsxColumn(i) + strconv.Itoa(x.row)
+		switch c := cell.(type) {
+		case nil:
+			continue
+		case string:
+			xlsxInline(b, ref, c)
+		case bool:
+			v := "0"
+			if c {
+				v = "1"
+			}
+			fmt.Fprintf(b, `<c r="%s" t="b"><v>%s</v></c>`, ref, v)
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+			fmt.Fprintf(b, `<c r="%s"><v>%d</v></c>`, ref, c)
+		case float32:
+			xlsxFloat(b, ref, float64(c), 32)
+		case float64:
+			xlsxFloat(b, ref, c, 64)
+		case time.Time:
+			fmt.Fprintf(b, `<c r="%s" s="%d"><v>%s</v></c>`, ref, xlsxDateStyle, strconv.FormatFloat(excelSerial(c), 'f', -1, 64))
+		default:
+			xlsxInline(b, ref, fmt.Sprint(c))
+		}
+	}
+	b.WriteString(`</row>`)
+	return nil
+}
+
+func (x *xlsxWriter) Close() error {
+	if x.err != nil {
+		return x.err
+	}
+	if len(x.sheets) == 0 {
+		if err := x.Sheet("Sheet1"); err != nil {
+			return err
+		}
+	}
+	if err := x.endSheet(); err != nil {
+		return err
+	}
+
+	var workbook, workbookRels, types strings.Builder
+	workbook.WriteString(ooxmlXMLDecl + `<workbook xmlns="` + ooxmlMain + `" xmlns:r="` + ooxmlDocRels + `"><sheets>`)
+	workbookRels.WriteString(ooxmlXMLDecl + `<Relationships xmlns="` + ooxmlRels + `">`)
+	types.WriteString(ooxmlXMLDecl + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	for i, name := range x.sheets {
+		n := i + 1
+		workbook.WriteString(`<sheet name="`)
+		xml.EscapeText(&workbook, []byte(name))
+		fmt.Fprintf(&workbook, `" sheetId="%d" r:id="rId%d"/>`, n, n)
+		fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" Type="%s/worksheet" Target="worksheets/sheet%d.xml"/>`, n, ooxmlDocRels, n)
+		fmt.Fprintf(&types, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, n)
+	}
+	workbook.WriteString(`</sheets></workbook>`)
+	fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" Type="%s/styles" Target="styles.xml"/></Relationships>`, len(x.sheets)+1, ooxmlDocRels)
+	types.WriteString(`</Types>`)
+
+	parts := []struct{ name, body string }{
+		{"xl/workbook.xml", workbook.String()},
+		{"xl/_rels/workbook.xml.rels", workbookRels.String()},
+		// Style 1 is the built-in date and time number format 22.
+		{"xl/styles.xml", ooxmlXMLDecl + `<styleSheet xmlns="` + ooxmlMain + `">` +
+			`<fonts count="1"><font><sz val="11"/><name val="Calibri"/></font></fonts>` +
+			`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+			`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+			`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+			`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+			`<xf numFmtId="22" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/></cellXfs>` +
+			`</styleSheet>`},
+		{"_rels/.rels", ooxmlXMLDecl + `<Relationships xmlns="` + ooxmlRels + `">` +
+			`<Relationship Id="rId1" Type="` + ooxmlDocRels + `/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+		{"[Content_Types].xml", types.String()},
+	}
+	for _, part := range parts {
+		f, err := x.zw.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, part.body); err != nil {
+			return err
+		}
+	}
+	return x.zw.Close()
+}
+
+// xlsxInline writes a string cell stored inline rather than in a shared
+// strings table, which keeps the writer single pass.
+// xlsxFloat writes a number cell, or, for NaN and infinities, which numeric
+// cells cannot hold, a text cell.
+func xlsxFloat(b *bufio.Writer, ref string, f float64, bitSize int) {
+	v := strconv.FormatFloat(f, 'g', -1, bitSize)
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		xlsxInline(b, ref, v)
+		return
+	}
+	fmt.Fprintf(b, `<c r="%s"><v>%s</v></c>`, ref, v)
+}
+
+func xlsxInline(b *bufio.Writer, ref, s string) {
+	fmt.Fprintf(b, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+	xml.EscapeText(b, []byte(s))
+	b.WriteString(`</t></is></c>`)
+}
+
+// xlsxColumn returns the column letters for the zero based index i.
+func xlsxColumn(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// excelSerial converts t to Excel's serial date, days since 1899-12-30 in
+// t's own time zone.
+func excelSerial(t time.Time) float64 {
+	y, m, d := t.Date()
+	local := time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	return local.Sub(time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)).Hours() / 24
+}
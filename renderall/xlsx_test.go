@@ -0,0 +1,88 @@
+package renderall
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"math"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// xlsxPart returns the named part of the workbook b.
+func xlsxPart(t *testing.T, b []byte, name string) string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := zr.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestXLSXCells(t *testing.T) {
+	var buf bytes.Buffer
+	sw := NewXLSXWriter(&buf)
+	if err := sw.Sheet("Data"); err != nil {
+		t.Fatal(err)
+	}
+	date := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+	if err := sw.Row("a<b", 7, 1.5, true, nil, math.NaN(), math.Inf(-1), date); err != nil {
+		t.Fatal(err)
+	}
+	if err := sw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	sheet := xlsxPart(t, buf.Bytes(), "xl/worksheets/sheet1.xml")
+	for _, want := range []string{
+		`<c r="A1" t="inlineStr"><is><t xml:space="preserve">a&lt;b</t></is></c>`,
+		`<c r="B1"><v>7</v></c>`,
+		`<c r="C1"><v>1.5</v></c>`,
+		`<c r="D1" t="b"><v>1</v></c>`,
+		`<c r="F1" t="inlineStr"><is><t xml:space="preserve">NaN</t></is></c>`,
+		`<c r="G1" t="inlineStr"><is><t xml:space="preserve">-Inf</t></is></c>`,
+		`<c r="H1" s="1"><v>45293.5</v></c>`,
+	} {
+		if !strings.Contains(sheet, want) {
+			t.Errorf("sheet lacks %s:\n%s", want, sheet)
+		}
+	}
+	if strings.Contains(sheet, `r="E1"`) {
+		t.Error("nil cell written")
+	}
+	if workbook := xlsxPart(t, buf.Bytes(), "xl/workbook.xml"); !strings.Contains(workbook, `<sheet name="Data" sheetId="1" r:id="rId1"/>`) {
+		t.Errorf("workbook = %s", workbook)
+	}
+	if err := NewXLSXWriter(io.Discard).Sheet("a/b"); err == nil {
+		t.Error("invalid sheet name accepted")
+	}
+}
+
+func TestXLSXStructs(t *testing.T) {
+	r := New(Options{})
+	w := httptest.NewRecorder()
+	if err := r.XLSX(w, 200, []tableAccount{{"ann", "secret", 7}}, XLSXOptions{Filename: "accounts.xlsx"}); err != nil {
+		t.Fatal(err)
+	}
+	if ct := w.Header().Get(ContentType); ct != ContentXLSX {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if cd := w.Header().Get("Content-Disposition"); !strings.Contains(cd, "accounts.xlsx") {
+		t.Errorf("Content-Disposition = %q", cd)
+	}
+	sheet := xlsxPart(t, w.Body.Bytes(), "xl/worksheets/sheet1.xml")
+	if !strings.Contains(sheet, ">Password<") || !strings.Contains(sheet, ">"+RedactedValue+"<") || strings.Contains(sheet, "secret") || strings.Contains(sheet, "Internal") {
+		t.Errorf("sheet = %s", sheet)
+	}
+}
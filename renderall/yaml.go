@@ -0,0 +1,197 @@
+package renderall
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ContentYAML header value for YAML data.
+const ContentYAML = "application/yaml"
+
+// YAMLOptions is a struct for overriding some rendering Options for specific YAML call.
+type YAMLOptions struct {
+	// Request being served, for PreRender hooks and request IDs. Defaults to nil.
+	Request *http.Request
+}
+
+// YAML built-in renderer. It writes the value's JSON form as block style
+// YAML, so json tags and marshalers apply as they do to JSON.
+type YAML struct {
+	Head
+	Hook MarshalHook
+}
+
+// Render a YAML response.
+func (y YAML) Render(w http.ResponseWriter, v interface{}) error {
+	return renderEncoded(w, y.Head, y, v)
+}
+
+// Encode writes v as a YAML document to w.
+func (y YAML) Encode(w io.Writer, v interface{}) (Encoded, error) {
+	return encodeBuffered(w, y, y.Head.ContentType, v)
+}
+
+// encodeBuffer writes v as a YAML document to buf.
+func (y YAML) encodeBuffer(buf *bytes.Buffer, v interface{}) error {
+	tree, err := jsonTree(y.Hook, v)
+	if err != nil {
+		return err
+	}
+	return writeYAML(buf, tree, 0)
+}
+
+// writeYAML writes v as lines indented by indent spaces. Non-empty objects
+// and arrays are blocks; everything else is a single scalar line.
+func writeYAML(buf *bytes.Buffer, v interface{}, indent int) error {
+	pad := strings.Repeat(" ", indent)
+	switch t := v.(type) {
+	case *jsonObject:
+		if len(t.keys) == 0 {
+			break
+		}
+		for _, k := range t.keys {
+			buf.WriteString(pad + yamlScalar(k) + ":")
+			if err := writeYAMLValue(buf, t.vals[k], indent+2); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []interface{}:
+		if len(t) == 0 {
+			break
+		}
+		for _, item := range t {
+			if yamlBlock(item) {
+				// Nest the block two deeper and put the dash in its first
+				// line's indentation.
+				var child bytes.Buffer
+				if err := writeYAML(&child, item, indent+2); err != nil {
+					return err
+				}
+				buf.WriteString(pad + "- ")
+				buf.Write(child.Bytes()[indent+2:])
+				continue
+			}
+			buf.WriteString(pad + "-")
+			if err := writeYAMLValue(buf, item, indent+2); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	s, err := yamlValue(v)
+	if err != nil {
+		return err
+	}
+	buf.WriteString(pad + s + "\n")
+	return nil
+}
+
+// writeYAMLValue writes v after a key or dash: a block on the following
+// lines, or a scalar on the same line.
+func writeYAMLValue(buf *bytes.Buffer, v interface{}, indent int) error {
+	if yamlBlock(v) {
+		buf.WriteByte('\n')
+		return writeYAML(buf, v, indent)
+	}
+	s, err := yamlValue(v)
+	if err != nil {
+		return err
+	}
+	buf.WriteString(" " + s + "\n")
+	return nil
+}
+
+// yamlBlock reports whether v is written as a block.
+func yamlBlock(v interface{}) bool {
+	switch t := v.(type) {
+	case *jsonObject:
+		return len(t.keys) > 0
+	case []interface{}:
+		return len(t) > 0
+	}
+	return false
+}
+
+// yamlValue is the inline form of a scalar or empty collection.
+func yamlValue(v interface{}) (string, error) {
+	switch t := v.(type) {
+	case nil:
+		return "null", nil
+	case bool:
+		if t {
+			return "true", nil
+		}
+		return "false", nil
+	case json.Number:
+		return t.String(), nil
+	case string:
+		return yamlScalar(t), nil
+	case *jsonObject:
+		return "{}", nil
+	case []interface{}:
+		return "[]", nil
+	}
+	return "", fmt.Errorf("renderall: YAML cannot encode %T", v)
+}
+
+// yamlScalar writes s plain when YAML reads it back as the same string, and
+// double quoted otherwise. JSON string syntax is valid YAML.
+func yamlScalar(s string) string {
+	if yamlPlain(s) {
+		return s
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// yamlPlain reports whether s can be written unquoted: words of letters,
+// digits, and a few punctuation marks that do not start with an indicator or
+// read as another type.
+func yamlPlain(s string) bool {
+	if s == "" || s != strings.TrimSpace(s) {
+		return false
+	}
+	switch strings.ToLower(s) {
+	case "null", "~", "true", "false", "yes", "no", "on", "off", "y", "n", ".inf", "-.inf", ".nan":
+		return false
+	}
+	for i, c := range s {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_':
+		case c >= '0' && c <= '9', c == '.', c == '/', c == '-', c == ' ':
+			if i == 0 {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// YAML marshals the given interface object and writes the YAML response.
+func (r *Render) YAML(w http.ResponseWriter, status int, v interface{}, yamlOpt ...YAMLOptions) error {
+	opt := YAMLOptions{}
+	if len(yamlOpt) > 0 {
+		opt = yamlOpt[0]
+	}
+
+	head := Head{
+		ContentType: r.withCharset(ContentYAML),
+		Status:      status,
+	}
+
+	y := YAML{
+		Head: head,
+		Hook: r.marshalHook(),
+	}
+	return r.render(w, opt.Request, y, v)
+}